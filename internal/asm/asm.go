@@ -35,8 +35,11 @@ debatable if ANTLR4 would be better.
 package asm
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/smoynes/elsie/internal/vm"
@@ -45,18 +48,30 @@ import (
 // Grammar declares the syntax of LC3ASM in EBNF (with some liberties).
 var Grammar = (`
 program      = { line } ;
-line         = ';' comment
-             | label ':' [ ';' comment ]
-             | label [ ':' ] instruction [ ';' comment ]
-             | '.' directive [ ';' comment ]
-             | instruction   [ ';' comment ] ;
-comment      = { char } ;
-directive    = "ORIG" literal
-             | "DW" literal
-             | "FILL" literal
-             | "BLKW" literal
+line         = comment
+             | label ':' [ comment ]
+             | label [ ':' ] instruction [ comment ]
+             | '.' directive [ comment ]
+             | label '.' "EQU" literal [ comment ]
+             | '.' "MACRO" ident { [ ',' ] ident } { line } '.' "ENDM"
+             | instruction   [ comment ] ;
+comment      = ( ';' | "//" ) { char } ;
+directive    = "ORIG" value
+             | "RADIX" literal
+             | "DW" value { ',' value }
+             | "FILL" value { ',' value }
+             | "BLKW" literal [ ',' literal ]
+             | ( "SPACE" | "ZERO" ) literal
              | "STRINGZ" literal
+             | "STRINGP" literal
+             | "INCBIN" literal
+             | "EQU" literal
+             | "ENTRY" label
+             | "EXTERNAL" label
+             | "TRAP" literal ',' label
              | "END" ;
+value        = term { ( '+' | '-' ) term } ;
+term         = [ '-' ] ( literal | label ) ;
 ident        = \p{Letter} { identchar } ;
 label        = ident ;
 instruction  = opcode [ operands ] ;
@@ -68,8 +83,10 @@ operand      = immediate
 immediate    = '#' integer
              | 'x' hex { hex }
              | 'o' octal { octal }
-             | 'b' binary { binary } ;
-register     = 'R' octal ;
+             | 'b' binary { binary }
+             | "'" ( char | escape ) "'" ;
+register     = 'R' octal
+             | "SP" | "LR" | "RET" ;
 indirect     = '[' ( identifier | literal | register ) ']' ;
 binary       = '0' | '1' | '_' ;
 octal        = '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '_' ;
@@ -77,6 +94,7 @@ decimal      = '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9' | '_' ;
 hex          = decimal
              | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'A' | 'B' | 'C' | 'D' | 'E' | 'F' ;
 integer      = [ '-' ] decimal { decimal } ;
+escape       = '\' ( 'n' | 't' | 'r' | '0' | '\' | "'" ) ;
 identchar    = \p{Letter}
              | \p{Decimal Digits}
 		     | \p{Marks}
@@ -93,14 +111,74 @@ func (s SymbolTable) Count() int {
 	return len(s)
 }
 
-// Add adds a symbol to the symbol table.
-func (s SymbolTable) Add(sym string, loc vm.Word) {
+// Add adds a symbol to the symbol table. If the symbol is already defined at a different location,
+// it is redefined and a DuplicateSymbolError is returned.
+func (s SymbolTable) Add(sym string, loc vm.Word) error {
 	if sym == "" {
 		panic("empty symbol")
 	}
 
 	sym = strings.ToUpper(sym)
+	prev, ok := s[sym]
 	s[sym] = loc
+
+	if ok && prev != loc {
+		return &DuplicateSymbolError{Symbol: sym, Loc: loc, Prev: prev}
+	}
+
+	return nil
+}
+
+// Labels returns the reverse of the symbol table: a map of locations to the symbols that name them.
+// If several symbols have the same location, the first in alphabetical order names it.
+func (s SymbolTable) Labels() map[vm.Word]string {
+	labels := make(map[vm.Word]string, len(s))
+
+	for name, loc := range s {
+		if prev, ok := labels[loc]; !ok || name < prev {
+			labels[loc] = name
+		}
+	}
+
+	return labels
+}
+
+// ReadSymbolTable reads a symbol table in the format written by Generator.WriteSymbolTable. Each
+// symbol is on a comment line with its name followed by its hexadecimal address. Other comment
+// lines, e.g. the header, and blank lines are skipped.
+func ReadSymbolTable(in io.Reader) (SymbolTable, error) {
+	var (
+		symbols = SymbolTable{}
+		scanner = bufio.NewScanner(in)
+		line    = 0
+	)
+
+	for scanner.Scan() {
+		line++
+
+		text, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "//")
+		fields := strings.Fields(text)
+
+		if !ok && len(fields) > 0 {
+			return nil, fmt.Errorf("symbols: line %d: %w: %q", line, ErrOperand, scanner.Text())
+		} else if len(fields) != 2 || !isSymbol(fields[0]) {
+			continue
+		}
+
+		addr, err := strconv.ParseUint(fields[1], 16, 16)
+		if err != nil {
+			continue // Not a symbol, e.g. the header.
+		}
+
+		// Symbols may be defined at the same address, so duplicates are not an error.
+		_ = symbols.Add(fields[0], vm.Word(addr))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("symbols: %w", err)
+	}
+
+	return symbols, nil
 }
 
 // Offset computes a n-bit program-counter relative offset. If the offset can be
@@ -117,26 +195,26 @@ func (s SymbolTable) Offset(sym string, pc vm.Word, n uint8) (vm.Word, error) {
 	}
 
 	var (
-		delta = int16(loc - pc)
+		delta = int(int16(loc - pc))
+
+		// An n-bit offset is sign-extended by the CPU, so its range is that of a signed n-bit
+		// integer, e.g. if n == 9 then -256 <= delta <= 255.
+		high = 1<<(n-1) - 1
+		low  = -(1 << (n - 1))
 
 		// The bottom n bits are set to 1, eg. if n ==
-		// 9 then bottom == 0x01ff.
-		bottom = int16(1<<n - 1)
+		// 9 then mask == 0x01ff.
+		mask = vm.Word(1<<n - 1)
 	)
 
-	if delta > bottom {
+	if delta > high || delta < low {
 		return badSymbol, &OffsetRangeError{
 			Offset: uint16(delta),
-			Range:  uint16(bottom),
-		}
-	} else if delta <= -bottom {
-		return badSymbol, &OffsetRangeError{
-			Offset: uint16(delta),
-			Range:  uint16(bottom),
+			Range:  uint16(high),
 		}
 	}
 
-	return vm.Word(delta) & vm.Word(bottom), nil
+	return vm.Word(delta) & mask, nil
 }
 
 const badSymbol vm.Word = 0xffff
@@ -150,6 +228,26 @@ var (
 
 	// ErrLiteral causes a SyntaxError if the literal operand is invalid.
 	ErrLiteral = errors.New("literal error")
+
+	// ErrConstant causes a SyntaxError if a constant is redefined or is used in place of a label.
+	ErrConstant = errors.New("constant error")
+
+	// ErrMacro causes a SyntaxError if a macro is invalid, incorrectly invoked or recursive.
+	ErrMacro = errors.New("macro error")
+
+	// ErrLimit causes a SyntaxError if a line is longer, or has more operands, than the parser's
+	// limits.
+	ErrLimit = errors.New("limit exceeded")
+
+	// ErrOrigin causes a SyntaxError if code or data appears before the first .ORIG directive.
+	ErrOrigin = errors.New("origin error")
+
+	// ErrOverlap is returned by the generator if the code in two sections share an address.
+	ErrOverlap = errors.New("section overlap")
+
+	// ErrVerify is returned by the generator if generated code does not survive a round-trip
+	// through the disassembler and assembler.
+	ErrVerify = errors.New("verify error")
 )
 
 // SyntaxError is a wrapped error returned when the assembler encounters a syntax error. If fields
@@ -159,20 +257,30 @@ type SyntaxError struct {
 	File string  // Source file name.
 	Loc  vm.Word // Location counter.
 	Pos  vm.Word // Line counter.
+	Col  vm.Word // Column of the token in the line, counting bytes from 1.
 	Line string  // Source code line.
 	Err  error   // Error cause.
 }
 
 func (se *SyntaxError) Error() string {
-	if se.Err == nil && se.Line == "" {
-		return fmt.Sprintf("syntax error: loc: %0#4x", se.Loc)
-	} else if se.Err == nil && se.Line != "" {
+	switch {
+	case se.Err == nil && se.Line == "":
+		return fmt.Sprintf("syntax error: loc: %0#4x", uint16(se.Loc))
+	case se.Err == nil && se.Line != "":
 		return fmt.Sprintf("syntax error: line: %q", se.Line)
-	} else {
-		return fmt.Sprintf("syntax error: %s: line: %0#4x %q", se.Err, se.Pos, se.Line)
+	case se.Col != 0:
+		return fmt.Sprintf("syntax error: %s: line: %0#4x col %d %q",
+			se.Err, uint16(se.Pos), se.Col, se.Line)
+	default:
+		return fmt.Sprintf("syntax error: %s: line: %0#4x %q", se.Err, uint16(se.Pos), se.Line)
 	}
 }
 
+// Unwrap returns the error cause.
+func (se *SyntaxError) Unwrap() error {
+	return se.Err
+}
+
 // Is checks if SyntaxError's error-tree matches a target error.
 func (se *SyntaxError) Is(target error) bool {
 	if errors.Is(se.Err, target) {
@@ -245,6 +353,34 @@ func (se *SymbolError) Is(err error) bool {
 	return false
 }
 
+// DuplicateSymbolError is returned when a symbol is defined more than once at different locations.
+type DuplicateSymbolError struct {
+	Symbol string
+	Loc    vm.Word // Location of the redefinition.
+	Prev   vm.Word // Location of the previous definition.
+}
+
+func (de *DuplicateSymbolError) Error() string {
+	return fmt.Sprintf("duplicate symbol: %q: defined at %s and %s", de.Symbol, de.Prev, de.Loc)
+}
+
+// Section is a block of code or data delimited by .ORIG and .END directives. Each section is placed
+// at its own origin address.
+type Section struct {
+	Orig vm.Word // Origin address.
+	Size vm.Word // Number of words in the section.
+}
+
+// Overlaps returns true if the sections share any address.
+func (s Section) Overlaps(other Section) bool {
+	if s.Size == 0 || other.Size == 0 {
+		return false
+	}
+
+	return uint32(s.Orig) < uint32(other.Orig)+uint32(other.Size) &&
+		uint32(other.Orig) < uint32(s.Orig)+uint32(s.Size)
+}
+
 // SyntaxTable is holds the parsed code and data indexed by its location counter.
 type SyntaxTable []Operation
 
@@ -262,6 +398,32 @@ func (s *SyntaxTable) Add(oper Operation) {
 	*s = append(*s, oper)
 }
 
+// Source returns the i'th operation with its source metadata. Operations that were not added by the
+// parser are wrapped in a SourceInfo with zero values.
+func (s SyntaxTable) Source(i int) *SourceInfo {
+	if si, ok := s[i].(*SourceInfo); ok {
+		return si
+	}
+
+	return &SourceInfo{Operation: s[i]}
+}
+
+// Walk calls fn for each operation in the table, in order, with its source metadata. If fn returns
+// an error, the walk stops and the error is returned.
+func (s SyntaxTable) Walk(fn func(si *SourceInfo) error) error {
+	for i := range s {
+		if s[i] == nil {
+			continue
+		}
+
+		if err := fn(s.Source(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Operation is an assembly instruction or directive. It is parsed from source code during the
 // assembler's first pass and encoded to object code in the second pass.
 type Operation interface {
@@ -277,6 +439,7 @@ type Operation interface {
 // SourceInfo wraps an operation to annotate it with parser metadata.
 type SourceInfo struct {
 	Filename string
+	Loc      vm.Word // Location counter, i.e. the address of the operation's code.
 	Pos      vm.Word
 	Line     string
 
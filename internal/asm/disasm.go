@@ -0,0 +1,60 @@
+package asm
+
+// disasm.go disassembles code with symbols.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// DisassembleWithSymbols disassembles code located at an origin, one statement per word. Operands of
+// PC-relative instructions, e.g. BR, LD and JSR, are written as the label of their target, if the
+// symbol table has one. Otherwise, the operand is written as a numeric offset, followed by a comment
+// with the target's address. If several symbols label a target, the first in sort order is used.
+func DisassembleWithSymbols(code []vm.Word, orig vm.Word, symbols SymbolTable) []string {
+	labels := symbols.Labels()
+
+	lines := make([]string, len(code))
+
+	for i, word := range code {
+		var (
+			loc    = orig + vm.Word(i)
+			ins    = vm.Instruction(word)
+			disasm = ins.Disassemble()
+		)
+
+		target, ok := relativeTarget(ins, loc)
+		if !ok {
+			lines[i] = disasm
+			continue
+		}
+
+		// The offset is the last operand.
+		operand := strings.LastIndexAny(disasm, " ,") + 1
+
+		if label, ok := labels[target]; ok {
+			lines[i] = disasm[:operand] + label
+		} else {
+			lines[i] = fmt.Sprintf("%s ; x%04X", disasm, uint16(target))
+		}
+	}
+
+	return lines
+}
+
+// relativeTarget returns the target address of a PC-relative or indirect instruction located at an
+// address. A BR without condition codes never branches and has no target.
+func relativeTarget(ins vm.Instruction, loc vm.Word) (vm.Word, bool) {
+	dec := ins.Decode(loc + 1)
+
+	switch {
+	case dec.Opcode == vm.BR && dec.Cond == 0:
+		return 0, false // NOP
+	case dec.Mode == vm.ModePCRelative, dec.Mode == vm.ModeIndirect:
+		return dec.Target, true
+	default:
+		return 0, false
+	}
+}
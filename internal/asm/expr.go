@@ -0,0 +1,111 @@
+package asm
+
+// expr.go implements simple arithmetic expressions for data directives.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// exprTerm is a term in an expression: either a literal or a symbol, optionally negated.
+type exprTerm struct {
+	neg bool
+	sym string
+	lit uint16
+}
+
+// parseExpression parses an expression: terms separated by '+' or '-' operators. Each term is a
+// literal or a symbol and may be negated with a unary minus. Expressions are evaluated left to
+// right, e.g.
+//
+//	LABEL+2
+//	END-START
+//	-OFFSET+x10
+func parseExpression(expr string) ([]exprTerm, error) {
+	var (
+		terms []exprTerm
+		neg   bool
+		start = 0
+	)
+
+	for i := 0; i <= len(expr); i++ {
+		if i < len(expr) && expr[i] != '+' && expr[i] != '-' {
+			continue
+		}
+
+		text := strings.TrimSpace(expr[start:i])
+
+		switch {
+		case text == "" && i < len(expr) && expr[i] == '-':
+			// Unary minus.
+			neg = !neg
+			start = i + 1
+
+			continue
+		case text == "":
+			return nil, fmt.Errorf("%w: expression: %q", ErrOperand, expr)
+		}
+
+		term := exprTerm{neg: neg}
+
+		if lit, err := parseLiteral(strings.TrimPrefix(text, "#"), 16); err == nil {
+			term.lit = lit
+		} else if isSymbol(text) {
+			term.sym = strings.ToUpper(text)
+		} else {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+
+		neg = i < len(expr) && expr[i] == '-'
+		start = i + 1
+	}
+
+	return terms, nil
+}
+
+// hasSymbol returns true if any of the terms of an expression is a symbol.
+func hasSymbol(terms []exprTerm) bool {
+	for _, term := range terms {
+		if term.sym != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evalExpression evaluates an expression, resolving symbols to their addresses. Arithmetic wraps
+// around, as it does in the machine. A SymbolError is returned for undefined symbols.
+func evalExpression(expr string, symbols SymbolTable, loc vm.Word) (vm.Word, error) {
+	terms, err := parseExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	var val vm.Word
+
+	for _, term := range terms {
+		word := vm.Word(term.lit)
+
+		if term.sym != "" {
+			addr, ok := symbols[term.sym]
+			if !ok {
+				return 0, &SymbolError{Symbol: term.sym, Loc: loc}
+			}
+
+			word = addr
+		}
+
+		if term.neg {
+			val -= word
+		} else {
+			val += word
+		}
+	}
+
+	return val, nil
+}
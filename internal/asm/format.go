@@ -0,0 +1,245 @@
+package asm
+
+// format.go holds a source-code formatter.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+// FormatOption configures the formatter.
+type FormatOption func(*formatter)
+
+// WithColumns sets the columns, counting from zero, at which opcodes, operands and end-of-line
+// comments are aligned. The defaults are 8, 16 and 32.
+func WithColumns(opcode, operands, comment int) FormatOption {
+	return func(f *formatter) {
+		f.opcodeCol, f.operandCol, f.commentCol = opcode, operands, comment
+	}
+}
+
+// WithTabs configures the formatter to align columns with tabs of the given width, padding with
+// spaces only when a column is not at a tab stop. By default, only spaces are used.
+func WithTabs(width int) FormatOption {
+	return func(f *formatter) {
+		f.tabWidth = width
+	}
+}
+
+// formatter holds the layout of formatted source.
+type formatter struct {
+	opcodeCol  int // Column of opcodes and directives.
+	operandCol int // Column of operands.
+	commentCol int // Column of end-of-line comments.
+	tabWidth   int // Width of tabs, or zero to pad with spaces.
+
+	parser *Parser // Parser of the unformatted source.
+}
+
+// Format reads source code and writes it with consistent alignment: labels in the first column,
+// then opcodes, operands and comments, each in their own column. Labels and directives are written
+// in upper case. Full-line comments and blank lines are kept as they are, except that indented
+// comments are aligned with opcodes.
+//
+// Format is a reformatter, not a rewriter: the formatted source is parsed again and, if its syntax
+// table or symbols differ from the original, an error is returned and nothing is written. Likewise,
+// source that cannot be parsed is not formatted.
+func Format(out io.Writer, in io.Reader, opts ...FormatOption) error {
+	f := &formatter{
+		opcodeCol:  8,
+		operandCol: 16,
+		commentCol: 32,
+	}
+
+	for _, fn := range opts {
+		fn(f)
+	}
+
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+
+	name := ""
+	if file, ok := in.(interface{ Name() string }); ok {
+		name = file.Name()
+	}
+
+	f.parser = NewParser(log.DefaultLogger())
+	f.parser.Parse(namedReader{bytes.NewReader(src), name})
+
+	if err := f.parser.Err(); err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+
+	var (
+		formatted bytes.Buffer
+		lines     = bufio.NewScanner(bytes.NewReader(src))
+	)
+
+	for lines.Scan() {
+		formatted.WriteString(f.formatLine(lines.Text()))
+		formatted.WriteByte('\n')
+	}
+
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("format: %w", err)
+	} else if err := f.verify(namedReader{bytes.NewReader(formatted.Bytes()), name}); err != nil {
+		return err
+	}
+
+	_, err = formatted.WriteTo(out)
+
+	return err
+}
+
+// formatLine formats a single line of source.
+func (f *formatter) formatLine(line string) string {
+	code := strings.TrimSpace(line)
+	comment := ""
+
+	if i := indexComment(code); i >= 0 {
+		code, comment = strings.TrimSpace(code[:i]), code[i:]
+	}
+
+	if code == "" && comment == "" {
+		return ""
+	} else if code == "" && !strings.HasPrefix(line, comment) {
+		return f.pad("", f.opcodeCol) + comment
+	} else if code == "" {
+		return comment
+	}
+
+	label := ""
+
+	if matched := labelPattern.FindStringSubmatchIndex(code); len(matched) > 1 {
+		ident := strings.ToUpper(code[matched[2]:matched[3]])
+
+		if !f.parser.isReservedKeyword(ident) {
+			// Keep the colon, if any, that follows the label.
+			label = ident + strings.TrimSpace(code[matched[3]:matched[1]])
+			code = code[matched[1]:]
+		}
+	}
+
+	var opcode, operands string
+
+	if matched := directivePattern.FindStringSubmatch(code); len(matched) > 2 {
+		opcode, operands = strings.ToUpper(matched[1]), strings.TrimSpace(matched[2])
+	} else if matched := instructionPattern.FindStringSubmatch(code); len(matched) > 2 {
+		opcode, operands = matched[1], strings.TrimSpace(matched[2])
+	}
+
+	if operands != "" {
+		split := splitUnquoted(operands, ',')
+
+		for i := range split {
+			split[i] = strings.TrimSpace(split[i])
+		}
+
+		operands = strings.Join(split, ",")
+	}
+
+	text := label
+
+	if opcode != "" {
+		text = f.pad(text, f.opcodeCol) + opcode
+	}
+
+	if operands != "" {
+		text = f.pad(text, f.operandCol) + operands
+	}
+
+	if comment != "" {
+		text = f.pad(text, f.commentCol) + comment
+	}
+
+	return text
+}
+
+// pad returns text padded to a column or, if the text already reaches the column, with a single
+// space.
+func (f *formatter) pad(text string, col int) string {
+	width := f.width(text)
+
+	if width >= col {
+		if text == "" {
+			return text
+		}
+
+		return text + " "
+	}
+
+	var b strings.Builder
+
+	b.WriteString(text)
+
+	for f.tabWidth > 0 && (width/f.tabWidth+1)*f.tabWidth <= col {
+		b.WriteByte('\t')
+		width = (width/f.tabWidth + 1) * f.tabWidth
+	}
+
+	b.WriteString(strings.Repeat(" ", col-width))
+
+	return b.String()
+}
+
+// width returns the number of columns that text occupies, expanding tabs to the next tab stop.
+func (f *formatter) width(text string) int {
+	width := 0
+
+	for _, r := range text {
+		if r == '\t' && f.tabWidth > 0 {
+			width = (width/f.tabWidth + 1) * f.tabWidth
+		} else {
+			width++
+		}
+	}
+
+	return width
+}
+
+// verify parses the formatted source and checks that it produces the same symbols and syntax as
+// the original.
+func (f *formatter) verify(formatted io.Reader) error {
+	parser := NewParser(f.parser.log)
+	parser.Parse(formatted)
+
+	if err := parser.Err(); err != nil {
+		return fmt.Errorf("%w: format: %w", ErrVerify, err)
+	} else if !reflect.DeepEqual(parser.Symbols(), f.parser.Symbols()) {
+		return fmt.Errorf("%w: format: symbols differ", ErrVerify)
+	}
+
+	want, got := f.parser.Syntax(), parser.Syntax()
+
+	if len(want) != len(got) {
+		return fmt.Errorf("%w: format: want: %d operations, got: %d", ErrVerify, len(want), len(got))
+	}
+
+	for i := range want {
+		if want.Source(i).Loc != got.Source(i).Loc ||
+			!reflect.DeepEqual(unwrap(want[i]), unwrap(got[i])) {
+			return fmt.Errorf("%w: format: line %d: %q: formatted as: %q",
+				ErrVerify, want.Source(i).Pos, want.Source(i).Line, got.Source(i).Line)
+		}
+	}
+
+	return nil
+}
+
+// namedReader is a reader with a filename, so that the parser can resolve paths in the source.
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+func (r namedReader) Name() string {
+	return r.name
+}
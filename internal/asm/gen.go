@@ -3,10 +3,15 @@ package asm
 // gen.go contains a code generation pass for our two-pass assembler.
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/smoynes/elsie/internal/encoding"
 	"github.com/smoynes/elsie/internal/vm"
@@ -16,7 +21,8 @@ import (
 //
 // The generator starts at the beginning of the parsed-syntax table, generates code for each
 // operation, and then writes the generated code to the output (usually, a file). Use Encode to
-// write as hex-encoded ASCII files or use WriteTo write to binary object-code to a buffer.
+// write as hex-encoded ASCII files, WriteTo to write binary object-code, or WriteBinary to write
+// ASCII binary. WriteSymbolTable writes a companion symbol file.
 //
 // During the generation pass, any syntax or semantic errors that prevent generating machine code
 // are immediately returned. The errors are wrapped in SyntaxErrors and may be tested and retrieved
@@ -26,127 +32,751 @@ type Generator struct {
 	symbols  SymbolTable
 	syntax   SyntaxTable
 	encoding encoding.HexEncoding
+	progress ProgressFunc
+	pool     bool      // Whether out-of-range LEA operations use a literal pool.
+	header   bool      // Whether binary object code begins with a header.
+	checksum bool      // Whether binary object code ends with a checksum.
+	pending  []poolRef // Pool references in the current section.
+
+	externals   map[string]bool // Symbols declared by .EXTERNAL directives.
+	relocations []Relocation    // References to external symbols.
+
+	trapCheck bool           // Whether TRAP vectors are checked.
+	vectors   map[uint8]bool // Registered trap vectors.
+	traps     []*TRAPVEC     // Trap-table entries declared by .TRAP directives.
+	warnings  []Warning      // Advisory diagnostics.
+}
+
+// Warning is an advisory diagnostic. Unlike errors, warnings do not prevent generating code.
+type Warning struct {
+	File string  // Source file name.
+	Loc  vm.Word // Location counter.
+	Pos  vm.Word // Line counter.
+	Line string  // Source code line.
+	Msg  string  // Warning message.
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("warning: %s: line: %0#4x %q", w.Msg, uint16(w.Pos), w.Line)
+}
+
+// Relocation is a reference to an external symbol in generated code. The symbol is resolved at
+// link time: its address, or its offset from the incremented PC, is stored in the low bits of the
+// word at the location.
+type Relocation struct {
+	Loc    vm.Word // Address of the referring word.
+	Symbol string  // External symbol name.
+	Bits   uint8   // Width of the operand.
+}
+
+// GeneratorOption configures a generator.
+type GeneratorOption func(*Generator)
+
+// WithGenerateProgress configures a generator to call fn before code is generated for each
+// operation. The line is empty if the operation has no source information.
+func WithGenerateProgress(fn ProgressFunc) GeneratorOption {
+	return func(gen *Generator) {
+		gen.progress = fn
+	}
+}
+
+// WithLiteralPool configures a generator to use a literal pool for LEA operations with symbols that
+// are out of range of their 9-bit offset. The symbol's address is stored in a pool at the end of the
+// section and the LEA is rewritten as an LD of the pool entry. Because the pool extends the
+// section, it is not enabled by default.
+func WithLiteralPool() GeneratorOption {
+	return func(gen *Generator) {
+		gen.pool = true
+	}
+}
+
+// WithObjectHeader configures a generator to write binary object code with a header, i.e.
+// vm.ObjectMagic and vm.ObjectVersion, so that a loader can tell object code from raw data.
+func WithObjectHeader() GeneratorOption {
+	return func(gen *Generator) {
+		gen.header = true
+	}
+}
+
+// WithObjectChecksum configures a generator to write binary object code with a header that has the
+// vm.ObjectChecksum flag and with a trailing checksum word, so that a loader can detect corrupt
+// object files. It implies WithObjectHeader.
+func WithObjectChecksum() GeneratorOption {
+	return func(gen *Generator) {
+		gen.header = true
+		gen.checksum = true
+	}
+}
+
+// WithTrapCheck configures a generator to warn when a TRAP instruction's vector is not defined. The
+// given vectors, e.g. those of the system image's trap table, are defined, as are those declared by
+// .TRAP directives.
+func WithTrapCheck(vectors ...uint8) GeneratorOption {
+	return func(gen *Generator) {
+		gen.trapCheck = true
+		gen.vectors = make(map[uint8]bool)
+
+		for _, vec := range vectors {
+			gen.vectors[vec] = true
+		}
+	}
 }
 
 // NewGenerator creates a code generator using the given symbol and syntax tables.
-func NewGenerator(symbols SymbolTable, syntax SyntaxTable) *Generator {
-	return &Generator{
+func NewGenerator(symbols SymbolTable, syntax SyntaxTable, opts ...GeneratorOption) *Generator {
+	gen := &Generator{
 		pc:       0x0000,
 		symbols:  symbols,
 		syntax:   syntax,
 		encoding: encoding.HexEncoding{},
 	}
+
+	for _, fn := range opts {
+		fn(gen)
+	}
+
+	return gen
 }
 
 // Encode generates object code and encodes it as hex-encoded ASCII object code.
 //
-// Multiple sections are supported if the syntax table has multiple ORIG directives.
+// Multiple sections are supported if the syntax table has multiple ORIG directives. Each section is
+// encoded with its own origin. An error is returned if sections overlap.
 func (gen *Generator) Encode() ([]byte, error) {
 	if len(gen.syntax) == 0 {
 		return nil, nil
 	}
 
+	code, err := gen.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	gen.encoding.Code = code
+
+	if b, err := gen.encoding.MarshalText(); err != nil {
+		return nil, fmt.Errorf("gen: %w", err)
+	} else {
+		return b, nil
+	}
+}
+
+// ObjectCode generates code and returns the object code for each section, e.g. to load directly into
+// a machine. An error is returned if sections overlap.
+func (gen *Generator) ObjectCode() ([]vm.ObjectCode, error) {
+	if len(gen.syntax) == 0 {
+		return nil, nil
+	}
+
+	return gen.generate()
+}
+
+// Relocations returns the references to external symbols in the code generated most recently.
+func (gen *Generator) Relocations() []Relocation {
+	return gen.relocations
+}
+
+// Warnings returns the advisory diagnostics found while generating code most recently.
+func (gen *Generator) Warnings() []Warning {
+	return gen.warnings
+}
+
+// WriteTo writes generated machine code to an output stream in the binary object format used by
+// other LC-3 tools: a big-endian origin word followed by big-endian code words. With
+// WithObjectHeader, the object code is preceded by a header and, with WithObjectChecksum, followed
+// by a checksum. The entry point, if the code has one, is recorded in the header, so it implies
+// WithObjectHeader. Unlike Encode, WriteTo does not support writing more than a single section of
+// code.
+func (gen *Generator) WriteTo(out io.Writer) (int64, error) {
+	obj, err := gen.section()
+	if err != nil || obj == nil {
+		return 0, err
+	}
+
+	var count int64
+
+	if gen.header || obj.Entry != nil {
+		version := vm.ObjectVersion
+
+		if gen.checksum {
+			version |= vm.ObjectChecksum
+		}
+
+		if obj.Entry != nil {
+			version |= vm.ObjectEntry
+		}
+
+		n, err := io.WriteString(out, vm.ObjectMagic)
+		count += int64(n)
+
+		if err != nil {
+			return count, fmt.Errorf("gen: %w", err)
+		} else if err := binary.Write(out, binary.BigEndian, version); err != nil {
+			return count, fmt.Errorf("gen: %w", err)
+		}
+
+		count += 2
+
+		if obj.Entry != nil {
+			if err := binary.Write(out, binary.BigEndian, *obj.Entry); err != nil {
+				return count, fmt.Errorf("gen: %w", err)
+			}
+
+			count += 2
+		}
+	}
+
+	n, err := obj.WriteTo(out)
+	count += n
+
+	if err != nil {
+		return count, fmt.Errorf("gen: %w", err)
+	}
+
+	if gen.checksum {
+		if err := binary.Write(out, binary.BigEndian, obj.Checksum()); err != nil {
+			return count, fmt.Errorf("gen: %w", err)
+		}
+
+		count += 2
+	}
+
+	return count, nil
+}
+
+// WriteCodeOnly writes generated machine code to an output stream as big-endian code words, like
+// WriteTo, but without the origin word or a header. Segments written this way may be concatenated
+// with separately written origins. Like WriteTo, only a single section is supported.
+func (gen *Generator) WriteCodeOnly(out io.Writer) (int64, error) {
+	obj, err := gen.section()
+	if err != nil || obj == nil {
+		return 0, err
+	}
+
+	if err := binary.Write(out, binary.BigEndian, obj.Code); err != nil {
+		return 0, fmt.Errorf("gen: %w", err)
+	}
+
+	return int64(len(obj.Code) * 2), nil
+}
+
+// WriteBinary writes generated machine code to an output stream in the ASCII binary format used by
+// other LC-3 tools: each line has a word written as 16 '0' or '1' characters. The first line is the
+// origin and the remaining lines are code. Like WriteTo, only a single section is supported.
+func (gen *Generator) WriteBinary(out io.Writer) (int64, error) {
+	obj, err := gen.section()
+	if err != nil || obj == nil {
+		return 0, err
+	}
+
+	var count int64
+
+	for _, word := range append([]vm.Word{obj.Orig}, obj.Code...) {
+		n, err := fmt.Fprintf(out, "%016b\n", uint16(word))
+		count += int64(n)
+
+		if err != nil {
+			return count, fmt.Errorf("gen: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+// WriteSymbolTable writes the symbol table to an output stream in the format used by other LC-3
+// tools. Symbols are sorted by address.
+func (gen *Generator) WriteSymbolTable(out io.Writer) (int64, error) {
+	names := make([]string, 0, len(gen.symbols))
+	for name := range gen.symbols {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := gen.symbols[names[i]], gen.symbols[names[j]]
+		if a == b {
+			return names[i] < names[j]
+		}
+
+		return a < b
+	})
+
+	buf := bytes.Buffer{}
+	buf.WriteString("// Symbol table\n")
+	buf.WriteString("// Symbol Name       Page Address\n")
+	buf.WriteString("// ----------------  ------------\n")
+
+	for _, name := range names {
+		fmt.Fprintf(&buf, "// %-16s  %04X\n", name, uint16(gen.symbols[name]))
+	}
+
+	count, err := buf.WriteTo(out)
+	if err != nil {
+		return count, fmt.Errorf("gen: %w", err)
+	}
+
+	return count, nil
+}
+
+// section generates code for a syntax table that has a single section. It returns nil if the
+// table is empty.
+func (gen *Generator) section() (*vm.ObjectCode, error) {
+	if len(gen.syntax) == 0 {
+		return nil, nil
+	}
+
+	code, err := gen.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(code) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &code[0], nil
+	default:
+		return nil, fmt.Errorf("gen: %d sections: format supports a single section", len(code))
+	}
+}
+
+// generate translates the syntax table into object code, one per section.
+func (gen *Generator) generate() ([]vm.ObjectCode, error) {
 	var (
-		obj   vm.ObjectCode
-		count int64
-		err   error
+		obj  vm.ObjectCode
+		code []vm.ObjectCode
+		err  error
 	)
 
-	// We expect the .ORIG directive to be the first operation in the syntax table. TODO: We should
-	// be able to support multiple origins if the encoder does.
-	if _, ok := origin(gen.syntax[0]); !ok {
-		return nil, fmt.Errorf(".ORIG should be first operation; was: %T", gen.syntax[0])
+	gen.externals = make(map[string]bool)
+	gen.relocations = nil
+	gen.traps = nil
+	gen.warnings = nil
+
+	first := -1
+
+	for i, op := range gen.syntax {
+		if ext, ok := unwrap(op).(*EXTERNAL); ok {
+			gen.externals[ext.SYMBOL] = true
+		} else if trap, ok := unwrap(op).(*TRAPVEC); ok {
+			gen.traps = append(gen.traps, trap)
+		} else if first < 0 && op != nil {
+			first = i
+		}
 	}
 
+	// We expect the .ORIG directive to be the first operation in the syntax table, apart from
+	// external and trap declarations.
+	if first < 0 {
+		return nil, nil
+	} else if _, ok := origin(gen.syntax[first]); !ok {
+		return nil, fmt.Errorf(".ORIG should be first operation; was: %T", gen.syntax[first])
+	}
+
+	inSection := false
+	gen.pending = nil
+
 	for _, op := range gen.syntax {
 		if op == nil {
 			continue
+		} else if _, ok := unwrap(op).(*EXTERNAL); ok {
+			continue
+		} else if _, ok := unwrap(op).(*TRAPVEC); ok {
+			continue
 		} else if orig, ok := origin(op); ok {
+			if err = gen.flushPool(&obj); err != nil {
+				break
+			}
+
 			if obj.Code != nil {
-				gen.encoding.Code = append(gen.encoding.Code, obj)
+				code = append(code, obj)
 			}
 
 			gen.pc = orig.LITERAL
 			obj = vm.ObjectCode{Orig: gen.pc}
+			inSection = true
 
 			continue // We don't need to generate code.
+		} else if _, ok := unwrap(op).(*END); ok {
+			if err = gen.flushPool(&obj); err != nil {
+				break
+			}
+
+			if obj.Code != nil {
+				code = append(code, obj)
+			}
+
+			obj = vm.ObjectCode{}
+			inSection = false
+
+			continue
+		} else if !inSection {
+			err = gen.annotate(op, errors.New("operation is outside of a section"))
+			break
+		} else if entry, ok := unwrap(op).(*ENTRY); ok {
+			addr, found := gen.symbols[entry.SYMBOL]
+			if !found {
+				err = gen.annotate(op, &SymbolError{Symbol: entry.SYMBOL, Loc: gen.pc})
+				break
+			}
+
+			obj.Entry = &addr
+
+			continue
+		}
+
+		if gen.progress != nil {
+			line := ""
+			if src, ok := op.(*SourceInfo); ok {
+				line = src.Line
+			}
+
+			gen.progress(gen.pc, line)
+		}
+
+		if trap, ok := unwrap(op).(*TRAP); ok && gen.trapCheck {
+			gen.checkTrap(op, trap)
 		}
 
 		genWords, genErr := op.Generate(gen.symbols, gen.pc+1)
 
+		if genErr != nil && gen.pool {
+			genWords, genErr = gen.poolAddress(op, genErr, len(obj.Code))
+		}
+
+		if genErr != nil && len(gen.externals) > 0 {
+			genWords, genErr = gen.relocate(op, genErr)
+		}
+
 		if genErr != nil {
 			err = gen.annotate(op, genErr)
 			break
 		}
 
 		obj.Code = append(obj.Code, genWords...)
-
 		gen.pc += vm.Word(len(genWords))
-		count += int64(len(genWords) * 2)
+	}
+
+	if err == nil {
+		err = gen.flushPool(&obj)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("gen: %w", err)
 	}
 
-	gen.encoding.Code = append(gen.encoding.Code, obj)
+	if obj.Code != nil {
+		code = append(code, obj)
+	}
 
-	if b, err := gen.encoding.MarshalText(); err != nil {
+	if table, err := gen.trapTable(); err != nil {
 		return nil, fmt.Errorf("gen: %w", err)
 	} else {
-		return b, nil
+		code = append(code, table...)
 	}
+
+	if err := checkOverlap(code); err != nil {
+		return nil, fmt.Errorf("gen: %w", err)
+	}
+
+	return code, nil
 }
 
-// writeTo writes generated binary machine-code to an output stream. Unlike Encode, writeTo does not
-// support writing more than a single section of code. It exists for the sake of development and
-// debugging.
-func (gen *Generator) writeTo(out io.Writer) (int64, error) {
+// Verify generates code and checks that each generated instruction survives a round-trip: the
+// instruction is disassembled, the disassembly is parsed and assembled again, and the result must
+// match the generated code. Data directives, e.g. .FILL and .STRINGZ, are not verified. It is a
+// self-check of the assembler's encoding and the first error found is returned.
+func (gen *Generator) Verify() error {
 	if len(gen.syntax) == 0 {
-		return 0, nil
+		return nil
 	}
 
-	var (
-		count int64
-		err   error
-	)
+	sections, err := gen.generate()
+	if err != nil {
+		return err
+	}
 
-	// Write the origin offset as the leader of the object file. The .ORIG directive should be the
-	// first operation in the syntax table.
-	if orig, ok := origin(gen.syntax[0]); ok {
-		gen.pc = orig.LITERAL
-	} else {
-		return 0, fmt.Errorf(".ORIG should be first operation; was: %T", gen.syntax[0])
+	words := make(map[vm.Word]vm.Word)
+
+	for _, obj := range sections {
+		for i, word := range obj.Code {
+			words[obj.Orig+vm.Word(i)] = word
+		}
 	}
 
-	for i, oper := range gen.syntax {
-		if oper == nil {
+	gen.pc = 0x0000
+
+	for _, op := range gen.syntax {
+		if op == nil {
+			continue
+		} else if orig, ok := origin(op); ok {
+			gen.pc = orig.LITERAL
 			continue
-		} else if _, ok := origin(oper); ok && i != 0 {
-			err = errors.New(".ORIG directive may only be the first operation")
-			break
 		}
 
-		generated, genErr := oper.Generate(gen.symbols, gen.pc+1)
+		var (
+			size   = vm.Word(1)
+			verify = true
+		)
 
-		if err != nil {
-			err = gen.annotate(oper, genErr)
-			break
+		switch oper := unwrap(op).(type) {
+		case *END, *ENTRY, *EXTERNAL, *TRAPVEC:
+			continue
+		case *FILL:
+			size, verify = vm.Word(len(oper.LITERAL)), false
+		case *BLKW:
+			size, verify = oper.ALLOC, false
+		case *STRINGZ:
+			size, verify = oper.Size(), false
+		case *STRINGP:
+			size, verify = oper.Size(), false
+		case *INCBIN:
+			size, verify = oper.Size(), false
+		case interface{ Size() vm.Word }:
+			size = oper.Size()
 		}
 
-		if err = binary.Write(out, binary.BigEndian, generated); err != nil {
-			break
+		for i := vm.Word(0); verify && i < size; i++ {
+			if err := verifyWord(words[gen.pc+i], gen.pc+i); err != nil {
+				return fmt.Errorf("gen: %w", gen.annotate(op, err))
+			}
 		}
 
-		gen.pc += vm.Word(len(generated))
-		count += int64(len(generated) * 2)
+		gen.pc += size
+	}
+
+	return nil
+}
+
+// verifyWord disassembles an instruction located at an address and reassembles it. An error is
+// returned if the disassembly cannot be parsed or if the reassembled code differs.
+func verifyWord(word vm.Word, loc vm.Word) error {
+	disasm := vm.Instruction(word).Disassemble()
+	opcode, args, _ := strings.Cut(disasm, " ")
+
+	var operands []string
+	if args != "" {
+		operands = strings.Split(args, ",")
+	}
+
+	oper := (&Parser{}).parseOperator(opcode)
+	if oper == nil {
+		return fmt.Errorf("%w: %s: %q: %w", ErrVerify, word, disasm, ErrOpcode)
+	} else if err := oper.Parse(strings.ToUpper(opcode), operands); err != nil {
+		return fmt.Errorf("%w: %s: %q: %w", ErrVerify, word, disasm, err)
 	}
 
+	code, err := oper.Generate(SymbolTable{}, loc+1)
 	if err != nil {
-		return count, fmt.Errorf("gen: %w", err)
+		return fmt.Errorf("%w: %s: %q: %w", ErrVerify, word, disasm, err)
+	} else if len(code) != 1 || code[0] != word {
+		return fmt.Errorf("%w: %s: %q: reassembled as %v", ErrVerify, word, disasm, code)
 	}
 
-	return count, nil
+	return nil
+}
+
+// poolRef is a reference to a literal pool entry: an LEA operation whose symbol is out of range and
+// that will be rewritten as an LD of the symbol's address from the pool.
+type poolRef struct {
+	op    Operation // The LEA operation.
+	dr    string    // Destination register.
+	addr  vm.Word   // The symbol's address, i.e. the pool entry's value.
+	pc    vm.Word   // Incremented program counter of the operation.
+	index int       // Index of the operation in the section's code.
+}
+
+// poolSymbol names a pool entry when generating an LD that refers to it.
+const poolSymbol = "POOL"
+
+// poolAddress handles an error generating code for an operation. If the operation is an LEA of a
+// symbol that is out of range, a pool entry is referenced and a placeholder word is returned. The
+// placeholder is replaced when the pool is flushed. Otherwise, the error is returned.
+func (gen *Generator) poolAddress(op Operation, err error, index int) ([]vm.Word, error) {
+	var rangeErr *OffsetRangeError
+
+	lea, ok := unwrap(op).(*LEA)
+	if !ok || lea.SYMBOL == "" || !errors.As(err, &rangeErr) {
+		return nil, err
+	}
+
+	gen.pending = append(gen.pending, poolRef{
+		op:    op,
+		dr:    lea.DR,
+		addr:  gen.symbols[strings.ToUpper(lea.SYMBOL)],
+		pc:    gen.pc + 1,
+		index: index,
+	})
+
+	return []vm.Word{0x0000}, nil
+}
+
+// relocate handles an error generating code for an operation. If the error is caused by a reference
+// to an external symbol, the symbol is given a placeholder address, a relocation is recorded and the
+// code is generated again. Otherwise, the error is returned.
+func (gen *Generator) relocate(op Operation, err error) ([]vm.Word, error) {
+	oper := unwrap(op)
+	bits := relocationBits(oper)
+	symbols := maps.Clone(gen.symbols)
+
+	for bits > 0 {
+		var symErr *SymbolError
+
+		if !errors.As(err, &symErr) {
+			return nil, err
+		}
+
+		sym := strings.ToUpper(symErr.Symbol)
+		loc := gen.pc
+
+		if !gen.externals[sym] {
+			return nil, err
+		} else if _, ok := symbols[sym]; ok {
+			return nil, err // Not resolved by the placeholder; give up.
+		}
+
+		if fill, ok := oper.(*FILL); ok {
+			// Data refers to the symbol's address. Only plain references, not expressions, are
+			// relocated.
+			index := slices.IndexFunc(fill.SYMBOL, func(s string) bool {
+				return strings.EqualFold(strings.TrimSpace(s), sym)
+			})
+
+			if index < 0 {
+				return nil, err
+			}
+
+			symbols[sym] = 0x0000
+			loc += vm.Word(index)
+		} else {
+			// Instructions refer to the symbol's offset from the incremented PC.
+			symbols[sym] = gen.pc + 1
+		}
+
+		gen.relocations = append(gen.relocations, Relocation{Loc: loc, Symbol: sym, Bits: bits})
+
+		var code []vm.Word
+
+		if code, err = op.Generate(symbols, gen.pc+1); err == nil {
+			return code, nil
+		}
+	}
+
+	return nil, err
+}
+
+// relocationBits returns the width of the symbolic operand of an operation, or zero if the operation
+// has none.
+func relocationBits(oper Operation) uint8 {
+	switch oper.(type) {
+	case *BR, *LD, *LDI, *LEA, *ST, *STI:
+		return 9
+	case *JSR:
+		return 11
+	case *LDR, *STR:
+		return 6
+	case *FILL:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// flushPool appends the literal pool to the end of a section, with an entry for each distinct
+// address, and rewrites the pooled LEA operations as LD operations of their pool entries.
+func (gen *Generator) flushPool(obj *vm.ObjectCode) error {
+	entries := make(map[vm.Word]vm.Word) // Addresses to their pool entries.
+
+	for _, ref := range gen.pending {
+		entry, ok := entries[ref.addr]
+		if !ok {
+			entry = obj.Orig + vm.Word(len(obj.Code))
+			entries[ref.addr] = entry
+			obj.Code = append(obj.Code, ref.addr)
+		}
+
+		ld := LD{DR: ref.dr, SYMBOL: poolSymbol}
+
+		code, err := ld.Generate(SymbolTable{poolSymbol: entry}, ref.pc)
+		if err != nil {
+			return gen.annotate(ref.op, fmt.Errorf("pool: %w", err))
+		}
+
+		obj.Code[ref.index] = code[0]
+	}
+
+	gen.pending = nil
+
+	return nil
+}
+
+// checkTrap adds a warning if a TRAP instruction's vector is neither a registered vector nor declared
+// by a .TRAP directive.
+func (gen *Generator) checkTrap(op Operation, trap *TRAP) {
+	vector := uint8(trap.LITERAL)
+
+	if gen.vectors[vector] {
+		return
+	}
+
+	for _, decl := range gen.traps {
+		if uint8(decl.LITERAL) == vector {
+			return
+		}
+	}
+
+	warning := Warning{
+		Loc: gen.pc,
+		Msg: fmt.Sprintf("undefined trap vector: %0#2x", vector),
+	}
+
+	if src, ok := op.(*SourceInfo); ok {
+		warning.File = src.Filename
+		warning.Pos = src.Pos
+		warning.Line = src.Line
+	}
+
+	gen.warnings = append(gen.warnings, warning)
+}
+
+// trapTable returns object code for the trap-table entries declared by .TRAP directives: each entry
+// is a section of one word, the handler's address, located at the vector in the trap table.
+func (gen *Generator) trapTable() ([]vm.ObjectCode, error) {
+	var table []vm.ObjectCode
+
+	for _, op := range gen.syntax {
+		trap, ok := unwrap(op).(*TRAPVEC)
+		if !ok {
+			continue
+		}
+
+		vector := vm.TrapTable + vm.Word(trap.LITERAL)
+
+		addr, ok := gen.symbols[trap.SYMBOL]
+		if !ok {
+			return nil, gen.annotate(op, &SymbolError{Symbol: trap.SYMBOL, Loc: vector})
+		}
+
+		table = append(table, vm.ObjectCode{Orig: vector, Code: []vm.Word{addr}})
+	}
+
+	return table, nil
+}
+
+// checkOverlap returns an error if any two sections of object code share an address.
+func checkOverlap(code []vm.ObjectCode) error {
+	for i := range code {
+		a := Section{Orig: code[i].Orig, Size: vm.Word(len(code[i].Code))}
+
+		for j := i + 1; j < len(code); j++ {
+			b := Section{Orig: code[j].Orig, Size: vm.Word(len(code[j].Code))}
+
+			if a.Overlaps(b) {
+				return fmt.Errorf("%w: %s and %s", ErrOverlap, a.Orig, b.Orig)
+			}
+		}
+	}
+
+	return nil
 }
 
 // annotate wraps errors with source code information.
@@ -163,7 +793,7 @@ func (gen *Generator) annotate(code Operation, err error) error {
 		}
 		return err
 	} else {
-		return nil
+		return err
 	}
 }
 
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/smoynes/elsie/internal/encoding"
 	"github.com/smoynes/elsie/internal/vm"
 )
 
@@ -93,10 +97,10 @@ func TestGenerator(tt *testing.T) {
 	syntax.Add(&AND{DR: "R3", SR1: "R4", SR2: "R6"})
 
 	symbols := SymbolTable{}
-	symbols.Add("LABEL", 0x2ff0)
+	_ = symbols.Add("LABEL", 0x2ff0)
 
 	gen := NewGenerator(symbols, syntax)
-	count, err := gen.writeTo(&buf)
+	count, err := gen.WriteTo(&buf)
 
 	if err != nil {
 		t.Error(err)
@@ -121,6 +125,99 @@ func TestGenerator(tt *testing.T) {
 	}
 }
 
+func TestGenerator_ObjectHeader(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	var buf bytes.Buffer
+
+	syntax := make(SyntaxTable, 0)
+
+	syntax.Add(&ORIG{LITERAL: 0x3000})
+	syntax.Add(&TRAP{LITERAL: 0x25})
+
+	gen := NewGenerator(SymbolTable{}, syntax, WithObjectHeader())
+	count, err := gen.WriteTo(&buf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		'E', 'L', 'S', 'I', 'E', 0x01,
+		0x00, 0x01,
+		0x30, 0x00,
+		0xf0, 0x25,
+	}
+
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("want: %#v, got: %#v", expected, buf.Bytes())
+	} else if count != int64(len(expected)) {
+		t.Errorf("count: want: %d, got: %d", len(expected), count)
+	}
+}
+
+func TestGenerator_ObjectChecksum(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	var buf bytes.Buffer
+
+	syntax := make(SyntaxTable, 0)
+
+	syntax.Add(&ORIG{LITERAL: 0x3000})
+	syntax.Add(&LEA{DR: "R0", OFFSET: 2})
+	syntax.Add(&TRAP{LITERAL: 0x25})
+
+	gen := NewGenerator(SymbolTable{}, syntax, WithObjectChecksum())
+
+	if _, err := gen.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		'E', 'L', 'S', 'I', 'E', 0x01,
+		0x01, 0x01,
+		0x30, 0x00,
+		0xe0, 0x02,
+		0xf0, 0x25,
+		0x00, 0x29,
+	}
+
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("want: %#v, got: %#v", expected, buf.Bytes())
+	} else if _, err := vm.ReadObjectCode(&buf); err != nil {
+		t.Errorf("read: %s", err)
+	}
+}
+
+func TestGenerator_WriteCodeOnly(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	syntax := make(SyntaxTable, 0)
+
+	syntax.Add(&ORIG{LITERAL: 0x3000})
+	syntax.Add(&NOT{DR: "R0", SR: "R7"})
+	syntax.Add(&AND{DR: "R3", SR1: "R4", SR2: "R6"})
+
+	var withOrig, codeOnly bytes.Buffer
+
+	if _, err := NewGenerator(SymbolTable{}, syntax).WriteTo(&withOrig); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := NewGenerator(SymbolTable{}, syntax).WriteCodeOnly(&codeOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := withOrig.Bytes()[2:] // Without the origin word.
+
+	if count != int64(len(expected)) {
+		t.Errorf("count: want: %d, got: %d", len(expected), count)
+	} else if !bytes.Equal(codeOnly.Bytes(), expected) {
+		t.Errorf("code: want: %#v, got: %#v", expected, codeOnly.Bytes())
+	}
+}
+
 func TestAND_Generate(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []generateCase{
@@ -184,6 +281,55 @@ func TestBR_Generate(tt *testing.T) {
 	t.Run(pc, symbols, tcs)
 }
 
+func TestEmitPCRelative(tt *testing.T) {
+	t := generatorHarness{tt}
+	symbols := SymbolTable{"LABEL": 0x3005, "BACK": 0x2ff0, "FAR": 0x4000}
+
+	tcs := []struct {
+		sym     string
+		offset  uint16
+		bits    uint8
+		want    vm.Word
+		wantErr error
+	}{
+		{sym: "LABEL", bits: 9, want: 0x0005},
+		{sym: "BACK", bits: 9, want: 0x01f0},
+		{sym: "BACK", bits: 6, want: 0x0030},
+		{offset: 0xffff, bits: 9, want: 0x01ff},
+		{offset: 0xffff, bits: 8, want: 0x00ff},
+		{offset: 0x0010, bits: 11, want: 0x0010},
+		{sym: "FAR", bits: 9, wantErr: &OffsetRangeError{}},
+		{sym: "GONE", bits: 9, wantErr: &SymbolError{}},
+	}
+
+	for _, tc := range tcs {
+		code := vm.NewInstruction(vm.LD, 0)
+		err := emitPCRelative(&code, symbols, tc.sym, tc.offset, 0x3000, tc.bits)
+
+		var (
+			rangeErr *OffsetRangeError
+			symErr   *SymbolError
+		)
+
+		switch tc.wantErr.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%+v: unexpected error: %v", tc, err)
+			} else if got := code.Encode(); got != 0x2000|tc.want {
+				t.Errorf("%+v: want: %s, got: %s", tc, 0x2000|tc.want, got)
+			}
+		case *OffsetRangeError:
+			if !errors.As(err, &rangeErr) {
+				t.Errorf("%+v: want: %T, got: %v", tc, tc.wantErr, err)
+			}
+		case *SymbolError:
+			if !errors.As(err, &symErr) {
+				t.Errorf("%+v: want: %T, got: %v", tc, tc.wantErr, err)
+			}
+		}
+	}
+}
+
 func TestLDR_Generate(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []generateCase{
@@ -212,16 +358,26 @@ func TestLD_Generate(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []generateCase{
 		{oper: &LD{DR: "R0", OFFSET: 0x2f}, want: 0x202f},
-		{oper: &LD{DR: "R0", OFFSET: 0xffff}, want: 0x20ff},
+		{oper: &LD{DR: "R0", OFFSET: 0xffff}, want: 0x21ff},
 		{oper: &LD{DR: "R0", OFFSET: 0x10}, want: 0x2010, wantErr: nil},
 		{oper: &LD{DR: "R7", SYMBOL: "LABEL"}, want: 0x2e05, wantErr: nil},
+		{oper: &LD{DR: "R1", SYMBOL: "LAST"}, want: 0x22ff, wantErr: nil},
+		{oper: &LD{DR: "R2", SYMBOL: "FIRST"}, want: 0x2500, wantErr: nil},
+		{oper: &LD{DR: "R1", SYMBOL: "AHEAD"}, wantErr: &OffsetRangeError{Offset: 0x0100}},
+		{oper: &LD{DR: "R2", SYMBOL: "BEHIND"}, wantErr: &OffsetRangeError{Offset: 0xfeff}},
+		{oper: &LD{DR: "R3", SYMBOL: "YONDER"}, wantErr: &OffsetRangeError{Offset: 0x1000}},
+		{oper: &LD{DR: "R4", SYMBOL: "FAR"}, wantErr: &OffsetRangeError{Offset: 0xfe00}},
 	}
 
 	pc := vm.Word(0x3000)
 	symbols := SymbolTable{
 		"LABEL":  0x3005,
 		"BACK":   0x3000,
-		"FAR":    0x2f00,
+		"LAST":   0x30ff, // +255
+		"FIRST":  0x2f00, // -256
+		"AHEAD":  0x3100, // +256
+		"BEHIND": 0x2eff, // -257
+		"FAR":    0x2e00, // -512
 		"YONDER": 0x4000,
 	}
 
@@ -253,7 +409,7 @@ func TestLEA_Generate(tt *testing.T) {
 func TestLDI_Generate(tt *testing.T) {
 	pc := vm.Word(0x3000)
 	symbols := SymbolTable{
-		"LABEL":     0x31ff,
+		"LABEL":     0x30ff,
 		"THERE":     0x3080,
 		"WAYBACK":   0x2dff,
 		"OVERTHERE": 0x3200,
@@ -263,7 +419,7 @@ func TestLDI_Generate(tt *testing.T) {
 	tcs := []generateCase{
 		{oper: &LDI{DR: "R0", OFFSET: 0x10}, want: 0xa010, wantErr: nil},
 		{oper: &LDI{DR: "R0", OFFSET: 0xffff}, want: 0xa1ff, wantErr: nil},
-		{oper: &LDI{DR: "R7", SYMBOL: "LABEL"}, want: 0xafff, wantErr: nil},
+		{oper: &LDI{DR: "R7", SYMBOL: "LABEL"}, want: 0xaeff, wantErr: nil},
 		{oper: &LDI{DR: "R2", SYMBOL: "THERE"}, want: 0xa480},
 		{oper: &LDI{DR: "R3", SYMBOL: "WAYBACK"}, wantErr: &OffsetRangeError{Offset: 0xfdff}},
 		{oper: &LDI{DR: "R4", SYMBOL: "OVERTHERE"}, wantErr: &OffsetRangeError{Offset: 0x0200}},
@@ -324,10 +480,11 @@ func TestSTR_Generate(tt *testing.T) {
 	pc := vm.Word(0x3000)
 	symbols := SymbolTable{
 		"LABEL":     0x2fff, // -1
-		"THERE":     0x301f, // 64
-		"BACK":      0x2fe0, // -64
-		"WAYBACK":   0x2fd0,
-		"OVERTHERE": 0x3040,
+		"THERE":     0x301f, // +31
+		"BACK":      0x2fe0, // -32
+		"WAYBACK":   0x2fc0, // -64
+		"OVERTHERE": 0x3040, // +64
+		"PAST":      0x3020, // +32
 	}
 
 	t := generatorHarness{tt}
@@ -335,10 +492,12 @@ func TestSTR_Generate(tt *testing.T) {
 		{oper: &STR{SR1: "R0", SR2: "R1", OFFSET: 0x2f}, want: 0x706f},
 		{oper: &STR{SR1: "R0", SR2: "R0", OFFSET: 0x00}, want: 0x7000},
 		{oper: &STR{SR1: "R0", SR2: "R1", OFFSET: 0xffff}, want: 0x707f},
-		{oper: &STR{SR1: "R7", SR2: "R2", SYMBOL: "LABEL"}, want: 0x7e9f},
+		{oper: &STR{SR1: "R7", SR2: "R2", SYMBOL: "LABEL"}, want: 0x7ebf},
 		{oper: &STR{SR1: "R2", SR2: "R3", SYMBOL: "THERE"}, want: 0x74df},
-		{oper: &STR{SR1: "R3", SR2: "R4", SYMBOL: "WAYBACK"}, wantErr: &OffsetRangeError{Offset: 0xffd0}},
+		{oper: &STR{SR1: "R6", SR2: "R7", SYMBOL: "BACK"}, want: 0x7de0},
+		{oper: &STR{SR1: "R3", SR2: "R4", SYMBOL: "WAYBACK"}, wantErr: &OffsetRangeError{Offset: 0xffc0}},
 		{oper: &STR{SR1: "R4", SR2: "R5", SYMBOL: "OVERTHERE"}, wantErr: &OffsetRangeError{Offset: 0x0040}},
+		{oper: &STR{SR1: "R1", SR2: "R2", SYMBOL: "PAST"}, wantErr: &OffsetRangeError{Offset: 0x0020}},
 		{oper: &STR{SR1: "R5", SR2: "R6", SYMBOL: "DNE"}, wantErr: &SymbolError{Loc: 0x3000, Symbol: "DNE"}},
 	}
 
@@ -403,7 +562,10 @@ func TestJSR_Generate(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []generateCase{
 		{oper: &JSR{OFFSET: 0x00ff}, want: 0x48ff},
-		{oper: &JSR{OFFSET: 0xffff}, want: 0x4bff},
+		{oper: &JSR{OFFSET: 0xffff}, want: 0x4fff},
+		{oper: &JSR{OFFSET: 0x03ff}, want: 0x4bff}, // #1023
+		{oper: &JSR{OFFSET: 0xfc00}, want: 0x4c00}, // #-1024
+		{oper: &JSR{OFFSET: 0x0400}, want: 0x4c00}, // #-1024, as parsed.
 		{oper: &JSR{SYMBOL: "LABEL"}, want: 0x4fff},
 		{oper: &JSR{SYMBOL: "THERE"}, want: 0x4bff},
 		{oper: &JSR{SYMBOL: "BACK"}, want: 0x4f00},
@@ -498,6 +660,42 @@ func TestRTI_Generate(tt *testing.T) {
 	t.Run(pc, symbols, tcs)
 }
 
+func TestSTRINGP_Generate(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	tcs := []struct {
+		oper *STRINGP
+		want []vm.Word
+	}{
+		{
+			oper: &STRINGP{LITERAL: "Hi!?"},
+			want: []vm.Word{0x6948, 0x3f21, 0x0000}, // "Hi" and "!?", low byte first; terminator.
+		},
+		{
+			oper: &STRINGP{LITERAL: "Hey"},
+			want: []vm.Word{0x6548, 0x0079}, // "He" and "y", low byte first; terminator.
+		},
+		{
+			oper: &STRINGP{LITERAL: ""},
+			want: []vm.Word{0x0000},
+		},
+	}
+
+	for _, tc := range tcs {
+		code, err := tc.oper.Generate(SymbolTable{}, 0x3000)
+
+		if err != nil {
+			t.Fatal(err)
+		} else if len(code) != int(tc.oper.Size()) {
+			t.Errorf("%q: size: want: %d, got: %d", tc.oper.LITERAL, tc.oper.Size(), len(code))
+		}
+
+		if !slices.Equal(code, tc.want) {
+			t.Errorf("%q: code: want: %v, got: %v", tc.oper.LITERAL, tc.want, code)
+		}
+	}
+}
+
 func TestSTRINGZ_Generate(tt *testing.T) {
 	t := generatorHarness{tt}
 
@@ -572,7 +770,7 @@ func Test_CaseInsensitiveLabels(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []generateCase{
 		{oper: &JSR{OFFSET: 0x00ff}, want: 0x48ff},
-		{oper: &JSR{OFFSET: 0xffff}, want: 0x4bff},
+		{oper: &JSR{OFFSET: 0xffff}, want: 0x4fff},
 		{oper: &JSR{SYMBOL: "lAbEl"}, want: 0x4fff},
 		{oper: &JSR{SYMBOL: "thErE"}, want: 0x49ff},
 		{oper: &JSR{SYMBOL: "bAck"}, want: 0x4f00},
@@ -650,35 +848,40 @@ type symbolCase struct {
 func TestSymbolTable_Offset(tt *testing.T) {
 	t := generatorHarness{tt}
 	tcs := []symbolCase{
-		{pc: 0x0000, label: 0x0000, bits: 1, val: 0}, // TODO: Should bits=1 ALWAYS be a range error. That is: it is a signed value with only a sign bit.
-		{pc: 0x0000, label: 0x0001, bits: 1, val: 0x0001},
+		// Offsets are signed: an n-bit offset ranges from -2^(n-1) to 2^(n-1)-1.
+		{pc: 0x0000, label: 0x0000, bits: 1, val: 0},
+		{pc: 0x0000, label: 0x0001, bits: 1, val: 0xffff,
+			err: &OffsetRangeError{Offset: 1, Range: 0}},
 		{pc: 0x0000, label: 0x0002, bits: 1, val: 0xffff,
-			err: &OffsetRangeError{Offset: 2, Range: 0x0001}},
-		{pc: 0x0001, label: 0x0002, bits: 1, val: 1},
+			err: &OffsetRangeError{Offset: 2, Range: 0}},
 		{pc: 0x0001, label: 0x0001, bits: 1, val: 0},
-		{pc: 0x0001, label: 0x0000, bits: 1, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0xffff, Range: 0x0001}},
+		{pc: 0x0001, label: 0x0000, bits: 1, val: 0x0001},
 
 		{pc: 0x0000, label: 0x0000, bits: 2, val: 0},
 		{pc: 0x0000, label: 0x0001, bits: 2, val: 1},
-		{pc: 0x0000, label: 0x0002, bits: 2, val: 2},
-		{pc: 0x0000, label: 0x0003, bits: 2, val: 0x0003},
+		{pc: 0x0000, label: 0x0002, bits: 2, val: 0xffff,
+			err: &OffsetRangeError{Offset: 0x0002, Range: 0x0001}},
 		{pc: 0x0000, label: 0x0004, bits: 2, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0x0004, Range: 0x0003}},
+			err: &OffsetRangeError{Offset: 0x0004, Range: 0x0001}},
 
 		{pc: 0x0001, label: 0x0000, bits: 2, val: 0x0003},
 		{pc: 0x0002, label: 0x0000, bits: 2, val: 0x0002},
 		{pc: 0x0003, label: 0x0000, bits: 2, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0x0fffd, Range: 0x0003}},
-		{pc: 0x0004, label: 0x0000, bits: 2, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0xfffc, Range: 0x0003}},
+			err: &OffsetRangeError{Offset: 0x0fffd, Range: 0x0001}},
+
+		{pc: 0x3000, label: 0x30ff, bits: 9, val: 0x00ff},
+		{pc: 0x3000, label: 0x2f00, bits: 9, val: 0x0100},
+		{pc: 0x3000, label: 0x3100, bits: 9, val: 0xffff,
+			err: &OffsetRangeError{Offset: 0x0100, Range: 0x00ff}},
+		{pc: 0x3000, label: 0x2eff, bits: 9, val: 0xffff,
+			err: &OffsetRangeError{Offset: 0xfeff, Range: 0x00ff}},
 
 		{pc: 0x3000, label: 0x8000, bits: 5, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0x5000, Range: 0x001f},
+			err: &OffsetRangeError{Offset: 0x5000, Range: 0x000f},
 		},
 
 		{pc: 0x3000, label: 0x0000, bits: 6, val: 0xffff,
-			err: &OffsetRangeError{Offset: 0xd000, Range: 0x003f},
+			err: &OffsetRangeError{Offset: 0xd000, Range: 0x001f},
 		},
 	}
 
@@ -703,3 +906,1142 @@ func TestSymbolTable_Offset(tt *testing.T) {
 		}
 	}
 }
+
+func TestReadSymbolTable(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+LOOP    ADD R0,R0,#-1
+        BRp LOOP
+DONE    HALT
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := NewGenerator(parser.Symbols(), parser.Syntax()).WriteSymbolTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadSymbolTable(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if !maps.Equal(got, parser.Symbols()) {
+		t.Errorf("symbols: want: %v, got: %v", parser.Symbols(), got)
+	}
+
+	if _, err := ReadSymbolTable(strings.NewReader("LOOP 3000\n")); !errors.Is(err, ErrOperand) {
+		t.Errorf("error: want: %v, got: %v", ErrOperand, err)
+	}
+}
+
+func TestGenerator_Sections(tt *testing.T) {
+	t := ParserHarness{T: tt}
+
+	parser := t.ParseStream(t.inputFixture("parser10.asm"))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sections := parser.Sections()
+	want := []Section{
+		{Orig: 0x3000, Size: 3},
+		{Orig: 0x8000, Size: 2},
+	}
+
+	if len(sections) != len(want) {
+		t.Fatalf("sections: want: %+v, got: %+v", want, sections)
+	}
+
+	for i := range want {
+		if sections[i] != want[i] {
+			t.Errorf("sections[%d]: want: %+v, got: %+v", i, want[i], sections[i])
+		}
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+	encoded, err := gen.Encode()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hex := encoding.HexEncoding{}
+
+	if err := hex.UnmarshalText(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCode := []vm.ObjectCode{
+		{Orig: 0x3000, Code: []vm.Word{0x2001, 0xf025, 0x8000}},
+		{Orig: 0x8000, Code: []vm.Word{0x2364, 0x2365}},
+	}
+
+	if len(hex.Code) != len(wantCode) {
+		t.Fatalf("code: want: %+v, got: %+v", wantCode, hex.Code)
+	}
+
+	for i := range wantCode {
+		if got := hex.Code[i]; got.Orig != wantCode[i].Orig {
+			t.Errorf("code[%d] orig: want: %s, got: %s", i, wantCode[i].Orig, got.Orig)
+		} else if !slices.Equal(got.Code, wantCode[i].Code) {
+			t.Errorf("code[%d]: want: %v, got: %v", i, wantCode[i].Code, got.Code)
+		}
+	}
+}
+
+func TestGenerator_SectionOverlap(tt *testing.T) {
+	t := ParserHarness{T: tt}
+
+	parser := t.ParseStream(t.inputFixture("parser11.asm"))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sections := parser.Sections()
+
+	if len(sections) != 2 || !sections[0].Overlaps(sections[1]) {
+		t.Errorf("sections: expected overlap: %+v", sections)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := gen.Encode(); !errors.Is(err, ErrOverlap) {
+		t.Errorf("expected overlap error, got: %v", err)
+	}
+}
+
+func TestGenerator_SectionOverlapPartial(tt *testing.T) {
+	t := ParserHarness{T: tt}
+
+	// The second section begins below the first and runs into it.
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3002
+        .BLKW 2
+        .END
+        .ORIG x3000
+        .FILL 1, 2, 3
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := gen.Encode(); !errors.Is(err, ErrOverlap) {
+		t.Errorf("expected overlap error, got: %v", err)
+	}
+}
+
+func TestGenerator_Progress(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        ADD R0,R0,#1
+        .BLKW 2
+        HALT
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var locs []vm.Word
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax(),
+		WithGenerateProgress(func(loc vm.Word, line string) {
+			locs = append(locs, loc)
+		}))
+
+	if _, err := gen.Encode(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{0x3000, 0x3001, 0x3003}
+
+	if !slices.Equal(locs, want) {
+		t.Errorf("locs: want: %v, got: %v", want, locs)
+	}
+}
+
+func TestGenerator_LiteralPool(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        LEA R0,FAR
+        LEA R1,NEAR
+        LEA R2,FAR
+NEAR    HALT
+        .END
+        .ORIG x4000
+FAR     .FILL x1234
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	tt.Run("disabled", func(tt *testing.T) {
+		t := generatorHarness{tt}
+		gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+		var rangeErr *OffsetRangeError
+		if _, err := gen.generate(); !errors.As(err, &rangeErr) {
+			t.Errorf("expected offset range error, got: %v", err)
+		}
+	})
+
+	tt.Run("enabled", func(tt *testing.T) {
+		t := generatorHarness{tt}
+		gen := NewGenerator(parser.Symbols(), parser.Syntax(), WithLiteralPool())
+
+		code, err := gen.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []vm.Word{
+			0x2003, // LD R0,#3 ; pool entry at x3004
+			0xe201, // LEA R1,NEAR
+			0x2401, // LD R2,#1 ; same pool entry
+			0xf025, // HALT
+			0x4000, // Pool: FAR
+		}
+
+		if len(code) != 2 || !slices.Equal(code[0].Code, want) {
+			t.Errorf("code: want: %v, got: %v", want, code)
+		}
+	})
+
+	tt.Run("execute", func(tt *testing.T) {
+		t := generatorHarness{tt}
+		gen := NewGenerator(parser.Symbols(), parser.Syntax(), WithLiteralPool())
+
+		code, err := gen.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		machine := vm.New()
+		loader := vm.NewLoader(machine)
+
+		for _, obj := range code {
+			if _, err := loader.Load(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i := 0; i < 3; i++ {
+			if err := machine.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if machine.REG[vm.R0] != 0x4000 || machine.REG[vm.R2] != 0x4000 {
+			t.Errorf("want: R0, R2: %s, got:\n%s", vm.Word(0x4000), machine.REG)
+		}
+	})
+}
+
+func TestGenerator_Constants(tt *testing.T) {
+	t := ParserHarness{T: tt}
+
+	parser := t.ParseStream(t.inputString(`
+STEP    .EQU #-2
+        .ORIG x3000
+        ADD R1,R1,STEP
+        LDR R2,R1,STEP
+        .FILL STEP
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+	encoded, err := gen.Encode()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hex := encoding.HexEncoding{}
+
+	if err := hex.UnmarshalText(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{0x127e, 0x647e, 0xfffe}
+
+	if len(hex.Code) != 1 || !slices.Equal(hex.Code[0].Code, want) {
+		t.Errorf("code: want: %v, got: %v", want, hex.Code)
+	}
+}
+
+func TestMUL_Generate(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	oper := &MUL{DR: "R0", SR1: "R1", SR2: "R2"}
+	want := []vm.Word{
+		0x5020, // AND R0,R0,#0
+		0x1ea0, // ADD R7,R2,#0
+		0x0602, // BRzp #2
+		0x9fff, // NOT R7,R7
+		0x1fe1, // ADD R7,R7,#1
+		0x0403, // BRz #3
+		0x1001, // ADD R0,R0,R1
+		0x1fff, // ADD R7,R7,#-1
+		0x03fd, // BRp #-3
+		0x1ea0, // ADD R7,R2,#0
+		0x0602, // BRzp #2
+		0x903f, // NOT R0,R0
+		0x1021, // ADD R0,R0,#1
+		0x1020, // ADD R0,R0,#0
+	}
+
+	code, err := oper.Generate(SymbolTable{}, 0x3001)
+
+	if err != nil {
+		t.Fatal(err)
+	} else if len(code) != int(oper.Size()) {
+		t.Errorf("size: want: %d, got: %d", oper.Size(), len(code))
+	}
+
+	if !slices.Equal(code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
+func TestMUL_Execute(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	tcs := []struct {
+		sr1, sr2 int16
+		want     int16
+	}{
+		{3, 4, 12},
+		{4, 3, 12},
+		{-3, 4, -12},
+		{5, -6, -30},
+		{-7, -7, 49},
+		{7, 0, 0},
+		{0, 7, 0},
+	}
+
+	oper := &MUL{DR: "R0", SR1: "R1", SR2: "R2"}
+
+	for _, tc := range tcs {
+		code, err := oper.Generate(SymbolTable{}, 0x3001)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		machine := vm.New()
+		loader := vm.NewLoader(machine)
+
+		if _, err := loader.Load(vm.ObjectCode{Orig: 0x3000, Code: code}); err != nil {
+			t.Fatal(err)
+		}
+
+		machine.PC = 0x3000
+		machine.REG[vm.R1] = vm.Register(tc.sr1)
+		machine.REG[vm.R2] = vm.Register(tc.sr2)
+
+		for steps := 0; machine.PC != vm.ProgramCounter(0x3000+len(code)); steps++ {
+			if steps > 1000 {
+				t.Fatalf("%d × %d: did not finish: PC: %s", tc.sr1, tc.sr2, machine.PC)
+			} else if err := machine.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := int16(machine.REG[vm.R0]); got != tc.want {
+			t.Errorf("%d × %d: want: %d, got: %d", tc.sr1, tc.sr2, tc.want, got)
+		} else if machine.REG[vm.R1] != vm.Register(tc.sr1) || machine.REG[vm.R2] != vm.Register(tc.sr2) {
+			t.Errorf("%d × %d: source registers clobbered: %s", tc.sr1, tc.sr2, machine.REG)
+		}
+	}
+}
+
+func TestMOV_Generate(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	tcs := []struct {
+		oper *MOV
+		want []vm.Word
+	}{
+		{
+			oper: &MOV{DR: "R1", SR: "R2"},
+			want: []vm.Word{0x12a0}, // ADD R1,R2,#0
+		},
+		{
+			oper: &MOV{DR: "R3", LITERAL: 0x1f},
+			want: []vm.Word{0x56e0, 0x16ff}, // AND R3,R3,#0; ADD R3,R3,#-1
+		},
+		{
+			oper: &MOV{DR: "R0", LITERAL: 0x0f},
+			want: []vm.Word{0x5020, 0x102f}, // AND R0,R0,#0; ADD R0,R0,#15
+		},
+	}
+
+	for _, tc := range tcs {
+		code, err := tc.oper.Generate(SymbolTable{}, 0x3001)
+
+		if err != nil {
+			t.Fatal(err)
+		} else if len(code) != int(tc.oper.Size()) {
+			t.Errorf("%#v: size: want: %d, got: %d", tc.oper, tc.oper.Size(), len(code))
+		}
+
+		if !slices.Equal(code, tc.want) {
+			t.Errorf("%#v: code: want: %v, got: %v", tc.oper, tc.want, code)
+		}
+	}
+}
+
+func TestFILL_Generate(tt *testing.T) {
+	t := generatorHarness{tt}
+	fill := &FILL{LITERAL: []uint16{0x0001, 0xbeef, 0x0003}}
+	want := []vm.Word{0x0001, 0xbeef, 0x0003}
+
+	code, err := fill.Generate(SymbolTable{}, 0x3000)
+
+	if err != nil {
+		t.Fatal(err)
+	} else if !slices.Equal(code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
+func TestFILL_GenerateSymbol(tt *testing.T) {
+	t := generatorHarness{tt}
+	symbols := SymbolTable{"START": 0x3000, "TABLE": 0x4567}
+	fill := &FILL{LITERAL: []uint16{0, 0x0002, 0}, SYMBOL: []string{"START", "", "TABLE"}}
+	want := []vm.Word{0x3000, 0x0002, 0x4567}
+
+	code, err := fill.Generate(symbols, 0x3100)
+	if err != nil {
+		t.Fatal(err)
+	} else if !slices.Equal(code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+
+	fill = &FILL{LITERAL: []uint16{0, 0}, SYMBOL: []string{"", "GONE"}}
+
+	var symErr *SymbolError
+
+	if _, err := fill.Generate(symbols, 0x3100); !errors.As(err, &symErr) {
+		t.Errorf("want: SymbolError, got: %v", err)
+	} else if symErr.Symbol != "GONE" || symErr.Loc != 0x3101 {
+		t.Errorf("want: GONE at 0x3101, got: %v", symErr)
+	}
+}
+
+func TestFILL_GenerateExpression(tt *testing.T) {
+	t := generatorHarness{tt}
+	symbols := SymbolTable{"START": 0x3000, "LABEL": 0x3010, "END": 0x3020}
+
+	tcs := []struct {
+		operand string
+		want    vm.Word
+		wantErr error
+	}{
+		{operand: "LABEL+2", want: 0x3012},
+		{operand: "END-START", want: 0x0020},
+		{operand: "START-END", want: 0xffe0},
+		{operand: "-1+LABEL", want: 0x300f},
+		{operand: "label - #1 + x10", want: 0x301f},
+		{operand: "END-START+GONE", wantErr: &SymbolError{Loc: 0x3100, Symbol: "GONE"}},
+		{operand: "LABEL+", wantErr: ErrOperand},
+		{operand: "LABEL+x10000", wantErr: &LiteralRangeError{}},
+	}
+
+	for _, tc := range tcs {
+		fill := &FILL{}
+		err := fill.Parse(".FILL", []string{tc.operand})
+
+		if err == nil {
+			var code []vm.Word
+
+			code, err = fill.Generate(symbols, 0x3100)
+			if err == nil && code[0] != tc.want {
+				t.Errorf("%s: want: %s, got: %s", tc.operand, tc.want, code[0])
+			}
+		}
+
+		var (
+			symErr *SymbolError
+			litErr *LiteralRangeError
+		)
+
+		switch wantErr := tc.wantErr.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.operand, err)
+			}
+		case *SymbolError:
+			if !errors.As(err, &symErr) || *symErr != *wantErr {
+				t.Errorf("%s: want: %v, got: %v", tc.operand, wantErr, err)
+			}
+		case *LiteralRangeError:
+			if !errors.As(err, &litErr) {
+				t.Errorf("%s: want: %T, got: %v", tc.operand, wantErr, err)
+			}
+		default:
+			if !errors.Is(err, wantErr) {
+				t.Errorf("%s: want: %v, got: %v", tc.operand, wantErr, err)
+			}
+		}
+	}
+}
+
+func TestBLKW_Generate(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	tcs := []struct {
+		operands []string
+		want     []vm.Word
+	}{
+		{
+			operands: []string{"3"},
+			want:     []vm.Word{0x0000, 0x0000, 0x0000},
+		},
+		{
+			operands: []string{"3", "xFFFF"},
+			want:     []vm.Word{0xffff, 0xffff, 0xffff},
+		},
+	}
+
+	for _, tc := range tcs {
+		blkw := &BLKW{}
+
+		if err := blkw.Parse(".BLKW", tc.operands); err != nil {
+			t.Fatal(err)
+		}
+
+		code, err := blkw.Generate(SymbolTable{}, 0x3000)
+
+		if err != nil {
+			t.Fatal(err)
+		} else if !slices.Equal(code, tc.want) {
+			t.Errorf("%v: code: want: %v, got: %v", tc.operands, tc.want, code)
+		}
+	}
+}
+
+func TestBLKW_Error(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+EMPTY   .BLKW 0
+`))
+
+	var syntaxErr *SyntaxError
+	if err := parser.Err(); !errors.As(err, &syntaxErr) || !errors.Is(err, ErrOperand) {
+		t.Errorf("expected syntax error, got: %v", err)
+	}
+
+	blkw := &BLKW{}
+
+	if err := blkw.Parse(".BLKW", []string{"#-1"}); !errors.Is(err, ErrOperand) {
+		t.Errorf("negative count: want: %v, got: %v", ErrOperand, err)
+	}
+
+	if err := blkw.Parse(".BLKW", []string{"x8000"}); err != nil {
+		t.Errorf("large count: %v", err)
+	} else if blkw.ALLOC != 0x8000 {
+		t.Errorf("large count: want: %s, got: %s", vm.Word(0x8000), blkw.ALLOC)
+	}
+}
+
+func TestGenerator_Verify(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+START   AND R0,R0,#0
+        ADD R1,R0,#-16
+        ADD R2,R1,R0
+        NOT R3,R2
+LOOP    BRnz LOOP
+        BRp START
+        LD R4,DATA
+        LDI R5,PTR
+        LDR R6,R5,#-32
+        LEA R0,MSG
+        ST R4,DATA
+        STI R5,PTR
+        STR R6,R5,#31
+        JSR SUB
+        JSRR R2
+        JMP R7
+        MOV R1,#3
+        NOP
+        PUTS
+        TRAP x25
+SUB     RET
+        RTI
+DATA    .FILL x7fff
+PTR     .FILL DATA
+MSG     .STRINGZ "Hi!"
+BUF     .BLKW 2, x1234
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if err := gen.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerator_VerifyWord(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	if err := verifyWord(0x96bf, 0x3000); err != nil {
+		t.Errorf("NOT R3,R2: %v", err)
+	}
+
+	tcs := []vm.Word{
+		0x96a0, // NOT with its low bits clear.
+		0x0005, // BR with no condition and an offset.
+		0xd123, // Reserved opcode.
+	}
+
+	for _, word := range tcs {
+		if err := verifyWord(word, 0x3000); !errors.Is(err, ErrVerify) {
+			t.Errorf("%s: want: %v, got: %v", word, ErrVerify, err)
+		}
+	}
+}
+
+func TestGenerator_Entry(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+MSG     .STRINGZ "Hi"
+MAIN    LEA R0,MSG
+        PUTS
+        HALT
+        .ENTRY MAIN
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	code, err := gen.ObjectCode()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(code) != 1 {
+		t.Fatalf("sections: want: 1, got: %d", len(code))
+	}
+
+	if code[0].Orig != 0x3000 || code[0].Entry == nil || *code[0].Entry != 0x3003 {
+		t.Errorf("want: orig: 0x3000, entry: 0x3003, got: orig: %s, entry: %v",
+			code[0].Orig, code[0].Entry)
+	}
+
+	if len(code[0].Code) != 6 {
+		t.Errorf("code: want: 6 words, got: %d", len(code[0].Code))
+	}
+
+	machine := vm.New()
+	loader := vm.NewLoader(machine)
+
+	if _, err := loader.Load(code[0]); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x3003 {
+		t.Errorf("PC: want: 0x3003, got: %s", machine.PC)
+	}
+
+	// The entry point survives a round trip through an object file.
+	var buf bytes.Buffer
+
+	if _, err := gen.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := vm.ReadObjectCode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if obj.Entry == nil || *obj.Entry != 0x3003 {
+		t.Errorf("read: entry: want: 0x3003, got: %v", obj.Entry)
+	}
+}
+
+func TestGenerator_EntryUndefined(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        HALT
+        .ENTRY MAIN
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := gen.ObjectCode(); !errors.Is(err, &SymbolError{}) {
+		t.Errorf("want: symbol error, got: %v", err)
+	}
+}
+
+func TestGenerator_Relocations(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .EXTERNAL PRINT
+        .EXTERNAL BUFFER
+        .ORIG x3000
+        LEA R0,MSG
+        JSR PRINT
+        LD R1,BUFFER
+        HALT
+MSG     .STRINGZ "Hi"
+PTR     .FILL x1234, BUFFER
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	code, err := gen.ObjectCode()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(code) != 1 {
+		t.Fatalf("sections: want: 1, got: %d", len(code))
+	}
+
+	want := []Relocation{
+		{Loc: 0x3001, Symbol: "PRINT", Bits: 11},
+		{Loc: 0x3002, Symbol: "BUFFER", Bits: 9},
+		{Loc: 0x3008, Symbol: "BUFFER", Bits: 16},
+	}
+
+	if got := gen.Relocations(); !slices.Equal(got, want) {
+		t.Errorf("relocations: want: %v, got: %v", want, got)
+	}
+
+	// Placeholders leave the operands zero.
+	placeholders := map[vm.Word]vm.Word{0x3001: 0x4800, 0x3002: 0x2200, 0x3008: 0x0000}
+
+	for loc, word := range placeholders {
+		if got := code[0].Code[loc-code[0].Orig]; got != word {
+			t.Errorf("%s: want: %s, got: %s", loc, word, got)
+		}
+	}
+}
+
+func TestGenerator_UndeclaredExternal(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .EXTERNAL PRINT
+        .ORIG x3000
+        JSR PRINTF
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := gen.ObjectCode(); !errors.Is(err, &SymbolError{}) {
+		t.Errorf("want: symbol error, got: %v", err)
+	} else if len(gen.Relocations()) != 0 {
+		t.Errorf("relocations: want: none, got: %v", gen.Relocations())
+	}
+}
+
+func TestGenerator_UndefinedTrap(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        TRAP x30
+        TRAP x31
+        HALT
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax(), WithTrapCheck(vm.TrapHALT, 0x31))
+
+	if _, err := gen.ObjectCode(); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := gen.Warnings()
+
+	if len(warnings) != 1 {
+		t.Fatalf("warnings: want: 1, got: %v", warnings)
+	} else if warnings[0].Loc != 0x3000 {
+		t.Errorf("warning loc: want: %s, got: %s", vm.Word(0x3000), warnings[0].Loc)
+	}
+}
+
+func TestGenerator_DeclaredTrap(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .TRAP x30, HANDLER
+        .ORIG x3000
+        TRAP x30
+        HALT
+HANDLER RET
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax(), WithTrapCheck(vm.TrapHALT))
+
+	code, err := gen.ObjectCode()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(gen.Warnings()) != 0 {
+		t.Errorf("warnings: want: none, got: %v", gen.Warnings())
+	}
+
+	if len(code) != 2 {
+		t.Fatalf("sections: want: 2, got: %d", len(code))
+	}
+
+	table := code[1]
+
+	if table.Orig != 0x0030 {
+		t.Errorf("vector: want: %s, got: %s", vm.Word(0x0030), table.Orig)
+	} else if !slices.Equal(table.Code, []vm.Word{0x3002}) {
+		t.Errorf("handler: want: %s, got: %v", vm.Word(0x3002), table.Code)
+	}
+}
+
+func TestGenerator_DeclaredTrapUndefined(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .TRAP x30, HANDLER
+        .ORIG x3000
+        TRAP x30
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := gen.ObjectCode(); !errors.Is(err, &SymbolError{}) {
+		t.Errorf("want: symbol error, got: %v", err)
+	}
+}
+
+func TestGenerator_Lint(tt *testing.T) {
+	tcs := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "fall through",
+			src: `
+        .ORIG x3000
+        JSR FIRST
+        JSR SECOND
+        HALT
+FIRST   ADD R0,R0,#1
+SECOND  ADD R0,R0,#2
+        RET
+        .END
+`,
+			want: []string{"subroutine FIRST falls through to SECOND without RET"},
+		},
+		{
+			name: "tail call",
+			src: `
+        .ORIG x3000
+        JSR OUTER
+        HALT
+OUTER   JSR INNER
+        RET
+INNER   RET
+        .END
+`,
+			want: []string{"tail call: JSR INNER followed by RET could be BR"},
+		},
+		{
+			name: "clean",
+			src: `
+        .ORIG x3000
+        JSR FIRST
+        JSR SECOND
+        HALT
+FIRST   ADD R0,R0,#1
+        RET
+SECOND  ADD R0,R0,#2
+        BRp DONE
+        ADD R0,R0,#1
+DONE    RET
+        .END
+`,
+			want: nil,
+		},
+		{
+			name: "branch to self",
+			src: `
+        .ORIG x3000
+WAIT    BRz WAIT
+        BRnzp #0
+        HALT
+        .END
+`,
+			want: []string{"BR to itself", "BR to next instruction has no effect"},
+		},
+		{
+			name: "branch into data",
+			src: `
+        .ORIG x3000
+        BRp #5
+        BRz #11
+        JSR #3
+        BRn BUF
+        HALT
+MSG     .STRINGZ "hello"
+BUF     .BLKW 4
+        .END
+`,
+			want: []string{
+				"BR into data block at 0x3005",
+				"BR into data block at 0x300b",
+				"JSR into data block at 0x3005",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := ParserHarness{T: tt}
+			parser := t.ParseStream(t.inputString(tc.src))
+
+			if err := parser.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+			var got []string
+			for _, warning := range gen.Lint() {
+				got = append(got, warning.Msg)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("warnings: want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerator_CrossReference(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+LOOP    ADD R0,R0,#-1
+        BRp LOOP
+        LEA R1,LOOP
+        JSR SUB
+SUB     RET
+DATA    .FILL LOOP+1
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+	got := gen.CrossReference()
+
+	want := map[string][]vm.Word{
+		"LOOP": {0x3001, 0x3002, 0x3005},
+		"SUB":  {0x3003},
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("symbols: want: %v, got: %v", want, got)
+	}
+
+	for sym, locs := range want {
+		if !slices.Equal(got[sym], locs) {
+			t.Errorf("%s: want: %v, got: %v", sym, locs, got[sym])
+		}
+	}
+}
+
+func TestReferences_Case(t *testing.T) {
+	// Operations that are not parsed, e.g. the monitor's routines, may refer to symbols in any case.
+	tcs := []struct {
+		oper Operation
+		want []string
+	}{
+		{&LD{DR: "R0", SYMBOL: "loop"}, []string{"LOOP"}},
+		{&FILL{SYMBOL: []string{"loop+1", "Data"}}, []string{"LOOP", "DATA"}},
+	}
+
+	for _, tc := range tcs {
+		if got := references(tc.oper); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: want: %v, got: %v", tc.oper, tc.want, got)
+		}
+	}
+}
+
+func TestGenerator_Optimize(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name    string
+		src     string
+		code    []vm.Word
+		changes int
+	}{
+		{
+			name: "BR to next",
+			src: `
+        BRz NEXT
+NEXT    HALT`,
+			code:    []vm.Word{0xf025},
+			changes: 1,
+		},
+		{
+			name: "ADD zero",
+			src: `
+        ADD R1,R1,#0
+        ADD R2,R2,#1
+        HALT`,
+			code:    []vm.Word{0x14a1, 0xf025},
+			changes: 1,
+		},
+		{
+			name: "ADD zero sets condition",
+			src: `
+        ADD R1,R1,#0
+        BRz DONE
+DONE    HALT`,
+			code:    []vm.Word{0x1260, 0xf025},
+			changes: 1, // The branch to the next instruction, only.
+		},
+		{
+			name: "MOV to itself",
+			src: `
+        MOV R3,R3
+        NOT R3,R3
+        HALT`,
+			code:    []vm.Word{0x96ff, 0xf025},
+			changes: 1,
+		},
+		{
+			name: "load constant",
+			src: `
+        AND R0,R0,#0
+        ADD R0,R0,#5
+        HALT`,
+			code: []vm.Word{0x5020, 0x1025, 0xf025},
+		},
+		{
+			name: "labels",
+			src: `
+LOOP    ADD R1,R1,#-1
+        BRnzp SKIP
+SKIP    ADD R2,R2,#0
+        ADD R2,R2,#1
+        BRp LOOP
+DATA    .FILL DATA`,
+			code:    []vm.Word{0x127f, 0x14a0, 0x14a1, 0x03fc, 0x3004},
+			changes: 1, // The labeled ADD is kept.
+		},
+		{
+			name: "literal offset",
+			src: `
+        BRnzp #2
+        BRnzp NEXT
+NEXT    ADD R1,R1,#1
+        HALT`,
+			code: []vm.Word{0x0e02, 0x0e00, 0x1261, 0xf025},
+		},
+	}
+
+	for _, tc := range tcs {
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := ParserHarness{T: tt}
+			parser := t.ParseStream(t.inputString(".ORIG x3000\n" + tc.src + "\n.END\n"))
+
+			if err := parser.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			gen := NewGenerator(parser.Symbols(), parser.Syntax())
+			_, changes := gen.Optimize()
+
+			if len(changes) != tc.changes {
+				t.Errorf("changes: want: %d, got: %v", tc.changes, changes)
+			}
+
+			code, err := gen.ObjectCode()
+			if err != nil {
+				t.Fatal(err)
+			} else if len(code) != 1 || !slices.Equal(code[0].Code, tc.code) {
+				t.Errorf("code: want: %v, got: %v", tc.code, code)
+			}
+		})
+	}
+}
+
+func TestDisassembleWithSymbols(tt *testing.T) {
+	t := generatorHarness{tt}
+
+	code := []vm.Word{
+		0xe003, // LOOP: LEA R0,MSG
+		0xf022, //       TRAP x22
+		0x0ffd, //       BRnzp LOOP
+		0x2205, //       LD R1,#5
+		0x0048, // MSG:  .FILL x0048
+	}
+	symbols := SymbolTable{"LOOP": 0x3000, "MSG": 0x3004, "START": 0x3000}
+
+	want := []string{
+		"LEA R0,MSG",
+		"TRAP x22",
+		"BRnzp LOOP",
+		"LD R1,#5 ; x3009",
+		".FILL x0048",
+	}
+
+	if got := DisassembleWithSymbols(code, 0x3000, symbols); !slices.Equal(want, got) {
+		t.Errorf("want: %q\ngot:  %q", want, got)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/smoynes/elsie/internal/log"
@@ -103,6 +105,8 @@ func TestAssembler_Gold(tt *testing.T) {
 
 			if parser.Err() != nil {
 				t.Error(parser.Err())
+			} else if err := parser.LocCheck(); err != nil {
+				t.Error(err)
 			}
 
 			syntax := parser.Syntax()
@@ -117,7 +121,7 @@ func TestAssembler_Gold(tt *testing.T) {
 			)
 
 			if tc.expectedHex == nil {
-				count, err = generator.writeTo(&out)
+				count, err = generator.WriteTo(&out)
 			} else {
 				bs, err := generator.Encode()
 				if err != nil {
@@ -168,3 +172,120 @@ func TestAssembler_Gold(tt *testing.T) {
 		})
 	}
 }
+
+func TestAssembler_GoldFormats(tt *testing.T) {
+	t := assemblerHarness{tt}
+
+	parser := NewParser(t.logger())
+	parser.Parse(t.inputStream("parser6.asm"))
+
+	if parser.Err() != nil {
+		t.Fatal(parser.Err())
+	}
+
+	generator := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	var obj, bin, sym bytes.Buffer
+
+	if _, err := generator.WriteTo(&obj); err != nil {
+		t.Fatal(err)
+	} else if _, err := generator.WriteBinary(&bin); err != nil {
+		t.Fatal(err)
+	} else if _, err := generator.WriteSymbolTable(&sym); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		filename string
+		got      []byte
+	}{
+		{"parser6.out", obj.Bytes()},
+		{"parser6.bin", bin.Bytes()},
+		{"parser6.sym", sym.Bytes()},
+	} {
+		expected, err := io.ReadAll(t.expectOutput(tc.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(expected, tc.got) {
+			t.Errorf("%s: not equal:\nwant: %q\ngot:  %q", tc.filename, expected, tc.got)
+		}
+	}
+
+	// The ASCII binary format should decode to the same words as the object format.
+	var decoded bytes.Buffer
+
+	lines := bufio.NewScanner(&bin)
+	for lines.Scan() {
+		word, err := strconv.ParseUint(lines.Text(), 2, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded.Write([]byte{byte(word >> 8), byte(word)})
+	}
+
+	if !bytes.Equal(decoded.Bytes(), obj.Bytes()) {
+		t.Errorf("round trip: not equal:\nbin: %x\nobj: %x", decoded.Bytes(), obj.Bytes())
+	}
+}
+
+func TestAssembler_GoldFormatsSections(tt *testing.T) {
+	t := assemblerHarness{tt}
+
+	parser := NewParser(t.logger())
+	parser.Parse(t.inputStream("parser10.asm"))
+
+	if parser.Err() != nil {
+		t.Fatal(parser.Err())
+	}
+
+	generator := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	if _, err := generator.WriteTo(io.Discard); err == nil {
+		t.Error("expected error writing multiple sections")
+	} else if _, err := generator.WriteBinary(io.Discard); err == nil {
+		t.Error("expected error writing multiple sections")
+	}
+}
+
+func TestAssembler_GoldFormat(tt *testing.T) {
+	t := assemblerHarness{tt}
+
+	var out bytes.Buffer
+
+	if err := Format(&out, t.inputStream("format1.asm")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := io.ReadAll(t.expectOutput("format1.fmt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected, out.Bytes()) {
+		t.Errorf("not equal:\nwant: %q\ngot:  %q", expected, out.Bytes())
+	}
+
+	// Formatting is idempotent.
+	var again bytes.Buffer
+
+	if err := Format(&again, bytes.NewReader(out.Bytes())); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(out.Bytes(), again.Bytes()) {
+		t.Errorf("reformatted:\nwant: %q\ngot:  %q", out.Bytes(), again.Bytes())
+	}
+
+	// Columns may be aligned with tabs.
+	out.Reset()
+
+	src := ".ORIG x3000\nLOOP ADD R0, R0, #1 ; Count.\n"
+	want := "\t.ORIG\tx3000\nLOOP\tADD\tR0,R0,#1\t; Count.\n"
+
+	if err := Format(&out, strings.NewReader(src), WithTabs(8)); err != nil {
+		t.Fatal(err)
+	} else if out.String() != want {
+		t.Errorf("tabs:\nwant: %q\ngot:  %q", want, out.String())
+	}
+}
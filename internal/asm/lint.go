@@ -0,0 +1,218 @@
+package asm
+
+// lint.go contains a static analysis of the syntax table that finds dubious, but valid, code.
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// Lint analyses the syntax table and returns advisory warnings about the program's calling
+// conventions:
+//
+//   - a subroutine, i.e. a label that is the target of a JSR, that falls through to another
+//     subroutine or to labelled data without returning; and
+//   - a JSR that is immediately followed by a RET, i.e. a tail call that could be a BR;
+//   - a BR or JSR to itself, often a typo, and a BR to the next instruction, which has no effect;
+//     and
+//   - a BR or JSR into the middle of a data block, i.e. a .STRINGZ, .STRINGP, .INCBIN or .BLKW.
+//
+// Lint does not generate code and the warnings do not prevent generating code.
+func (gen *Generator) Lint() []Warning {
+	var (
+		warnings []Warning
+		subs     = make(map[string]bool)      // Subroutine labels.
+		labels   = make(map[vm.Word][]string) // Labels at each location.
+		seen     = make(map[vm.Word]bool)     // Locations whose labels have been checked.
+	)
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		if jsr, ok := unwrap(si).(*JSR); ok && jsr.SYMBOL != "" {
+			subs[jsr.SYMBOL] = true
+		}
+
+		return nil
+	})
+
+	for name, loc := range gen.symbols {
+		labels[loc] = append(labels[loc], name)
+	}
+
+	for loc := range labels {
+		slices.Sort(labels[loc])
+	}
+
+	var (
+		current string      // Subroutine being analysed, if any.
+		prev    *SourceInfo // Previous instruction in the section, if any.
+	)
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		switch unwrap(si).(type) {
+		case *ORIG, *END:
+			current, prev = "", nil
+			return nil
+		case *ENTRY, *EXTERNAL, *TRAPVEC:
+			return nil
+		}
+
+		if !seen[si.Loc] {
+			seen[si.Loc] = true
+			names := labels[si.Loc]
+
+			// Local labels, e.g. branch targets, are expected within a subroutine. Falling through
+			// to another subroutine or to data is not.
+			if current != "" && len(names) > 0 && !slices.Contains(names, current) {
+				for _, name := range names {
+					if subs[name] || isData(unwrap(si)) {
+						warnings = append(warnings, sourceWarning(si,
+							"subroutine %s falls through to %s without RET", current, name))
+						current = ""
+
+						break
+					}
+				}
+			}
+
+			for _, name := range names {
+				if subs[name] {
+					current = name
+				}
+			}
+		}
+
+		if _, ok := unwrap(si).(*RET); ok && prev != nil {
+			if jsr, ok := unwrap(prev).(*JSR); ok {
+				warnings = append(warnings, sourceWarning(prev,
+					"tail call: JSR %s followed by RET could be BR", jsr.SYMBOL))
+			}
+		}
+
+		if returns(unwrap(si)) {
+			current = ""
+		}
+
+		prev = si
+
+		return nil
+	})
+
+	return append(warnings, gen.lintTargets()...)
+}
+
+// extent is the range of locations of a data block.
+type extent struct {
+	start, end vm.Word // Half-open: [start, end).
+}
+
+// lintTargets checks the targets of branches and subroutine calls.
+func (gen *Generator) lintTargets() []Warning {
+	var (
+		warnings []Warning
+		blocks   []extent
+	)
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		switch oper := unwrap(si).(type) {
+		case *STRINGZ:
+			blocks = append(blocks, extent{si.Loc, si.Loc + oper.Size()})
+		case *STRINGP:
+			blocks = append(blocks, extent{si.Loc, si.Loc + oper.Size()})
+		case *INCBIN:
+			blocks = append(blocks, extent{si.Loc, si.Loc + oper.Size()})
+		case *BLKW:
+			blocks = append(blocks, extent{si.Loc, si.Loc + oper.ALLOC})
+		}
+
+		return nil
+	})
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		var (
+			target vm.Word
+			ok     bool
+			branch bool
+			name   string
+		)
+
+		switch oper := unwrap(si).(type) {
+		case *BR:
+			target, ok = gen.target(oper, si.Loc)
+			branch, name = true, "BR"
+		case *JSR:
+			target, ok = gen.target(oper, si.Loc)
+			name = "JSR"
+		}
+
+		switch {
+		case !ok:
+			return nil
+		case target == si.Loc:
+			warnings = append(warnings, sourceWarning(si, "%s to itself", name))
+		case branch && target == si.Loc+1:
+			warnings = append(warnings, sourceWarning(si, "BR to next instruction has no effect"))
+		}
+
+		for _, block := range blocks {
+			if block.start < target && target < block.end {
+				warnings = append(warnings, sourceWarning(si,
+					"%s into data block at %s", name, block.start))
+			}
+		}
+
+		return nil
+	})
+
+	return warnings
+}
+
+// target encodes a PC-relative or indirect operation located at loc and decodes its target. It is
+// not ok if the operation cannot be encoded, e.g. if its symbol is undefined.
+func (gen *Generator) target(oper Operation, loc vm.Word) (vm.Word, bool) {
+	code, err := oper.Generate(gen.symbols, loc+1)
+	if err != nil || len(code) != 1 {
+		return 0, false
+	}
+
+	dec := vm.Instruction(code[0]).Decode(loc + 1)
+
+	return dec.Target, dec.Mode == vm.ModePCRelative || dec.Mode == vm.ModeIndirect
+}
+
+// returns is true if an operation does not continue to the next instruction: it returns, jumps,
+// unconditionally branches, or halts.
+func returns(oper Operation) bool {
+	switch oper := oper.(type) {
+	case *RET, *JMP, *RTI:
+		return true
+	case *BR:
+		return oper.NZP == CondNZP
+	case *TRAP:
+		return oper.LITERAL == uint16(vm.TrapHALT)
+	default:
+		return false
+	}
+}
+
+// isData is true if an operation allocates data rather than code.
+func isData(oper Operation) bool {
+	switch oper.(type) {
+	case *FILL, *BLKW, *STRINGZ, *STRINGP, *INCBIN:
+		return true
+	default:
+		return false
+	}
+}
+
+// sourceWarning returns a warning located at an operation's source.
+func sourceWarning(si *SourceInfo, format string, args ...any) Warning {
+	return Warning{
+		File: si.Filename,
+		Loc:  si.Loc,
+		Pos:  si.Pos,
+		Line: si.Line,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
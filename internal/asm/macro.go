@@ -0,0 +1,183 @@
+package asm
+
+// macro.go implements simple text-substitution macros.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// macro is a named template of source lines. When the macro is invoked, its parameters are
+// substituted with the invocation's arguments and the lines are parsed in place of the invocation.
+//
+//	.MACRO PUSH reg
+//	    ADD R6,R6,#-1
+//	    STR reg,R6,#0
+//	.ENDM
+//
+//	    PUSH R1
+type macro struct {
+	name   string
+	params []string
+	lines  []string
+}
+
+// defineMacro begins the definition of a macro. Subsequent lines are recorded in the macro's
+// template until the .ENDM directive.
+func (p *Parser) defineMacro(arg string) {
+	fields := strings.FieldsFunc(arg, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	if len(fields) == 0 {
+		p.addSyntaxError(fmt.Errorf(".MACRO: %w: missing name", ErrMacro))
+		return
+	}
+
+	name := strings.ToUpper(fields[0])
+
+	if !symbolPattern.MatchString(name) || p.isReservedKeyword(name) {
+		p.addSyntaxError(fmt.Errorf(".MACRO: %w: invalid name: %s", ErrMacro, fields[0]))
+		return
+	}
+
+	params := make([]string, 0, len(fields)-1)
+
+	for _, param := range fields[1:] {
+		if !symbolPattern.MatchString(param) || p.isReservedKeyword(strings.ToUpper(param)) {
+			p.addSyntaxError(fmt.Errorf(".MACRO: %w: invalid parameter: %s", ErrMacro, param))
+			return
+		}
+
+		params = append(params, strings.ToUpper(param))
+	}
+
+	p.defining = &macro{name: name, params: params}
+}
+
+// recordMacro adds a line to the macro being defined or, if the line is an .ENDM directive, ends
+// the definition.
+func (p *Parser) recordMacro(line string) {
+	text := line
+
+	if i := indexComment(text); i >= 0 {
+		text = text[:i]
+	}
+
+	if strings.EqualFold(strings.TrimSpace(text), ".ENDM") {
+		if p.macros == nil {
+			p.macros = make(map[string]*macro)
+		}
+
+		p.macros[p.defining.name] = p.defining
+		p.defining = nil
+
+		return
+	}
+
+	p.defining.lines = append(p.defining.lines, line)
+}
+
+// expandMacro substitutes the macro's parameters with arguments and parses the resulting lines.
+// Operations in the expansion are attributed to the invocation's source line. Errors are, too, though
+// their columns are unknown.
+func (p *Parser) expandMacro(m *macro, args []string) error {
+	if len(args) != len(m.params) {
+		p.addSyntaxError(fmt.Errorf("%s: %w: expected %d arguments, got %d",
+			m.name, ErrMacro, len(m.params), len(args)))
+
+		return nil
+	} else if p.expanding[m.name] {
+		p.addSyntaxError(fmt.Errorf("%s: %w: recursive expansion", m.name, ErrMacro))
+		return nil
+	}
+
+	if p.expanding == nil {
+		p.expanding = make(map[string]bool)
+	}
+
+	p.expanding[m.name] = true
+	defer delete(p.expanding, m.name)
+
+	errs := len(p.errs)
+
+	for _, line := range m.lines {
+		if err := p.parseLine(substituteParams(line, m.params, args)); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+	}
+
+	for _, err := range p.errs[errs:] {
+		if se, ok := err.(*SyntaxError); ok {
+			se.Col = 0
+		}
+	}
+
+	return nil
+}
+
+// substituteParams replaces each identifier in line that names a parameter with its argument.
+// Parameter names are case-insensitive. Quoted literals, e.g. .STRINGZ text, and comments are not
+// changed.
+func substituteParams(line string, params, args []string) string {
+	var (
+		out     strings.Builder
+		comment string
+		start   = -1 // Start of the current identifier or -1, if none.
+		quote   rune // Quote character of the current literal or zero, if unquoted.
+		escaped bool // Whether the previous character in a literal was a backslash.
+	)
+
+	if i := indexComment(line); i >= 0 {
+		line, comment = line[:i], line[i:]
+	}
+
+	isIdent := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	replace := func(word string) string {
+		for i := range params {
+			if strings.EqualFold(word, params[i]) {
+				return args[i]
+			}
+		}
+
+		return word
+	}
+
+	for i, r := range line {
+		if quote == 0 && isIdent(r) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		} else if start >= 0 {
+			out.WriteString(replace(line[start:i]))
+			start = -1
+		}
+
+		switch {
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case r == quote:
+			quote = 0
+		}
+
+		out.WriteRune(r)
+	}
+
+	if start >= 0 {
+		out.WriteString(replace(line[start:]))
+	}
+
+	out.WriteString(comment)
+
+	return out.String()
+}
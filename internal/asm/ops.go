@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
 	"strconv"
 	"strings"
 	"unicode/utf16"
@@ -78,26 +79,42 @@ func (br *BR) Parse(opcode string, opers []string) error {
 func (br BR) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	code := vm.NewInstruction(vm.BR, uint16(br.NZP)<<9)
 
-	if br.SYMBOL != "" {
-		offset, err := symbols.Offset(br.SYMBOL, pc, 9)
+	if br.SYMBOL == "" && br.OFFSET > 0x01ff {
+		return nil, &OffsetRangeError{
+			Range:  1 << 9,
+			Offset: br.OFFSET,
+		}
+	}
+
+	if err := emitPCRelative(&code, symbols, br.SYMBOL, br.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("br: %w", err)
+	}
 
+	return []vm.Word{code.Encode()}, nil
+}
+
+// emitPCRelative encodes a PC-relative operand in the low bits of an instruction: the offset of a
+// symbol from the program counter, if there is a symbol, or otherwise a literal offset. An error is
+// returned if the symbol is not defined or is out of range of the n-bit offset.
+func emitPCRelative(code *vm.Instruction, symbols SymbolTable, sym string, offset uint16, pc vm.Word,
+	bits uint8,
+) error {
+	mask := vm.Word(1)<<bits - 1
+
+	if sym != "" {
+		symOffset, err := symbols.Offset(sym, pc, bits)
 		if err != nil {
-			return nil, fmt.Errorf("br: %w", err)
+			return err
 		}
 
-		code.Operand(offset & 0x01ff)
-	} else {
-		if br.OFFSET > 0x01ff {
-			return nil, &OffsetRangeError{
-				Range:  1 << 9,
-				Offset: br.OFFSET,
-			}
-		}
+		code.Operand(symOffset & mask)
 
-		code.Operand(vm.Word(br.OFFSET) & 0x01ff)
+		return nil
 	}
 
-	return []vm.Word{code.Encode()}, nil
+	code.Operand(vm.Word(offset) & mask)
+
+	return nil
 }
 
 // AND: Bitwise AND binary operator.
@@ -140,7 +157,7 @@ func (and *AND) Parse(oper string, opers []string) error {
 		return nil
 	}
 
-	off, sym, err := parseImmediate(opers[2], 5)
+	off, sym, err := parseImm5(opers[2])
 	if err != nil {
 		return err
 	}
@@ -221,7 +238,7 @@ func (ld *LD) Parse(opcode string, operands []string) error {
 	}
 
 	*ld = LD{
-		DR: operands[0],
+		DR: registerName(operands[0]),
 	}
 
 	ld.OFFSET, ld.SYMBOL, err = parseImmediate(operands[1], 9)
@@ -240,16 +257,8 @@ func (ld LD) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.LD, dr<<9)
 
-	switch {
-	case ld.SYMBOL != "":
-		offset, err := symbols.Offset(ld.SYMBOL, pc, 8)
-		if err != nil {
-			return nil, fmt.Errorf("and: %w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(ld.OFFSET) & 0x0ff)
+	if err := emitPCRelative(&code, symbols, ld.SYMBOL, ld.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("ld: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -284,8 +293,8 @@ func (ldr *LDR) Parse(opcode string, operands []string) error {
 	}
 
 	*ldr = LDR{
-		DR: operands[0],
-		SR: operands[1],
+		DR: registerName(operands[0]),
+		SR: registerName(operands[1]),
 	}
 
 	ldr.OFFSET, ldr.SYMBOL, err = parseImmediate(operands[2], 6)
@@ -349,7 +358,7 @@ func (lea *LEA) Parse(opcode string, operands []string) error {
 	}
 
 	*lea = LEA{
-		DR: operands[0],
+		DR: registerName(operands[0]),
 	}
 
 	lea.OFFSET, lea.SYMBOL, err = parseImmediate(operands[1], 9)
@@ -369,16 +378,8 @@ func (lea LEA) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.LEA, dr<<9)
 
-	switch {
-	case lea.SYMBOL != "":
-		offset, err := symbols.Offset(lea.SYMBOL, pc, 9)
-		if err != nil {
-			return nil, fmt.Errorf("%w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(lea.OFFSET) & 0x01ff)
+	if err := emitPCRelative(&code, symbols, lea.SYMBOL, lea.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("lea: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -412,7 +413,7 @@ func (ldi *LDI) Parse(opcode string, operands []string) error {
 	}
 
 	*ldi = LDI{
-		DR: operands[0],
+		DR: registerName(operands[0]),
 	}
 
 	ldi.OFFSET, ldi.SYMBOL, err = parseImmediate(operands[1], 9)
@@ -432,16 +433,8 @@ func (ldi LDI) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.LDI, sr<<9)
 
-	switch {
-	case ldi.SYMBOL != "":
-		offset, err := symbols.Offset(ldi.SYMBOL, pc, 9)
-		if err != nil {
-			return nil, fmt.Errorf("%w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(ldi.OFFSET) & 0x01ff)
+	if err := emitPCRelative(&code, symbols, ldi.SYMBOL, ldi.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("ldi: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -474,7 +467,7 @@ func (st *ST) Parse(opcode string, operands []string) error {
 	}
 
 	*st = ST{
-		SR: operands[0],
+		SR: registerName(operands[0]),
 	}
 
 	st.OFFSET, st.SYMBOL, err = parseImmediate(operands[1], 9)
@@ -494,16 +487,8 @@ func (st ST) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.ST, dr<<9)
 
-	switch {
-	case st.SYMBOL != "":
-		offset, err := symbols.Offset(st.SYMBOL, pc, 9)
-		if err != nil {
-			return nil, fmt.Errorf("st: %w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(st.OFFSET) & 0x01ff)
+	if err := emitPCRelative(&code, symbols, st.SYMBOL, st.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("st: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -537,7 +522,7 @@ func (sti *STI) Parse(opcode string, operands []string) error {
 	}
 
 	*sti = STI{
-		SR: operands[0],
+		SR: registerName(operands[0]),
 	}
 
 	sti.OFFSET, sti.SYMBOL, err = parseImmediate(operands[1], 9)
@@ -557,16 +542,8 @@ func (sti STI) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.STI, dr<<9)
 
-	switch {
-	case sti.SYMBOL != "":
-		offset, err := symbols.Offset(sti.SYMBOL, pc, 9)
-		if err != nil {
-			return nil, fmt.Errorf("%w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(sti.OFFSET) & 0x01ff)
+	if err := emitPCRelative(&code, symbols, sti.SYMBOL, sti.OFFSET, pc, 9); err != nil {
+		return nil, fmt.Errorf("sti: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -601,8 +578,8 @@ func (str *STR) Parse(opcode string, operands []string) error {
 	}
 
 	*str = STR{
-		SR1: operands[0],
-		SR2: operands[1],
+		SR1: registerName(operands[0]),
+		SR2: registerName(operands[1]),
 	}
 
 	str.OFFSET, str.SYMBOL, err = parseImmediate(operands[2], 6)
@@ -625,16 +602,8 @@ func (str STR) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 
 	code := vm.NewInstruction(vm.STR, sr1<<9|sr2<<6)
 
-	switch {
-	case str.SYMBOL != "":
-		offset, err := symbols.Offset(str.SYMBOL, pc, 5)
-		if err != nil {
-			return nil, fmt.Errorf("%w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(str.OFFSET) & 0x003f)
+	if err := emitPCRelative(&code, symbols, str.SYMBOL, str.OFFSET, pc, 6); err != nil {
+		return nil, fmt.Errorf("str: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -663,7 +632,7 @@ func (jmp *JMP) Parse(opcode string, operands []string) error {
 	}
 
 	*jmp = JMP{
-		SR: operands[0],
+		SR: registerName(operands[0]),
 	}
 
 	return nil
@@ -753,12 +722,12 @@ func (add *ADD) Parse(opcode string, operands []string) error {
 	if sr2 := parseRegister(operands[2]); sr2 != "" {
 		add.SR2 = sr2
 	} else {
-		off, _, err := parseImmediate(operands[2], 5)
+		off, _, err := parseImm5(operands[2])
 		if err != nil {
 			return err
 		}
 
-		add.LITERAL = off & 0x1f
+		add.LITERAL = off
 	}
 
 	return nil
@@ -794,41 +763,42 @@ func (add ADD) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 // TRAP: System call or software interrupt.
 //
 //	TRAP x25
+//	HALT
 //
 //	| 1111 | 0000 | VECTOR8 |
 //	|------+------+---------|
 //	|15  12|11   8|7       0|
 //
-// .
+// The standard service routines have aliases that take no operands: GETC, OUT, PUTS, IN, PUTSP and
+// HALT.
 type TRAP struct {
 	LITERAL uint16
 }
 
+// trapAliases maps trap aliases to their vectors.
+var trapAliases = map[string]uint8{
+	"GETC":  vm.TrapGETC,
+	"OUT":   vm.TrapOUT,
+	"PUTS":  vm.TrapPUTS,
+	"IN":    vm.TrapIN,
+	"PUTSP": vm.TrapPUTSP,
+	"HALT":  vm.TrapHALT,
+}
+
 func (trap TRAP) String() string { return fmt.Sprintf("%#v", trap) }
 
 func (trap *TRAP) Parse(opcode string, operands []string) error {
-	switch {
-	case opcode == "HALT":
-		if len(operands) != 0 {
-			return fmt.Errorf("HALT: %w", ErrOperand)
-		}
-
-		*trap = TRAP{LITERAL: uint16(vm.TrapHALT)}
+	opcode = strings.ToUpper(opcode)
 
-		return nil
-	case opcode == "OUT":
+	if vector, ok := trapAliases[opcode]; ok {
 		if len(operands) != 0 {
-			return fmt.Errorf("HALT: %w", ErrOperand)
+			return fmt.Errorf("%s: %w", opcode, ErrOperand)
 		}
 
-		*trap = TRAP{LITERAL: uint16(vm.TrapOUT)}
+		*trap = TRAP{LITERAL: uint16(vector)}
 
 		return nil
-	case opcode == "TRAP":
-		if len(operands) != 1 {
-			return ErrOperand
-		}
-	default:
+	} else if opcode != "TRAP" || len(operands) != 1 {
 		return ErrOperand
 	}
 
@@ -849,6 +819,32 @@ func (trap TRAP) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	return []vm.Word{code.Encode()}, nil
 }
 
+// NOP: No operation. A pseudo-instruction for a branch that is never taken, i.e. BR with no
+// condition flags.
+//
+//	NOP
+//
+//	| 0000 | 000 | 0 0000 0000 |
+//	|------+-----+-------------|
+//	|15  12|11  9|8           0|
+type NOP struct{}
+
+func (nop NOP) String() string { return "NOP" }
+
+func (nop *NOP) Parse(opcode string, operands []string) error {
+	if strings.ToUpper(opcode) != "NOP" {
+		return ErrOpcode
+	} else if len(operands) != 0 {
+		return fmt.Errorf("NOP: %w", ErrOperand)
+	}
+
+	return nil
+}
+
+func (nop NOP) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	return []vm.Word{vm.NewInstruction(vm.BR, 0x0000).Encode()}, nil
+}
+
 // RTI: Return from Trap or Interrupt
 //
 //	RTI
@@ -968,16 +964,8 @@ func (jsr *JSR) Parse(opcode string, operands []string) error {
 func (jsr JSR) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	code := vm.NewInstruction(vm.JSR, 1<<11)
 
-	switch {
-	case jsr.SYMBOL != "":
-		offset, err := symbols.Offset(jsr.SYMBOL, pc, 11)
-		if err != nil {
-			return nil, fmt.Errorf("%w", err)
-		}
-
-		code.Operand(offset)
-	default:
-		code.Operand(vm.Word(jsr.OFFSET) & 0x03ff)
+	if err := emitPCRelative(&code, symbols, jsr.SYMBOL, jsr.OFFSET, pc, 11); err != nil {
+		return nil, fmt.Errorf("jsr: %w", err)
 	}
 
 	return []vm.Word{code.Encode()}, nil
@@ -1006,7 +994,7 @@ func (jsrr *JSRR) Parse(opcode string, operands []string) error {
 	}
 
 	*jsrr = JSRR{
-		SR: operands[0],
+		SR: registerName(operands[0]),
 	}
 
 	return nil
@@ -1023,56 +1011,352 @@ func (jsrr JSRR) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	return []vm.Word{code.Encode()}, nil
 }
 
-// .FILL: Allocate and initialize one word of data.
+// MUL: Multiply pseudo-instruction.
+//
+//	MUL DR,SR1,SR2
+//
+// The LC-3 does not have a multiply instruction, so MUL expands into a sequence of instructions that
+// computes the product SR1 × SR2 by repeated addition and stores it in DR. The expansion is
+// equivalent to:
+//
+//	      AND DR,DR,#0    ; DR ← 0
+//	      ADD R7,SR2,#0   ; R7 ← SR2
+//	      BRzp COUNT
+//	      NOT R7,R7       ; R7 ← -SR2
+//	      ADD R7,R7,#1
+//	COUNT BRz SIGN
+//	LOOP  ADD DR,DR,SR1
+//	      ADD R7,R7,#-1
+//	      BRp LOOP
+//	SIGN  ADD R7,SR2,#0
+//	      BRzp DONE
+//	      NOT DR,DR       ; DR ← -DR
+//	      ADD DR,DR,#1
+//	DONE  ADD DR,DR,#0
+//
+// R7 is used as a scratch register and is clobbered, as it is by TRAP and JSR. Consequently, none
+// of the operands may be R7 and, because DR is cleared first, DR must differ from both source
+// registers. The condition codes are set from the product. The loop runs |SR2| times, so put the
+// smaller factor in SR2 when possible.
+type MUL struct {
+	DR  string
+	SR1 string
+	SR2 string
+}
+
+// mulScratch is the register clobbered by the MUL expansion.
+const mulScratch = "R7"
+
+func (mul MUL) String() string { return fmt.Sprintf("%#v", mul) }
+
+func (mul *MUL) Parse(opcode string, operands []string) error {
+	if opcode != "MUL" {
+		return ErrOpcode
+	} else if len(operands) != 3 {
+		return ErrOperand
+	}
+
+	*mul = MUL{
+		DR:  parseRegister(operands[0]),
+		SR1: parseRegister(operands[1]),
+		SR2: parseRegister(operands[2]),
+	}
+
+	switch {
+	case mul.DR == "" || mul.SR1 == "" || mul.SR2 == "":
+		return ErrOperand
+	case mul.DR == mulScratch || mul.SR1 == mulScratch || mul.SR2 == mulScratch:
+		return fmt.Errorf("%w: %s is clobbered", ErrOperand, mulScratch)
+	case mul.DR == mul.SR1 || mul.DR == mul.SR2:
+		return fmt.Errorf("%w: destination is a source register", ErrOperand)
+	}
+
+	return nil
+}
+
+// Size returns the number of words in the expansion.
+func (mul MUL) Size() vm.Word {
+	return 14
+}
+
+// Generate expands the pseudo-instruction. Branch offsets are computed relative to each expanded
+// instruction's address, starting from pc.
+func (mul MUL) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	const (
+		count = 5  // Index of the COUNT instruction.
+		loop  = 6  // Index of the LOOP instruction.
+		sign  = 9  // Index of the SIGN instruction.
+		done  = 13 // Index of the DONE instruction.
+	)
+
+	var (
+		zp   = uint8(vm.ConditionZero | vm.ConditionPositive)
+		z    = uint8(vm.ConditionZero)
+		p    = uint8(vm.ConditionPositive)
+		code = make([]vm.Word, 0, mul.Size())
+	)
+
+	// branch returns a branch from the instruction at index i to the one at index target. The
+	// expansion starts at pc-1 and the offset is relative to the incremented PC, pc+i.
+	branch := func(cond uint8, i, target vm.Word) Operation {
+		offset := (pc - 1 + target) - (pc + i)
+		return &BR{NZP: cond, OFFSET: uint16(offset) & 0x01ff}
+	}
+
+	expansion := []Operation{
+		&AND{DR: mul.DR, SR1: mul.DR, LITERAL: 0},
+		&ADD{DR: mulScratch, SR1: mul.SR2, LITERAL: 0},
+		branch(zp, 2, count),
+		&NOT{DR: mulScratch, SR: mulScratch},
+		&ADD{DR: mulScratch, SR1: mulScratch, LITERAL: 1},
+		branch(z, count, sign),
+		&ADD{DR: mul.DR, SR1: mul.DR, SR2: mul.SR1},
+		&ADD{DR: mulScratch, SR1: mulScratch, LITERAL: 0x1f},
+		branch(p, 8, loop),
+		&ADD{DR: mulScratch, SR1: mul.SR2, LITERAL: 0},
+		branch(zp, 10, done),
+		&NOT{DR: mul.DR, SR: mul.DR},
+		&ADD{DR: mul.DR, SR1: mul.DR, LITERAL: 1},
+		&ADD{DR: mul.DR, SR1: mul.DR, LITERAL: 0},
+	}
+
+	for i, oper := range expansion {
+		words, err := oper.Generate(symbols, pc+vm.Word(i))
+		if err != nil {
+			return nil, fmt.Errorf("mul: %w", err)
+		}
+
+		code = append(code, words...)
+	}
+
+	return code, nil
+}
+
+// MOV: Copy pseudo-instruction.
+//
+//	MOV DR,SR
+//	MOV DR,#IMM5
+//
+// In register mode, MOV expands into a single instruction:
+//
+//	ADD DR,SR,#0
+//
+// In immediate mode, MOV expands into two instructions that clear the register and add the literal:
+//
+//	AND DR,DR,#0
+//	ADD DR,DR,#IMM5
+//
+// In either mode, the condition codes are set from the value copied.
+type MOV struct {
+	DR      string
+	SR      string // Register mode.
+	LITERAL uint16 // Otherwise, immediate mode.
+}
+
+func (mov MOV) String() string { return fmt.Sprintf("%#v", mov) }
+
+func (mov *MOV) Parse(opcode string, operands []string) error {
+	if opcode != "MOV" {
+		return ErrOpcode
+	} else if len(operands) != 2 {
+		return ErrOperand
+	}
+
+	dr := parseRegister(operands[0])
+	if dr == "" {
+		return ErrOperand
+	}
+
+	*mov = MOV{DR: dr}
+
+	if sr := parseRegister(operands[1]); sr != "" {
+		mov.SR = sr
+	} else {
+		lit, sym, err := parseImmediate(operands[1], 5)
+		if err != nil {
+			return err
+		} else if sym != "" {
+			return fmt.Errorf("%w: not a register or literal: %s", ErrOperand, operands[1])
+		}
+
+		mov.LITERAL = lit & 0x1f
+	}
+
+	return nil
+}
+
+// Size returns the number of words in the expansion.
+func (mov MOV) Size() vm.Word {
+	if mov.SR != "" {
+		return 1
+	}
+
+	return 2
+}
+
+func (mov MOV) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	var expansion []Operation
+
+	if mov.SR != "" {
+		expansion = []Operation{
+			&ADD{DR: mov.DR, SR1: mov.SR, LITERAL: 0},
+		}
+	} else {
+		expansion = []Operation{
+			&AND{DR: mov.DR, SR1: mov.DR, LITERAL: 0},
+			&ADD{DR: mov.DR, SR1: mov.DR, LITERAL: mov.LITERAL},
+		}
+	}
+
+	code := make([]vm.Word, 0, len(expansion))
+
+	for i, oper := range expansion {
+		words, err := oper.Generate(symbols, pc+vm.Word(i))
+		if err != nil {
+			return nil, fmt.Errorf("mov: %w", err)
+		}
+
+		code = append(code, words...)
+	}
+
+	return code, nil
+}
+
+// .FILL: Allocate and initialize words of data. Multiple values are allocated consecutively. A
+// symbolic operand is replaced by the absolute address of the symbol. Operands may also be simple
+// expressions of symbols and literals, which are evaluated when code is generated.
 //
 //	.FILL x1234
 //	.FILL 0
+//	.FILL 1, 2, 3
+//	.FILL LABEL
+//	.FILL LABEL+2
+//	.FILL END-START
 type FILL struct {
-	LITERAL uint16 // Literal constant.
+	LITERAL []uint16 // Literal constants.
+	SYMBOL  []string // Symbolic operands, i.e. symbols or expressions, if any; empty for literals.
 }
 
 func (fill *FILL) Parse(opcode string, operands []string) error {
-	val, err := parseLiteral(operands[0], 16)
-	fill.LITERAL = val
+	if len(operands) == 0 {
+		return fmt.Errorf("%w: %s: missing operand", ErrOperand, opcode)
+	}
+
+	fill.LITERAL = make([]uint16, len(operands))
+	fill.SYMBOL = nil
+
+	for i := range operands {
+		val, err := parseLiteral(operands[i], 16)
+
+		if err != nil {
+			if _, err := parseExpression(operands[i]); err != nil {
+				return err
+			}
 
-	return err
+			if fill.SYMBOL == nil {
+				fill.SYMBOL = make([]string, len(operands))
+			}
+
+			fill.SYMBOL[i] = operands[i]
+			val = 0
+		}
+
+		fill.LITERAL[i] = val
+	}
+
+	return nil
 }
 
 func (fill FILL) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
-	return []vm.Word{vm.Word(fill.LITERAL)}, nil
+	code := make([]vm.Word, len(fill.LITERAL))
+
+	for i := range fill.LITERAL {
+		if i < len(fill.SYMBOL) && fill.SYMBOL[i] != "" {
+			val, err := evalExpression(fill.SYMBOL[i], symbols, pc+vm.Word(i))
+			if err != nil {
+				return nil, err
+			}
+
+			code[i] = val
+
+			continue
+		}
+
+		code[i] = vm.Word(fill.LITERAL[i])
+	}
+
+	return code, nil
 }
 
-// .BLKW: Data allocation directive.
+// .BLKW: Data allocation directive. Allocates a block of words, initialized to zero or to the
+// optional fill value. .SPACE and .ZERO are aliases that always initialize the block to zero.
 //
 //	.BLKW 1
+//	.BLKW 3, xffff
+//	.SPACE 4
 type BLKW struct {
 	ALLOC vm.Word // Number of words allocated.
+	FILL  vm.Word // Initial value of each word.
 }
 
 func (blkw *BLKW) String() string { return fmt.Sprintf("%#v", blkw) }
 
 func (blkw *BLKW) Parse(opcode string, operands []string) error {
-	val, err := parseLiteral(operands[0], 16)
-	blkw.ALLOC = vm.Word(val)
+	switch {
+	case opcode == ".SPACE" || opcode == ".ZERO":
+		if len(operands) != 1 {
+			return fmt.Errorf("%w: %s: expected count", ErrOperand, opcode)
+		}
+	case len(operands) == 0 || len(operands) > 2:
+		return fmt.Errorf("%w: %s: expected count and optional value", ErrOperand, opcode)
+	}
+
+	// The count is unsigned, so large counts, e.g. x8000, are not negative.
+	count, err := literalValue(strings.TrimPrefix(operands[0], "#"), 16)
+	if err != nil {
+		return err
+	} else if count <= 0 {
+		return fmt.Errorf("%w: %s: count must be positive: %s", ErrOperand, opcode, operands[0])
+	}
+
+	blkw.ALLOC = vm.Word(count)
+	blkw.FILL = 0x0000
 
-	return err
+	if len(operands) == 2 {
+		val, err := parseLiteral(operands[1], 16)
+		if err != nil {
+			return err
+		}
+
+		blkw.FILL = vm.Word(val)
+	}
+
+	return nil
 }
 
 func (blkw BLKW) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	code := make([]vm.Word, blkw.ALLOC)
 	for i := vm.Word(0); i < blkw.ALLOC; i++ {
-		code[i] = 0x2361
+		code[i] = blkw.FILL
 	}
 
 	return code, nil
 }
 
-// .ORIG: Origin directive. Sets the location counter to a literal value.
+// .ORIG: Origin directive. Sets the location counter to a literal value or to the value of an
+// expression of literals and of symbols and constants defined earlier in the source, e.g. to
+// continue after a previous section.
 //
 //	.ORIG x1234
 //	.ORIG 0
+//	.ORIG x3000+x10
+//	.ORIG PREVEND+1
+//
+// Sections are not filled: memory between the end of one section and the origin of another is left
+// unloaded, rather than filled with zeros.
 type ORIG struct {
-	LITERAL vm.Word // Literal constant.
+	LITERAL vm.Word // Literal constant, or the value of the expression.
+	EXPR    string  // Expression, if the origin refers to symbols.
 }
 
 func (orig *ORIG) Is(target Operation) bool {
@@ -1102,6 +1386,16 @@ func (orig *ORIG) Parse(opcode string, operands []string) error {
 	val, err := strconv.ParseUint(arg, 0, 16)
 
 	if numError := (&strconv.NumError{}); errors.As(err, &numError) {
+		// Not a literal: the operand may be an expression. An expression of literals is evaluated
+		// now; one with symbols is resolved by the parser.
+		if terms, exprErr := parseExpression(operands[0]); exprErr == nil && hasSymbol(terms) {
+			orig.EXPR = operands[0]
+			return nil
+		} else if exprErr == nil {
+			orig.LITERAL, err = evalExpression(operands[0], nil, 0)
+			return err
+		}
+
 		// TODO: err types
 		return fmt.Errorf("parse error: %s (%s)", numError.Num, numError.Err.Error())
 	} else if val > math.MaxUint16 {
@@ -1119,6 +1413,133 @@ func (orig ORIG) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	return []vm.Word{orig.LITERAL}, nil
 }
 
+// .END: End directive. Marks the end of a section that began with an .ORIG directive.
+//
+//	.END
+type END struct{}
+
+func (end END) String() string { return fmt.Sprintf("%#v", end) }
+
+func (end *END) Parse(opcode string, operands []string) error {
+	if opcode != ".END" {
+		return ErrOpcode
+	} else if len(operands) != 0 {
+		return ErrOperand
+	}
+
+	return nil
+}
+
+// Generate returns no code: the directive only marks the end of a section.
+func (end END) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	return nil, nil
+}
+
+// .ENTRY: Entry directive. Names the label at which execution of the program begins, if it is not
+// the origin.
+//
+//	.ENTRY MAIN
+type ENTRY struct {
+	SYMBOL string
+}
+
+func (entry ENTRY) String() string { return fmt.Sprintf("%#v", entry) }
+
+func (entry *ENTRY) Parse(opcode string, operands []string) error {
+	if opcode != ".ENTRY" {
+		return ErrOpcode
+	} else if len(operands) != 1 || !isSymbol(operands[0]) {
+		return ErrOperand
+	}
+
+	entry.SYMBOL = strings.ToUpper(operands[0])
+
+	return nil
+}
+
+// Size returns zero: the directive does not allocate memory.
+func (entry ENTRY) Size() vm.Word {
+	return 0
+}
+
+// Generate returns no code. The generator records the entry point in the object code, instead.
+func (entry ENTRY) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	return nil, nil
+}
+
+// .EXTERNAL: External directive. Declares a symbol that is defined by another program and resolved
+// at link time. References to the symbol are recorded as relocations by the generator.
+//
+//	.EXTERNAL PRINTF
+type EXTERNAL struct {
+	SYMBOL string
+}
+
+func (ext EXTERNAL) String() string { return fmt.Sprintf("%#v", ext) }
+
+func (ext *EXTERNAL) Parse(opcode string, operands []string) error {
+	if opcode != ".EXTERNAL" {
+		return ErrOpcode
+	} else if len(operands) != 1 || !isSymbol(operands[0]) {
+		return ErrOperand
+	}
+
+	ext.SYMBOL = strings.ToUpper(operands[0])
+
+	return nil
+}
+
+// Size returns zero: the directive does not allocate memory.
+func (ext EXTERNAL) Size() vm.Word {
+	return 0
+}
+
+// Generate returns no code.
+func (ext EXTERNAL) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	return nil, nil
+}
+
+// .TRAP: Trap directive. Declares a trap-table entry: the handler for a trap vector. The generator
+// emits the handler's address in the trap table and accepts TRAP instructions to the vector.
+//
+//	.TRAP x30, HANDLER
+type TRAPVEC struct {
+	LITERAL uint16 // Trap vector.
+	SYMBOL  string // Handler label.
+}
+
+func (trap TRAPVEC) String() string { return fmt.Sprintf("%#v", trap) }
+
+func (trap *TRAPVEC) Parse(opcode string, operands []string) error {
+	if opcode != ".TRAP" {
+		return ErrOpcode
+	} else if len(operands) != 2 || !isSymbol(operands[1]) {
+		return ErrOperand
+	}
+
+	lit, err := parseLiteral(strings.TrimPrefix(operands[0], "#"), 8)
+	if err != nil {
+		return err
+	}
+
+	*trap = TRAPVEC{
+		LITERAL: lit,
+		SYMBOL:  strings.ToUpper(operands[1]),
+	}
+
+	return nil
+}
+
+// Size returns zero: the entry is placed in the trap table, not in the section.
+func (trap TRAPVEC) Size() vm.Word {
+	return 0
+}
+
+// Generate returns no code. The generator emits the trap-table entry, instead.
+func (trap TRAPVEC) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	return nil, nil
+}
+
 // .STRINGZ: A directive to allocate a ASCII-encoded, zero-terminated string.
 //
 //	HELLO .STRINGZ "Hello, world!"
@@ -1135,6 +1556,12 @@ func (s *STRINGZ) ParseString(opcode string, val string) error {
 	return nil
 }
 
+// Size returns the number of words allocated for the string, i.e. its UTF-16 code units and a
+// terminator.
+func (s STRINGZ) Size() vm.Word {
+	return vm.Word(len(utf16.Encode([]rune(s.LITERAL))) + 1)
+}
+
 func (s STRINGZ) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	encoded := append(utf16.Encode([]rune(s.LITERAL)), 0)
 	code := make([]vm.Word, len(encoded))
@@ -1146,6 +1573,102 @@ func (s STRINGZ) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
 	return code, nil
 }
 
+// .STRINGP: A directive to allocate a packed, zero-terminated string. Two bytes are packed in each
+// word, low byte first, which is the order the PUTSP trap prints them in. The string is terminated by
+// a zero byte and, if that leaves the final word half-full, its high byte is padded with zero.
+//
+//	HELLO .STRINGP "Hello, world!"
+type STRINGP struct {
+	LITERAL string // Literal constant.
+}
+
+func (s *STRINGP) Parse(opcode string, val []string) error {
+	return s.ParseString(opcode, val[0])
+}
+
+func (s *STRINGP) ParseString(opcode string, val string) error {
+	s.LITERAL = strings.Trim(val, `"`)
+	return nil
+}
+
+// Size returns the number of words allocated for the string, including its terminator.
+func (s STRINGP) Size() vm.Word {
+	return vm.Word((len(s.LITERAL) + 2) / 2)
+}
+
+func (s STRINGP) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	packed := append([]byte(s.LITERAL), 0)
+	if len(packed)%2 != 0 {
+		packed = append(packed, 0)
+	}
+
+	code := make([]vm.Word, len(packed)/2)
+
+	for i := range code {
+		code[i] = vm.Word(packed[2*i]) | vm.Word(packed[2*i+1])<<8
+	}
+
+	return code, nil
+}
+
+// .INCBIN: A directive to include the contents of a binary file as data. The file holds big-endian
+// words, so its length must be even. A relative path is resolved from the directory of the source
+// file.
+//
+//	SINE .INCBIN "sine.bin"
+type INCBIN struct {
+	FILENAME string    // Path of the binary file, as written in the source.
+	DATA     []vm.Word // Words read from the file.
+}
+
+func (inc *INCBIN) Parse(opcode string, val []string) error {
+	if len(val) != 1 {
+		return fmt.Errorf("%w: %s: expected filename", ErrOperand, opcode)
+	}
+
+	return inc.ParseString(opcode, val[0])
+}
+
+func (inc *INCBIN) ParseString(opcode string, val string) error {
+	inc.FILENAME = strings.Trim(val, `"`)
+
+	if inc.FILENAME == "" {
+		return fmt.Errorf("%w: %s: expected filename", ErrOperand, opcode)
+	}
+
+	return nil
+}
+
+// Read reads the file's data from a path, i.e. the filename resolved by the parser.
+func (inc *INCBIN) Read(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	} else if len(data)%2 != 0 {
+		return fmt.Errorf("%w: %s: odd number of bytes: %d", ErrOperand, inc.FILENAME, len(data))
+	}
+
+	inc.DATA = make([]vm.Word, len(data)/2)
+
+	for i := range inc.DATA {
+		inc.DATA[i] = vm.Word(data[2*i])<<8 | vm.Word(data[2*i+1])
+	}
+
+	return nil
+}
+
+// Size returns the number of words read from the file.
+func (inc INCBIN) Size() vm.Word {
+	return vm.Word(len(inc.DATA))
+}
+
+func (inc INCBIN) Generate(symbols SymbolTable, pc vm.Word) ([]vm.Word, error) {
+	code := make([]vm.Word, len(inc.DATA))
+	copy(code, inc.DATA)
+
+	return code, nil
+}
+
 // badGPR is returned when a value is invalid because it is more noticeable than a zero value.
 const badGPR = uint16(vm.BadGPR)
 
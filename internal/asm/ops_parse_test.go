@@ -958,3 +958,119 @@ func TestJSRR_Parse(t *testing.T) {
 		})
 	}
 }
+
+func TestMUL_Parse(t *testing.T) {
+	tests := []parserCase{
+		{
+			name:   "bad oper",
+			opcode: "OP", operands: []string{"R0", "R1", "R2"},
+			wantErr: ErrOpcode,
+		},
+		{
+			name:   "MUL registers",
+			opcode: "MUL", operands: []string{"R0", "R1", "R2"},
+			want: &MUL{DR: "R0", SR1: "R1", SR2: "R2"},
+		},
+		{
+			name:   "MUL literal",
+			opcode: "MUL", operands: []string{"R0", "R1", "#2"},
+			wantErr: ErrOperand,
+		},
+		{
+			name:   "MUL scratch register",
+			opcode: "MUL", operands: []string{"R0", "R7", "R2"},
+			wantErr: ErrOperand,
+		},
+		{
+			name:   "MUL destination is source",
+			opcode: "MUL", operands: []string{"R1", "R1", "R2"},
+			wantErr: ErrOperand,
+		},
+		{
+			name:   "MUL too few operands",
+			opcode: "MUL", operands: []string{"R0", "R1"},
+			wantErr: ErrOperand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &MUL{}
+			err := got.Parse(tt.opcode, tt.operands)
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) || err != nil && tt.wantErr == nil {
+				t.Errorf("MUL.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if (err == nil) && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MUL.Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMOV_Parse(t *testing.T) {
+	tests := []parserCase{
+		{
+			name:   "bad oper",
+			opcode: "OP", operands: []string{"R0", "R1"},
+			wantErr: ErrOpcode,
+		},
+		{
+			name:   "MOV register",
+			opcode: "MOV", operands: []string{"R0", "R1"},
+			want: &MOV{DR: "R0", SR: "R1"},
+		},
+		{
+			name:   "MOV literal",
+			opcode: "MOV", operands: []string{"R0", "#-1"},
+			want: &MOV{DR: "R0", LITERAL: 0x1f},
+		},
+		{
+			name:   "MOV hex literal",
+			opcode: "MOV", operands: []string{"R2", "#xf"},
+			want: &MOV{DR: "R2", LITERAL: 0x0f},
+		},
+		{
+			name:   "MOV literal out of range",
+			opcode: "MOV", operands: []string{"R0", "#32"},
+			wantErr: &LiteralRangeError{},
+		},
+		{
+			name:   "MOV symbol",
+			opcode: "MOV", operands: []string{"R0", "LABEL"},
+			wantErr: ErrOperand,
+		},
+		{
+			name:   "MOV bad destination",
+			opcode: "MOV", operands: []string{"#1", "R0"},
+			wantErr: ErrOperand,
+		},
+		{
+			name:   "MOV too many operands",
+			opcode: "MOV", operands: []string{"R0", "R1", "R2"},
+			wantErr: ErrOperand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &MOV{}
+			err := got.Parse(tt.opcode, tt.operands)
+
+			if (tt.wantErr != nil && err == nil) || err != nil && tt.wantErr == nil {
+				t.Errorf("MOV.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if sentinel := tt.wantErr; sentinel == ErrOpcode || sentinel == ErrOperand { //nolint:errorlint
+				if !errors.Is(err, sentinel) {
+					t.Errorf("MOV.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			if (err == nil) && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MOV.Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
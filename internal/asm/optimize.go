@@ -0,0 +1,202 @@
+package asm
+
+// optimize.go contains a peephole optimizer.
+
+import (
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// Optimize is a peephole optimizer that removes operations that have no effect. It replaces the
+// generator's syntax and symbol tables with the transformed ones, so that code generated afterwards
+// is optimized, and returns the transformed syntax table and a report of the changes, located at
+// the removed operations. Removed operations are:
+//
+//   - a branch to the next instruction; and
+//   - ADD DR,DR,#0 and MOV DR,DR, when the next instruction sets the condition codes. Otherwise,
+//     the operation may be setting the condition codes for a branch, which is a common idiom.
+//
+// Other idioms cannot be improved and are left as they are, e.g. AND DR,DR,#0 followed by
+// ADD DR,DR,#k to load a small constant.
+//
+// Removing an operation moves the operations after it in its section and so labels that follow are
+// moved, too. An operation is not removed if it is labeled, i.e. if it may be the target of a
+// branch, nor if it lies between an operation with a literal PC-relative offset and its target.
+// Addresses written as literals, e.g. .FILL x3004, are not adjusted.
+func (gen *Generator) Optimize() (SyntaxTable, []Warning) {
+	var (
+		report  []Warning
+		removed = make(map[int]bool)     // Indices of removed operations.
+		labeled = make(map[vm.Word]bool) // Labeled locations.
+		spans   []extent                 // Locations spanned by literal offsets.
+	)
+
+	for _, loc := range gen.symbols {
+		labeled[loc] = true
+	}
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		if target, ok := gen.literalTarget(si); ok {
+			span := extent{si.Loc, target + 1}
+			if target < si.Loc {
+				span = extent{target, si.Loc + 1}
+			}
+
+			labeled[target] = true
+			spans = append(spans, span)
+		}
+
+		return nil
+	})
+
+	for i := range gen.syntax {
+		if gen.syntax[i] == nil {
+			continue
+		}
+
+		si := gen.syntax.Source(i)
+		msg := ""
+
+		switch oper := unwrap(si).(type) {
+		case *BR:
+			if target, ok := gen.target(oper, si.Loc); ok && target == si.Loc+1 {
+				msg = "removed branch to next instruction"
+			}
+		case *ADD:
+			if oper.SR2 == "" && oper.DR == oper.SR1 && oper.LITERAL == 0 && gen.setsCondition(i+1) {
+				msg = "removed ADD with no effect"
+			}
+		case *MOV:
+			if oper.SR != "" && oper.DR == oper.SR && gen.setsCondition(i+1) {
+				msg = "removed MOV with no effect"
+			}
+		}
+
+		if msg == "" || labeled[si.Loc] {
+			continue
+		}
+
+		spanned := false
+
+		for _, span := range spans {
+			spanned = spanned || span.start < si.Loc && si.Loc < span.end-1
+		}
+
+		if !spanned {
+			removed[i] = true
+			report = append(report, sourceWarning(si, "%s", msg))
+		}
+	}
+
+	gen.syntax, gen.symbols = gen.without(removed)
+
+	return gen.syntax, report
+}
+
+// literalTarget returns the target of an operation with a literal PC-relative offset.
+func (gen *Generator) literalTarget(si *SourceInfo) (vm.Word, bool) {
+	var symbol string
+
+	switch oper := unwrap(si).(type) {
+	case *BR:
+		symbol = oper.SYMBOL
+	case *LD:
+		symbol = oper.SYMBOL
+	case *LDI:
+		symbol = oper.SYMBOL
+	case *LEA:
+		symbol = oper.SYMBOL
+	case *ST:
+		symbol = oper.SYMBOL
+	case *STI:
+		symbol = oper.SYMBOL
+	case *JSR:
+		symbol = oper.SYMBOL
+	default:
+		return 0, false
+	}
+
+	if symbol != "" {
+		return 0, false
+	}
+
+	return gen.target(unwrap(si), si.Loc)
+}
+
+// setsCondition is true if the i'th operation is an instruction that sets the condition codes.
+func (gen *Generator) setsCondition(i int) bool {
+	if i >= len(gen.syntax) || gen.syntax[i] == nil {
+		return false
+	}
+
+	switch oper := unwrap(gen.syntax[i]).(type) {
+	case *ADD, *AND, *NOT, *LD, *LDI, *LDR:
+		return true
+	case *MOV:
+		return oper.SR != ""
+	default:
+		return false
+	}
+}
+
+// without returns the syntax table without the removed operations and the symbol table with
+// labels moved accordingly. Operations are located anew.
+func (gen *Generator) without(removed map[int]bool) (SyntaxTable, SymbolTable) {
+	var (
+		syntax  = make(SyntaxTable, 0, len(gen.syntax))
+		symbols = make(SymbolTable, len(gen.symbols))
+		moved   []vm.Word // Original locations of removed operations.
+		orig    vm.Word   // Origin of the current section.
+	)
+
+	for i := range gen.syntax {
+		if removed[i] {
+			moved = append(moved, gen.syntax.Source(i).Loc)
+		}
+	}
+
+	// shift returns the number of removed operations located in the same section before loc.
+	shift := func(loc vm.Word, orig vm.Word) vm.Word {
+		n := vm.Word(0)
+
+		for _, m := range moved {
+			if orig <= m && m < loc {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	for i, op := range gen.syntax {
+		if removed[i] {
+			continue
+		}
+
+		si, ok := op.(*SourceInfo)
+		if !ok {
+			syntax = append(syntax, op)
+			continue
+		} else if o, ok := unwrap(si).(*ORIG); ok {
+			orig = o.LITERAL
+		}
+
+		copied := *si
+		copied.Loc -= shift(si.Loc, orig)
+		syntax = append(syntax, &copied)
+	}
+
+	for sym, loc := range gen.symbols {
+		// Find the origin of the section holding the symbol: the greatest origin at or below it.
+		orig := vm.Word(0)
+
+		for _, op := range gen.syntax {
+			if o, ok := origin(op); ok && o.LITERAL <= loc && o.LITERAL >= orig {
+				orig = o.LITERAL
+			}
+		}
+
+		symbols[sym] = loc - shift(loc, orig)
+	}
+
+	return syntax, symbols
+}
@@ -2,12 +2,16 @@ package asm
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/smoynes/elsie/internal/log"
 	"github.com/smoynes/elsie/internal/vm"
@@ -34,24 +38,87 @@ type Parser struct {
 	filename string      // Current filename being parsed.
 	line     string      // Line being parsed.
 	symbols  SymbolTable // Symbolic references.
+	consts   SymbolTable // Named constant values.
 	syntax   SyntaxTable // Parsed code and data indexed by its address in memory.
+	sections []Section   // Sections of code and data.
+	open     bool        // True if the last section has not been ended.
 
-	fatal error   // Error causing parsing to halt, i.e., I/O errors.
-	errs  []error // Syntax errors.
+	macros    map[string]*macro // Defined macros.
+	defining  *macro            // Macro being defined, if any.
+	expanding map[string]bool   // Macros being expanded, to guard against recursion.
+
+	fatal    error     // Error causing parsing to halt, i.e., I/O errors.
+	errs     []error   // Syntax errors.
+	warnings []Warning // Advisory diagnostics, e.g. duplicate labels.
 
 	// Stub opcode and instruction for testing.
 	probeOpcode string
 	probeInstr  Operation
 
+	progress ProgressFunc // Called for each source line.
+
+	radix       int  // Base of bare numeric literals.
+	maxLine     int  // Maximum line length, in bytes.
+	maxOperands int  // Maximum number of operands.
+	long        bool // True if the line being parsed was truncated.
+
 	log *log.Logger
 }
 
-func NewParser(log *log.Logger) *Parser {
-	return &Parser{
-		symbols: make(SymbolTable),
-		syntax:  make(SyntaxTable, 0),
-		log:     log,
+// ProgressFunc is a callback that reports the progress of the assembler: the location counter and
+// the source line being assembled.
+type ProgressFunc func(loc vm.Word, line string)
+
+// ParserOption configures a parser.
+type ParserOption func(*Parser)
+
+// WithParseProgress configures a parser to call fn before each non-empty source line is parsed. The
+// location is the address at which code for the line will be placed.
+func WithParseProgress(fn ProgressFunc) ParserOption {
+	return func(p *Parser) {
+		p.progress = fn
+	}
+}
+
+// Default parser limits. See WithLimits.
+const (
+	DefaultMaxLineLength = bufio.MaxScanTokenSize
+	DefaultMaxOperands   = 256
+)
+
+// WithLimits configures the maximum length of a source line, in bytes, and the maximum number of
+// operands of an instruction or directive. Lines that exceed either limit are syntax errors and are
+// not parsed, so that malformed input does not cause unbounded work. Zero leaves a limit at its
+// default.
+func WithLimits(lineLength, operands int) ParserOption {
+	return func(p *Parser) {
+		if lineLength > 0 {
+			p.maxLine = lineLength
+		}
+
+		if operands > 0 {
+			p.maxOperands = operands
+		}
+	}
+}
+
+func NewParser(log *log.Logger, opts ...ParserOption) *Parser {
+	p := &Parser{
+		loc:         vm.UserSpaceAddr,
+		symbols:     make(SymbolTable),
+		consts:      make(SymbolTable),
+		syntax:      make(SyntaxTable, 0),
+		radix:       10,
+		maxLine:     DefaultMaxLineLength,
+		maxOperands: DefaultMaxOperands,
+		log:         log,
+	}
+
+	for _, fn := range opts {
+		fn(p)
 	}
+
+	return p
 }
 
 // Symbols returns the symbol table constructed so far.
@@ -59,11 +126,93 @@ func (p *Parser) Symbols() SymbolTable {
 	return p.symbols
 }
 
+// Constants returns the table of named constants defined so far. Unlike symbols, constants do not
+// refer to a location in the program.
+func (p *Parser) Constants() SymbolTable {
+	return p.consts
+}
+
+// Warnings returns the advisory diagnostics found while parsing so far. Unlike syntax errors, they do
+// not prevent code from being generated.
+func (p *Parser) Warnings() []Warning {
+	return p.warnings
+}
+
 // Syntax returns the abstract syntax table, i.e. "parse tree".
 func (p *Parser) Syntax() SyntaxTable {
 	return p.syntax
 }
 
+// Sections returns the sections of code and data parsed so far. A section begins with an .ORIG
+// directive and ends with an .END directive, the next .ORIG directive, or the end of the source.
+func (p *Parser) Sections() []Section {
+	sections := make([]Section, len(p.sections))
+	copy(sections, p.sections)
+
+	if p.open && len(sections) > 0 {
+		last := &sections[len(sections)-1]
+		last.Size = p.loc - last.Orig
+	}
+
+	return sections
+}
+
+// LocCheck is a self-check of the parser that verifies that the location counter agrees with the
+// code generated for each operation: within a section, each operation must be located after the
+// code generated for the ones before it and, in all, the code must fill the section, i.e. the final
+// location less the origin. Operations whose code cannot be generated, e.g. because of an undefined
+// symbol, are counted by their size. An error is a bug in the assembler, not in the program.
+func (p *Parser) LocCheck() error {
+	var (
+		sections = p.Sections()
+		index    = -1      // Index of the current section.
+		loc      vm.Word   // Location after the code generated so far.
+		sizes    []vm.Word // Generated size of each section.
+	)
+
+	err := p.syntax.Walk(func(si *SourceInfo) error {
+		oper := unwrap(si)
+
+		if orig, ok := oper.(*ORIG); ok {
+			index++
+			loc = orig.LITERAL
+			sizes = append(sizes, 0)
+
+			return nil
+		} else if index < 0 {
+			return nil
+		} else if si.Loc != loc {
+			return fmt.Errorf("%w: loc check: %s:%d: %q: want: %s, got: %s",
+				ErrVerify, si.Filename, si.Pos, si.Line, loc, si.Loc)
+		}
+
+		size := vm.Word(1)
+
+		if code, err := oper.Generate(p.symbols, si.Loc+1); err == nil {
+			size = vm.Word(len(code))
+		} else if sized, ok := oper.(interface{ Size() vm.Word }); ok {
+			size = sized.Size()
+		}
+
+		loc += size
+		sizes[index] += size
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range sections {
+		if i < len(sizes) && sizes[i] != sections[i].Size {
+			return fmt.Errorf("%w: loc check: section %s: want size: %d, got: %d",
+				ErrVerify, sections[i].Orig, sections[i].Size, sizes[i])
+		}
+	}
+
+	return nil
+}
+
 // Err returns errors that occur during parsing. If a fatal error occurs that prevents parsing from
 // continuing (e.g., a fs.PathError), that error is returned. Otherwise, the parser collects syntax
 // errors during parsing and returns an error that wraps and joins them all. Callers can inspect the
@@ -92,6 +241,8 @@ func (p *Parser) Parse(in io.Reader) {
 	}
 
 	lines := bufio.NewScanner(in)
+	lines.Buffer(make([]byte, 0, min(4096, p.maxLine+1)), p.maxLine+1)
+	lines.Split(p.scanLines())
 
 	if file, ok := in.(interface{ Name() string }); ok {
 		p.filename = file.Name()
@@ -99,6 +250,8 @@ func (p *Parser) Parse(in io.Reader) {
 		p.filename = ""
 	}
 
+	p.radix = 10 // Each file starts in decimal.
+
 	for {
 		scanned := lines.Scan()
 
@@ -114,6 +267,17 @@ func (p *Parser) Parse(in io.Reader) {
 			break
 		}
 
+		if p.long {
+			p.long = false
+			p.addSyntaxError(fmt.Errorf("%w: line longer than %d bytes", ErrLimit, p.maxLine))
+
+			continue
+		}
+
+		if p.progress != nil && strings.TrimSpace(p.line) != "" {
+			p.progress(p.loc, p.line)
+		}
+
 		if err := p.parseLine(p.line); err != nil {
 			// Assume descendant accumulated syntax errors and that any errors returned are
 			// therefore fatal.
@@ -121,35 +285,111 @@ func (p *Parser) Parse(in io.Reader) {
 			return
 		}
 	}
+
+	if p.defining != nil {
+		p.addSyntaxError(fmt.Errorf("%w: unterminated macro: %s", ErrMacro, p.defining.name))
+		p.defining = nil
+	}
+}
+
+// scanLines returns a split function that, like bufio.ScanLines, splits input into lines. Rather
+// than failing when a line is longer than the parser's limit, the line is truncated, the remainder
+// discarded, and the long flag set.
+func (p *Parser) scanLines() bufio.SplitFunc {
+	discard := false // True while discarding the remainder of a long line.
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if discard {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				discard = false
+				return i + 1, nil, nil
+			}
+
+			return len(data), nil, nil
+		}
+
+		advance, token, err := bufio.ScanLines(data, atEOF)
+
+		switch {
+		case err != nil:
+			return advance, token, err
+		case token != nil && len(token) > p.maxLine:
+			p.long = true
+			return advance, token[:p.maxLine], nil
+		case token == nil && len(data) > p.maxLine:
+			p.long = true
+			discard = true
+
+			return len(data), data[:p.maxLine], nil
+		default:
+			return advance, token, nil
+		}
+	}
 }
 
 // Parse line uses regular expressions to parse text. Based on the which patterns match, the text is
 // parsed and the parser state is updated.
 func (p *Parser) parseLine(line string) error {
-	remain := strings.TrimSpace(line) // Remaining, unparsed line.
+	if p.defining != nil {
+		p.recordMacro(line)
+		return nil
+	}
+
+	remain := strings.TrimSpace(line)     // Remaining, unparsed line.
+	offset := strings.Index(line, remain) // Offset of remaining text in the line.
 
-	if matched := commentPattern.FindStringIndex(remain); len(matched) > 1 {
-		remain = remain[:matched[0]] // Discard comments.
+	if i := indexComment(remain); i >= 0 {
+		remain = strings.TrimRightFunc(remain[:i], unicode.IsSpace) // Discard comments.
 	}
 
+	if n := len(splitUnquoted(remain, ',')); n > p.maxOperands {
+		p.addSyntaxError(fmt.Errorf("%w: more than %d operands", ErrLimit, p.maxOperands))
+		return nil
+	}
+
+	label := ""
+
 	if matched := labelPattern.FindStringSubmatchIndex(remain); len(matched) > 1 {
 		var (
 			matchEnd             = matched[1]
 			labelStart, labelEnd = matched[2], matched[3]
 		)
 
-		label := remain[labelStart:labelEnd]
+		label = remain[labelStart:labelEnd]
 		label = strings.TrimSpace(label)
 		label = strings.ToUpper(label)
 
 		if !p.isReservedKeyword(label) {
 			remain = remain[matchEnd:]
+			offset += matchEnd
+		} else {
+			label = ""
+		}
+	}
+
+	directive := directivePattern.FindStringSubmatch(remain)
+
+	if p.radix != 10 && (len(directive) < 2 || strings.ToUpper(directive[1]) != ".RADIX") {
+		var err error
 
-			p.symbols.Add(label, p.loc)
+		if remain, err = applyRadix(remain, p.radix); err != nil {
+			p.addSyntaxError(err)
+			return nil
 		}
+
+		directive = directivePattern.FindStringSubmatch(remain)
 	}
 
-	if matched := directivePattern.FindStringSubmatch(remain); len(matched) > 1 {
+	// Constants are named by the label, which is not a symbol for the location.
+	if len(directive) > 1 && strings.ToUpper(directive[1]) == ".EQU" {
+		if err := p.parseConstant(label, strings.TrimSpace(directive[2])); err != nil {
+			p.addSyntaxError(err)
+		}
+
+		return nil
+	}
+
+	if matched := directive; len(matched) > 1 {
 		ident := matched[1]
 		ident = strings.TrimSpace(ident)
 		ident = strings.ToUpper(ident)
@@ -157,34 +397,73 @@ func (p *Parser) parseLine(line string) error {
 		arg := matched[2]
 		arg = strings.TrimSpace(arg)
 
+		switch ident {
+		case ".ORIG", ".END", ".EXTERNAL", ".TRAP", ".MACRO", ".ENDM", ".RADIX":
+		default:
+			p.requireOrigin()
+		}
+
+		// A label names the location at which the directive begins. For .ORIG, that is the new
+		// origin, so the label is added after the directive is parsed.
+		if ident != ".ORIG" {
+			p.addLabel(label)
+		}
+
 		if err := p.parseDirective(ident, arg); err != nil {
 			p.fatal = err
 			return err
 		}
 
+		if ident == ".ORIG" {
+			p.addLabel(label)
+		}
+
 		return nil
 	}
 
-	if matched := instructionPattern.FindStringSubmatch(remain); len(matched) > 2 {
-		operator := matched[1]
+	p.addLabel(label)
 
-		// Split, trim, and clean operands.
-		operands := make([]string, 0, 3)
-		matched[2] = strings.TrimSpace(matched[2])
-		split := strings.Split(matched[2], ",")
+	if matched := instructionPattern.FindStringSubmatchIndex(remain); len(matched) > 5 {
+		operator := remain[matched[2]:matched[3]]
 
-		for i := range split {
-			split[i] = strings.TrimSpace(split[i])
+		// Split, trim, and clean operands, keeping track of the offset of each in the line.
+		var (
+			operands = make([]string, 0, 3)
+			cols     = make([]int, 0, 3)
+			start    = matched[4]
+		)
 
-			if split[i] == "" {
-				continue
+		for _, split := range splitUnquoted(remain[matched[4]:matched[5]], ',') {
+			operand := strings.TrimSpace(split)
+
+			if operand != "" {
+				operands = append(operands, operand)
+				cols = append(cols, offset+start+strings.Index(split, operand))
 			}
 
-			operands = append(operands, split[i])
+			start += len(split) + 1
 		}
 
+		if m, ok := p.macros[strings.ToUpper(operator)]; ok {
+			return p.expandMacro(m, operands)
+		}
+
+		p.requireOrigin()
+
 		if err := p.parseInstruction(operator, operands); err != nil {
-			p.addSyntaxError(err)
+			col := offset + matched[2]
+
+			// Literal errors are caused by an operand rather than the operator.
+			if le := (*LiteralRangeError)(nil); errors.As(err, &le) {
+				for i := range operands {
+					if literalText(operands[i]) == le.Literal {
+						col = cols[i]
+						break
+					}
+				}
+			}
+
+			p.addSyntaxErrorAt(vm.Word(col+1), err)
 		}
 
 		return nil
@@ -205,21 +484,45 @@ var (
 	ident      = `(\pL[\pL\p{Nd}\pM\p{Pc}\p{Pd}\pS]*)`
 	directives = []string{
 		`\.ORIG`,
+		`\.RADIX`,
 		`\.DW`,
 		`\.FILL`,
 		`\.BLKW`,
+		`\.SPACE`,
+		`\.ZERO`,
 		`\.STRINGZ`,
+		`\.STRINGP`,
+		`\.INCBIN`,
+		`\.EQU`,
+		`\.ENTRY`,
+		`\.EXTERNAL`,
+		`\.TRAP`,
+		`\.MACRO`,
+		`\.ENDM`,
 		`\.END`,
 	}
 
 	// Grammar patterns.
-	commentPattern   = regexp.MustCompile(space + `;` + text + `$`)
 	labelPattern     = regexp.MustCompile(`^` + ident + space + `:?` + space)
 	directivePattern = regexp.MustCompile(
 		`^(` + strings.Join(directives, `|`) + `)` + space + text + `$`)
 	instructionPattern = regexp.MustCompile(`^` + space + ident + space + text + `$`)
+	symbolPattern      = regexp.MustCompile(`^` + ident + `$`)
 )
 
+// addLabel adds a label, if any, to the symbol table at the current location. Labels may not share
+// a name with a constant. A label that is redefined at a different location is a warning: the last
+// definition is kept.
+func (p *Parser) addLabel(label string) {
+	if label == "" {
+		return
+	} else if _, ok := p.consts[label]; ok {
+		p.addSyntaxError(fmt.Errorf("%w: redefined: %s", ErrConstant, label))
+	} else if err := p.symbols.Add(label, p.loc); err != nil {
+		p.addWarning(err)
+	}
+}
+
 // parseInstruction dispatches parsing to an instruction parser based on the opcode. Parsing the
 // operands is delegated to the dispatched parser.
 func (p *Parser) parseInstruction(opcode string, operands []string) error {
@@ -228,13 +531,33 @@ func (p *Parser) parseInstruction(opcode string, operands []string) error {
 		return ErrOpcode
 	}
 
-	err := oper.Parse(opcode, operands)
+	// Substitute named constants with their literal values. Constants are not locations, so they may
+	// not be used where a label is expected.
+	for i := range operands {
+		val, ok := p.consts[strings.ToUpper(operands[i])]
+		if !ok {
+			continue
+		} else if isRelative(oper) {
+			return fmt.Errorf("%s: %w: not a label: %s", opcode, ErrConstant, operands[i])
+		}
+
+		operands[i] = "#" + strconv.Itoa(int(int16(val)))
+	}
+
+	// Opcodes are case-insensitive.
+	err := oper.Parse(strings.ToUpper(opcode), operands)
 	if err != nil {
 		return fmt.Errorf("%s: %w", opcode, err)
 	}
 
 	p.AddSyntax(oper)
-	p.loc++
+
+	// Pseudo-instructions may expand into more than one word.
+	if sized, ok := oper.(interface{ Size() vm.Word }); ok {
+		p.loc += sized.Size()
+	} else {
+		p.loc++
+	}
 
 	return nil
 }
@@ -244,6 +567,7 @@ func (p *Parser) parseInstruction(opcode string, operands []string) error {
 func (p *Parser) AddSyntax(oper Operation) {
 	op := &SourceInfo{
 		Operation: oper,
+		Loc:       p.loc,
 		Pos:       p.pos,
 		Line:      p.line,
 		Filename:  p.filename,
@@ -260,7 +584,7 @@ func (p *Parser) parseOperator(opcode string) Operation {
 		return &ADD{}
 	case "AND":
 		return &AND{}
-	case "BR", "BRNZP", "BRN", "BRZ", "BRP", "BRZN", "BRNP", "BRZP":
+	case "BR", "BRNZP", "BRN", "BRZ", "BRP", "BRNZ", "BRNP", "BRZP":
 		return &BR{}
 	case "JMP":
 		return &JMP{}
@@ -286,12 +610,16 @@ func (p *Parser) parseOperator(opcode string) Operation {
 		return &STR{}
 	case "STI":
 		return &STI{}
-	case "TRAP":
-		return &TRAP{}
-	case "HALT":
+	case "TRAP", "GETC", "OUT", "PUTS", "IN", "PUTSP", "HALT":
 		return &TRAP{}
+	case "NOP":
+		return &NOP{}
 	case "RTI":
 		return &RTI{}
+	case "MUL":
+		return &MUL{}
+	case "MOV":
+		return &MOV{}
 	case p.probeOpcode:
 		return p.probeInstr
 	default:
@@ -308,9 +636,46 @@ func (p *Parser) isReservedKeyword(word string) bool {
 		}
 	}
 
+	if _, ok := p.macros[word]; ok {
+		return true
+	}
+
 	return p.parseOperator(word) != nil
 }
 
+// isRelative returns true if the operation's operand is a PC-relative offset, i.e. a label.
+func isRelative(oper Operation) bool {
+	switch oper.(type) {
+	case *BR, *JSR, *LD, *LDI, *LEA, *ST, *STI:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseConstant defines a named constant with a literal value. Constants may not be redefined nor
+// share a name with a label.
+//
+//	MAXLEN .EQU #80
+func (p *Parser) parseConstant(name string, arg string) error {
+	if name == "" {
+		return fmt.Errorf(".EQU: %w: missing name", ErrConstant)
+	} else if _, ok := p.consts[name]; ok {
+		return fmt.Errorf(".EQU: %w: redefined: %s", ErrConstant, name)
+	} else if _, ok := p.symbols[name]; ok {
+		return fmt.Errorf(".EQU: %w: redefined: %s", ErrConstant, name)
+	}
+
+	val, err := parseLiteral(strings.TrimPrefix(arg, "#"), 16)
+	if err != nil {
+		return fmt.Errorf(".EQU: %w", err)
+	}
+
+	p.consts.Add(name, vm.Word(val))
+
+	return nil
+}
+
 // parseDirective parses a directive, or pseudo-instruction, by its identifier and argument.
 func (p *Parser) parseDirective(ident string, arg string) error {
 	var err error
@@ -324,28 +689,53 @@ func (p *Parser) parseDirective(ident string, arg string) error {
 			break
 		}
 
-		p.AddSyntax(&orig)
+		if orig.EXPR != "" {
+			if orig.LITERAL, err = p.resolveOrigin(orig.EXPR); err != nil {
+				break
+			}
+		}
+
+		p.endSection()
 		p.loc = orig.LITERAL
-	case ".BLKW":
+		p.AddSyntax(&orig)
+		p.sections = append(p.sections, Section{Orig: orig.LITERAL})
+		p.open = true
+	case ".BLKW", ".SPACE", ".ZERO":
 		blkw := BLKW{}
+		operands := splitUnquoted(arg, ',')
 
-		err = blkw.Parse(ident, []string{arg})
-		if err != nil {
-			break
+		for i := range operands {
+			operands[i] = strings.TrimSpace(operands[i])
+		}
+
+		// An invalid allocation is a syntax error, rather than a fatal one, so that parsing
+		// continues.
+		if err := blkw.Parse(ident, operands); err != nil {
+			p.addSyntaxError(fmt.Errorf("%s: %w", ident, err))
+			return nil
 		}
 
 		p.AddSyntax(&blkw)
 		p.loc += blkw.ALLOC
 	case ".FILL", ".DW":
 		fill := FILL{}
+		operands := splitUnquoted(arg, ',')
 
-		err = fill.Parse(ident, []string{arg})
+		for i := range operands {
+			operands[i] = strings.TrimSpace(operands[i])
+
+			if val, ok := p.consts[strings.ToUpper(operands[i])]; ok {
+				operands[i] = strconv.Itoa(int(int16(val)))
+			}
+		}
+
+		err = fill.Parse(ident, operands)
 		if err != nil {
 			break
 		}
 
 		p.AddSyntax(&fill)
-		p.loc++
+		p.loc += vm.Word(len(fill.LITERAL))
 	case ".STRINGZ":
 		strz := STRINGZ{}
 
@@ -355,11 +745,95 @@ func (p *Parser) parseDirective(ident string, arg string) error {
 		}
 
 		p.AddSyntax(&strz)
-		p.loc += vm.Word(len(strz.LITERAL) + 1)
+		p.loc += strz.Size()
+	case ".STRINGP":
+		strp := STRINGP{}
+
+		err = strp.ParseString(ident, arg)
+		if err != nil {
+			break
+		}
+
+		p.AddSyntax(&strp)
+		p.loc += strp.Size()
+	case ".INCBIN":
+		incbin := INCBIN{}
+
+		// Like an invalid allocation, a file that cannot be included is a syntax error.
+		if err := incbin.ParseString(ident, arg); err != nil {
+			p.addSyntaxError(fmt.Errorf("%s: %w", ident, err))
+			return nil
+		} else if err := incbin.Read(p.resolve(incbin.FILENAME)); err != nil {
+			p.addSyntaxError(fmt.Errorf("%s: %w", ident, err))
+			return nil
+		}
+
+		p.AddSyntax(&incbin)
+		p.loc += incbin.Size()
 	case ".END":
-		// TODO: add to syntax table
+		end := END{}
+		operands := []string(nil)
+
+		if arg != "" {
+			operands = append(operands, arg)
+		}
+
+		err = end.Parse(ident, operands)
+		if err != nil {
+			break
+		}
+
+		p.AddSyntax(&end)
+		p.endSection()
+	case ".ENTRY":
+		entry := ENTRY{}
+
+		err = entry.Parse(ident, []string{arg})
+		if err != nil {
+			break
+		}
+
+		p.AddSyntax(&entry)
+	case ".MACRO":
+		p.defineMacro(arg)
+	case ".ENDM":
+		p.addSyntaxError(fmt.Errorf(".ENDM: %w: not defining a macro", ErrMacro))
 	case ".EXTERNAL":
-		// TODO: add link-time references to symbol table
+		ext := EXTERNAL{}
+
+		err = ext.Parse(ident, []string{arg})
+		if err != nil {
+			break
+		}
+
+		p.AddSyntax(&ext)
+	case ".TRAP":
+		trap := TRAPVEC{}
+		operands := splitUnquoted(arg, ',')
+
+		for i := range operands {
+			operands[i] = strings.TrimSpace(operands[i])
+		}
+
+		err = trap.Parse(ident, operands)
+		if err != nil {
+			break
+		}
+
+		p.AddSyntax(&trap)
+	case ".RADIX":
+		// An invalid radix is a syntax error, rather than a fatal one, so that parsing continues.
+		radix, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+
+		switch {
+		case err != nil:
+			p.addSyntaxError(fmt.Errorf("%s: %w: %s", ident, ErrOperand, arg))
+		case radix != 2 && radix != 8 && radix != 10 && radix != 16:
+			p.addSyntaxError(fmt.Errorf("%s: %w: radix must be 2, 8, 10 or 16: %d",
+				ident, ErrOperand, radix))
+		default:
+			p.radix = radix
+		}
 	default:
 		return fmt.Errorf("directive error: %s", ident)
 	}
@@ -371,19 +845,94 @@ func (p *Parser) parseDirective(ident string, arg string) error {
 	return nil
 }
 
+// resolve returns the path of a file named in the source. A relative name is resolved from the
+// directory of the file being parsed, if known.
+func (p *Parser) resolve(name string) string {
+	if filepath.IsAbs(name) || p.filename == "" {
+		return name
+	}
+
+	return filepath.Join(filepath.Dir(p.filename), name)
+}
+
+// resolveOrigin evaluates an origin expression. Because the location of the code that follows
+// depends on the origin, only the symbols and constants defined before the directive are known:
+// forward references are errors.
+func (p *Parser) resolveOrigin(expr string) (vm.Word, error) {
+	known := make(SymbolTable, len(p.symbols)+len(p.consts))
+
+	for name, val := range p.consts {
+		known[name] = val
+	}
+
+	for name, loc := range p.symbols {
+		known[name] = loc
+	}
+
+	val, err := evalExpression(expr, known, p.loc)
+	if err != nil {
+		return 0, fmt.Errorf("%w: .ORIG %s: %w", ErrOrigin, expr, err)
+	}
+
+	return val, nil
+}
+
+// requireOrigin checks that a section has been started before code or data is parsed. If no .ORIG
+// directive has been parsed, a syntax error is added and a section is opened at the default origin,
+// vm.UserSpaceAddr, so that parsing may continue.
+func (p *Parser) requireOrigin() {
+	if len(p.sections) > 0 {
+		return
+	}
+
+	p.addSyntaxError(fmt.Errorf("%w: missing .ORIG; using %s", ErrOrigin, vm.UserSpaceAddr))
+
+	orig := ORIG{LITERAL: vm.UserSpaceAddr}
+
+	p.loc = orig.LITERAL
+	p.AddSyntax(&orig)
+	p.sections = append(p.sections, Section{Orig: orig.LITERAL})
+	p.open = true
+}
+
+// endSection closes the open section, if any, and records its size.
+func (p *Parser) endSection() {
+	if !p.open {
+		return
+	}
+
+	last := &p.sections[len(p.sections)-1]
+	last.Size = p.loc - last.Orig
+	p.open = false
+}
+
 // parseRegister returns the register name from an operand or an empty value if the register does
-// not exist.
+// not exist. Register aliases are replaced with the register's name.
 func parseRegister(oper string) string {
-	switch oper {
+	switch reg := registerName(oper); reg {
 	case
 		"R0", "R1", "R2", "R3",
 		"R4", "R5", "R6", "R7":
-		return oper
+		return reg
 	default:
 		return ""
 	}
 }
 
+// registerName returns the name of the register for an alias, i.e. SP for R6 and LR or RET for R7.
+// Otherwise, the operand is returned unchanged. Aliases are only valid in register operands: RET
+// remains an opcode and SP or LR may still name a label.
+func registerName(oper string) string {
+	switch strings.ToUpper(oper) {
+	case "SP":
+		return "R6"
+	case "LR", "RET":
+		return "R7"
+	default:
+		return oper
+	}
+}
+
 // parseImmediate returns a constant literal value or a symbolic reference from an operand. The
 // value is taken as n bits long. Literals can take the forms:
 //
@@ -392,6 +941,7 @@ func parseRegister(oper string) string {
 //   - #x123
 //   - #o123
 //   - #b0101
+//   - 'A'
 //
 // Symbolic references may be in the forms:
 //
@@ -401,6 +951,8 @@ func parseImmediate(oper string, n uint8) (lit uint16, sym string, err error) {
 	switch {
 	case len(oper) > 1 && oper[0] == '#': // #IMMn
 		lit, err = parseLiteral(oper[1:], n)
+	case len(oper) > 1 && oper[0] == '\'': // 'C'
+		lit, err = parseLiteral(oper, n)
 	case len(oper) > 2 && oper[0] == '[' && oper[len(oper)-1] == ']': // [LABEL]
 		sym = oper[1 : len(oper)-2]
 	case len(oper) > 1:
@@ -419,6 +971,29 @@ func parseImmediate(oper string, n uint8) (lit uint16, sym string, err error) {
 	return
 }
 
+// parseImm5 returns a 5-bit immediate value or a symbolic reference from an operand, like
+// parseImmediate. Unlike parseImmediate, a decimal literal must be in the signed range [-16, 15], or
+// a LiteralRangeError is returned. Hex, octal and binary literals are taken as bit patterns, e.g.
+// #x1f is -1.
+func parseImm5(oper string) (uint16, string, error) {
+	lit, sym, err := parseImmediate(oper, 5)
+	if err != nil || sym != "" {
+		return lit, sym, err
+	}
+
+	text := strings.TrimPrefix(oper, "#")
+	digits := strings.TrimPrefix(text, "-")
+
+	if len(digits) > 0 && strings.IndexByte("xob'", digits[0]) < 0 {
+		val, err := literalValue(text, 5)
+		if err != nil || val < -16 || val > 15 {
+			return 0xffff, "", &LiteralRangeError{Literal: literalText(oper), Range: 5}
+		}
+	}
+
+	return lit & 0x001f, "", nil
+}
+
 // parseLiteral converts an operand as literal text to an n-bit integer value. If the literal cannot
 // be parsed, or if the value exceeds 2ⁿ bits, an error is returned. Accepts operands in the
 // forms:
@@ -428,30 +1003,47 @@ func parseImmediate(oper string, n uint8) (lit uint16, sym string, err error) {
 // - b01011010
 // - 0
 // - -1
+// - -x1
+// - 'A'
 func parseLiteral(operand string, n uint8) (uint16, error) {
 	if len(operand) == 0 {
 		return 0xffff, ErrLiteral
 	}
 
-	prefix := operand[0]
+	if operand[0] == '\'' {
+		return parseCharLiteral(operand, n)
+	}
+
+	val64, err := literalValue(operand, n)
+	if err != nil {
+		return 0xffff, err
+	}
+
+	return uint16(val64) & uint16(1<<n-1), nil
+}
+
+// literalValue converts literal text, other than a character literal, to a signed integer value
+// that does not exceed n bits, i.e. its range is [-(2ⁿ-1), 2ⁿ-1]. Unlike parseLiteral, the value
+// is not truncated to n bits.
+func literalValue(operand string, n uint8) (int64, error) {
 	literal := operand
 
 	switch {
-	case prefix == 'x':
-		literal = "0" + operand
-	case prefix == 'o':
-		literal = "0" + operand
-	case prefix == 'b':
+	case len(operand) == 0:
+		return 0, ErrLiteral
+	case operand[0] == '-' && len(operand) > 1 && strings.ContainsRune("xob", rune(operand[1])):
+		literal = "-0" + operand[1:]
+	case strings.ContainsRune("xob", rune(operand[0])):
 		literal = "0" + operand
 	}
 
 	// The parsed value must not exceed n bits, i.e. its range is [0, 2ⁿ). Using strconv.Uint16
 	// seems like the thing to do. However, it does not accept negative decimal literals, e.g. ADD
 	// R1,R1,#-1, which we would like to handle. So, we use a signed integer with n+1 bits, giving
-	// us the range [-2ⁿ, 2ⁿ], and checking for overflow and converting to unsigned.
+	// us the range [-2ⁿ, 2ⁿ], and checking for overflow.
 	val64, err := strconv.ParseInt(literal, 0, int(n)+1)
 	if err != nil {
-		return 0xffff, &LiteralRangeError{
+		return 0, &LiteralRangeError{
 			Literal: literal,
 			Range:   n,
 		}
@@ -460,22 +1052,227 @@ func parseLiteral(operand string, n uint8) (uint16, error) {
 	var bitmask int64 = 1<<n - 1
 
 	if val64 < -bitmask || val64 > bitmask {
-		return 0xffff, &LiteralRangeError{
+		return 0, &LiteralRangeError{
 			Literal: literal,
 			Range:   n,
 		}
 	}
 
-	val16 := uint16(val64) & uint16(bitmask)
+	return val64, nil
+}
+
+// applyRadix rewrites the bare numbers in a line as literals with the prefix for a radix, e.g. 1f00
+// as x1f00 in radix 16. A bare number begins with a digit: one that begins with a letter is a
+// symbol, e.g. ff00, and one that begins with '#' is decimal. Quoted text is not changed. A bare
+// number that is not valid in the radix, e.g. 9 in radix 8, is an error.
+func applyRadix(line string, radix int) (string, error) {
+	var (
+		out    strings.Builder
+		prefix = map[int]string{2: "b", 8: "o", 16: "x"}[radix]
+		quote  byte // Quote character of the current literal or zero, if unquoted.
+	)
+
+	isWord := func(c byte) bool {
+		return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+	}
+
+	for i := 0; i < len(line); {
+		c := line[i]
+
+		switch {
+		case quote != 0 && c == '\\' && i+1 < len(line):
+			out.WriteString(line[i : i+2]) // Escaped character.
+			i += 2
+
+			continue
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == 0 && isWord(c):
+			j := i
+			for j < len(line) && isWord(line[j]) {
+				j++
+			}
+
+			word := line[i:j]
+			decimal := strings.HasSuffix(line[:i], "#") || strings.HasSuffix(line[:i], "#-")
+
+			if '0' <= c && c <= '9' && !decimal {
+				if _, err := strconv.ParseUint(word, radix, 16); err != nil {
+					return "", fmt.Errorf("%w: %s: radix %d", ErrOperand, word, radix)
+				}
+
+				out.WriteString(prefix)
+			}
+
+			out.WriteString(word)
+			i = j
+
+			continue
+		}
+
+		out.WriteByte(c)
+		i++
+	}
+
+	return out.String(), nil
+}
+
+// isSymbol returns true if an operand is a symbolic reference, i.e. it is an identifier and not a
+// numeric literal of any size, e.g. LABEL but not x10000.
+func isSymbol(operand string) bool {
+	if !symbolPattern.MatchString(operand) {
+		return false
+	}
+
+	switch operand[0] {
+	case 'x', 'o', 'b':
+		_, err := strconv.ParseInt("0"+operand, 0, 64)
+		return err != nil
+	default:
+		return true
+	}
+}
+
+// parseCharLiteral converts a single-quoted character literal to an n-bit value. Besides single
+// characters, the escapes \n, \t, \r, \0, \\ and \' are recognized. An error is returned if the
+// literal is not a single character or if its value exceeds n bits.
+func parseCharLiteral(literal string, n uint8) (uint16, error) {
+	rangeErr := &LiteralRangeError{Literal: literal, Range: n}
+
+	if len(literal) < 3 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+		return 0xffff, fmt.Errorf("%w: %s", ErrLiteral, literal)
+	}
+
+	var (
+		char rune
+		body = literal[1 : len(literal)-1]
+	)
+
+	switch body {
+	case `\n`:
+		char = '\n'
+	case `\t`:
+		char = '\t'
+	case `\r`:
+		char = '\r'
+	case `\0`:
+		char = 0
+	case `\\`:
+		char = '\\'
+	case `\'`:
+		char = '\''
+	default:
+		if utf8.RuneCountInString(body) != 1 || body == `\` {
+			return 0xffff, rangeErr
+		}
+
+		char, _ = utf8.DecodeRuneInString(body)
+	}
+
+	if uint64(char) > 1<<n-1 {
+		return 0xffff, rangeErr
+	}
+
+	return uint16(char), nil
+}
+
+// indexUnquoted returns the index of the first instance of sep in s that is not in a quoted
+// character or string literal, or -1 if there is none.
+func indexUnquoted(s string, sep byte) int {
+	var quote byte // Quote character of the current literal or zero, if unquoted.
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\':
+			i++ // Skip escaped character.
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '\'' || s[i] == '"'):
+			quote = s[i]
+		case quote == 0 && s[i] == sep:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// indexComment returns the index of the start of a comment in s or -1, if there is none. Like in
+// C, a comment may start with two slashes as well as with a semicolon. Neither may be in a quoted
+// literal.
+func indexComment(s string) int {
+	end := indexUnquoted(s, ';')
+	if end < 0 {
+		end = len(s)
+	}
+
+	for off := 0; off < end; {
+		i := indexUnquoted(s[off:end], '/')
+		if i < 0 {
+			break
+		} else if off+i+1 < end && s[off+i+1] == '/' {
+			return off + i
+		}
+
+		off += i + 1
+	}
+
+	if end == len(s) {
+		return -1
+	}
 
-	return val16, nil
+	return end
+}
+
+// splitUnquoted splits s on each instance of sep that is not in a quoted literal.
+func splitUnquoted(s string, sep byte) []string {
+	var split []string
+
+	for i := indexUnquoted(s, sep); i >= 0; i = indexUnquoted(s, sep) {
+		split = append(split, s[:i])
+		s = s[i+1:]
+	}
+
+	return append(split, s)
+}
+
+// literalText returns an operand as it would be given to parseLiteral, i.e. without a leading '#'
+// and with a leading zero for based literals.
+func literalText(operand string) string {
+	operand = strings.TrimPrefix(operand, "#")
+
+	if len(operand) > 0 && strings.IndexByte("xob", operand[0]) >= 0 {
+		operand = "0" + operand
+	}
+
+	return operand
 }
 
 // addSyntaxError appends a new SyntaxError wrapping err.
 func (p *Parser) addSyntaxError(err error) {
+	p.addSyntaxErrorAt(0, err)
+}
+
+// addWarning appends a warning for the line being parsed.
+func (p *Parser) addWarning(err error) {
+	p.warnings = append(p.warnings, Warning{
+		File: p.filename,
+		Loc:  p.loc,
+		Pos:  p.pos,
+		Line: p.line,
+		Msg:  err.Error(),
+	})
+}
+
+// addSyntaxErrorAt appends a new SyntaxError wrapping err that occurred at a column in the line.
+func (p *Parser) addSyntaxErrorAt(col vm.Word, err error) {
 	err = &SyntaxError{
+		File: p.filename,
 		Loc:  p.loc,
 		Pos:  p.pos,
+		Col:  col,
 		Line: p.line,
 		Err:  err,
 	}
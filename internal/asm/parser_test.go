@@ -5,10 +5,13 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"testing/iotest"
@@ -84,7 +87,7 @@ func (fake *fakeInstruction) Parse(oper string, opers []string) error {
 }
 
 func (fake *fakeInstruction) Generate(sym SymbolTable, loc vm.Word) ([]vm.Word, error) {
-	return nil, nil
+	return []vm.Word{0x0000}, nil
 }
 
 func (fake *fakeInstruction) Source() SourceInfo {
@@ -166,6 +169,24 @@ eof:
   .END
 `)
 
+func TestParser_LocCheck(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(ValidSyntax))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	} else if err := parser.LocCheck(); err != nil {
+		t.Error(err)
+	}
+
+	// Corrupt the location of an operation.
+	parser.syntax.Source(3).Loc++
+
+	if err := parser.LocCheck(); !errors.Is(err, ErrVerify) {
+		t.Errorf("want: %v, got: %v", ErrVerify, err)
+	}
+}
+
 // A rough integration test for the parser. It is quite brittle and, yet, has proven valuable during
 // design and development.
 func TestParser(tt *testing.T) {
@@ -236,6 +257,10 @@ func TestParser_Fixtures(tt *testing.T) {
 		"parser6.asm",
 		"parser7.asm",
 		"parser8.asm",
+		"parser10.asm",
+		"parser11.asm",
+		"parser12.asm",
+		"parser13.asm",
 	}
 
 	for _, fn := range tests {
@@ -257,6 +282,38 @@ func TestParser_Fixtures(tt *testing.T) {
 	}
 }
 
+func TestParser_DirectiveLabels(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputFixture("parser12.asm"))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := SymbolTable{
+		"BEGIN":  0x3000,
+		"WORDS":  0x3000,
+		"BLOCK":  0x3003,
+		"TEXT":   0x3007,
+		"PACKED": 0x300b,
+		"AFTER":  0x300e,
+		"NEXT":   0x4000,
+	}
+
+	symbols := parser.Symbols()
+
+	for label, loc := range want {
+		if got, ok := symbols[label]; !ok || got != loc {
+			t.Errorf("%s: want: %s, got: %s", label, loc, got)
+		}
+	}
+
+	if len(symbols) != len(want) {
+		t.Errorf("symbols: want: %v, got: %v", want, symbols)
+	}
+}
+
 type errorCase struct {
 	name string
 	in   io.Reader
@@ -287,7 +344,7 @@ func TestAssembler_Errors(tt *testing.T) {
 			name: "total nonsense",
 			in:   strings.NewReader(`result ← 2 3 5 + 1 4 6`),
 			want: &SyntaxError{
-				Loc:  0,
+				Loc:  0x3000,
 				Pos:  1,
 				File: "",
 				Line: `result ← 2 3 5 + 1 4 6`,
@@ -355,7 +412,7 @@ func GenerateErrors(tc errorCase, t ParserHarness) {
 	syn := t.parser.Syntax()
 	gen := NewGenerator(sym, syn)
 
-	_, err := gen.writeTo(bytes.NewBuffer(make([]byte, 0, 8192)))
+	_, err := gen.WriteTo(bytes.NewBuffer(make([]byte, 0, 8192)))
 
 	if err != nil {
 		t.Log(err.Error())
@@ -439,11 +496,129 @@ func TestParser_FILL(tt *testing.T) {
 		t.Error("Source is not wrapped")
 	}
 
-	if fill, ok := code.(*FILL); !ok || fill.LITERAL != 0xdada {
+	if fill, ok := code.(*FILL); !ok || len(fill.LITERAL) != 1 || fill.LITERAL[0] != 0xdada {
 		t.Errorf("data: 0x1234 %#v != %0#4x", code, 0xdada)
 	}
 }
 
+func TestParser_MUL(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+.ORIG x3000
+      MUL R0,R1,R2
+DONE  HALT
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The label following the expansion must account for all of its words.
+	if loc, ok := parser.Symbols()["DONE"]; !ok || loc != 0x300e {
+		t.Errorf("DONE: want: %0#4x, got: %0#4x", 0x300e, loc)
+	}
+}
+
+func TestParser_MOV(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+.ORIG x3000
+      MOV R0,R1
+REG   MOV R2,#-5
+IMM   HALT
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := parser.Symbols()
+
+	if loc := symbols["REG"]; loc != 0x3001 {
+		t.Errorf("REG: want: %0#4x, got: %0#4x", 0x3001, loc)
+	}
+
+	if loc := symbols["IMM"]; loc != 0x3003 {
+		t.Errorf("IMM: want: %0#4x, got: %0#4x", 0x3003, loc)
+	}
+}
+
+func TestParser_FILLValues(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+MAXLEN .EQU #10
+.ORIG x3000
+TABLE .DW x1, x2, x3
+NEXT  .FILL 1, 'B', MAXLEN
+DONE  HALT
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := parser.Symbols()
+
+	if loc := symbols["NEXT"]; loc != 0x3003 {
+		t.Errorf("NEXT: want: %0#4x, got: %0#4x", 0x3003, loc)
+	}
+
+	if loc := symbols["DONE"]; loc != 0x3006 {
+		t.Errorf("DONE: want: %0#4x, got: %0#4x", 0x3006, loc)
+	}
+
+	if fill, ok := unwrap(parser.Syntax()[1]).(*FILL); !ok || !slices.Equal(fill.LITERAL, []uint16{1, 2, 3}) {
+		t.Errorf("fill: want: [1 2 3], got: %#v", parser.Syntax()[1])
+	}
+
+	if fill, ok := unwrap(parser.Syntax()[2]).(*FILL); !ok || !slices.Equal(fill.LITERAL, []uint16{1, 'B', 10}) {
+		t.Errorf("fill: want: [1 66 10], got: %#v", parser.Syntax()[2])
+	}
+}
+
+func TestParser_FILLSymbol(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+.ORIG x3000
+START  LD R0,PTR
+       HALT
+PTR    .FILL START
+LATER  .FILL START, x10, END
+END    .FILL x10000
+`)
+
+	parser := t.ParseStream(in)
+
+	var litErr *LiteralRangeError
+	if err := parser.Err(); !errors.As(err, &litErr) {
+		t.Fatalf("want: LiteralRangeError, got: %v", err)
+	}
+
+	fill, ok := unwrap(parser.Syntax()[4]).(*FILL)
+	if !ok || !slices.Equal(fill.SYMBOL, []string{"START", "", "END"}) {
+		t.Fatalf("fill: want: symbols [START  END], got: %#v", parser.Syntax()[4])
+	}
+
+	code, err := fill.Generate(parser.Symbols(), 0x3003)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []vm.Word{0x3000, 0x0010, 0x3006}; !slices.Equal(code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
 func TestParser_STRINGZ(tt *testing.T) {
 	t := ParserHarness{T: tt}
 
@@ -477,6 +652,111 @@ func TestParser_STRINGZ(tt *testing.T) {
 	}
 }
 
+func TestParser_STRINGZUnicode(tt *testing.T) {
+	t := ParserHarness{T: tt}
+
+	// Each rune is three bytes in UTF-8, but one or two words in UTF-16.
+	in := t.inputString(`
+.ORIG x3000
+HELLO .STRINGZ "⍨⍤"
+EMOJI .STRINGZ "😀!"
+NEXT  HALT
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSymbol(t, parser.Symbols(), "EMOJI", 0x3003)
+	assertSymbol(t, parser.Symbols(), "NEXT", 0x3007)
+
+	gen := NewGenerator(parser.Symbols(), parser.Syntax())
+
+	code, err := gen.generate()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(code) != 1 || len(code[0].Code) != 8 {
+		t.Errorf("code: want: 8 words, got: %v", code)
+	}
+}
+
+func TestParser_STRINGP(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+.ORIG x3000
+ODD   .STRINGP "Hey"
+EVEN  .STRINGP "Hi!?"
+NEXT  HALT
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSymbol(t, parser.Symbols(), "EVEN", 0x3002)
+	assertSymbol(t, parser.Symbols(), "NEXT", 0x3005)
+}
+
+func TestParser_INCBIN(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("fixture", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputFixture("parser13.asm"))
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		} else if err := parser.LocCheck(); err != nil {
+			t.Error(err)
+		}
+
+		assertSymbol(t, parser.Symbols(), "TABLE", 0x3002)
+		assertSymbol(t, parser.Symbols(), "AFTER", 0x3005)
+
+		var code []vm.Word
+
+		_ = parser.Syntax().Walk(func(si *SourceInfo) error {
+			if inc, ok := si.Operation.(*INCBIN); ok {
+				code, _ = inc.Generate(parser.Symbols(), si.Loc+1)
+			}
+
+			return nil
+		})
+
+		if want := []vm.Word{0x1234, 0x0001, 0xfffe}; !slices.Equal(want, code) {
+			t.Errorf("code: want: %v, got: %v", want, code)
+		}
+	})
+
+	tt.Run("odd length", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+.ORIG x3000
+.INCBIN "testdata/odd.bin"
+`))
+
+		if err := parser.Err(); !errors.Is(err, ErrOperand) {
+			t.Errorf("want: %v, got: %v", ErrOperand, err)
+		}
+	})
+
+	tt.Run("missing file", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+.ORIG x3000
+.INCBIN "testdata/missing.bin"
+`))
+
+		if err := parser.Err(); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("want: %v, got: %v", os.ErrNotExist, err)
+		}
+	})
+}
+
 func assertSymbol(t ParserHarness, symbols SymbolTable, label string, want vm.Word) {
 	t.Helper()
 
@@ -486,3 +766,914 @@ func assertSymbol(t ParserHarness, symbols SymbolTable, label string, want vm.Wo
 		t.Errorf("symbol: %s, want: %0#4x, got: %0#4x", label, want, got)
 	}
 }
+
+func TestParser_EQU(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("constant", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		in := t.inputString(`
+MAXLEN  .EQU #10
+NEG     .EQU #-1
+        .ORIG x3000
+START   ADD R0,R0,MAXLEN
+        AND R1,R1,neg
+        .FILL MAXLEN
+`)
+
+		parser := t.ParseStream(in)
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		assertSymbol(t, parser.Constants(), "MAXLEN", 10)
+		assertSymbol(t, parser.Constants(), "NEG", 0xffff)
+		assertSymbol(t, parser.Symbols(), "START", 0x3000)
+
+		if _, ok := parser.Symbols()["MAXLEN"]; ok {
+			t.Error("constant is in symbol table")
+		}
+
+		syntax := parser.Syntax()
+
+		if syntax.Size() != 4 {
+			t.Fatalf("size: %d != %d", syntax.Size(), 4)
+		}
+
+		if add, ok := unwrap(syntax[1]).(*ADD); !ok || add.LITERAL != 10 {
+			t.Errorf("add: want: literal 10, got: %#v", syntax[1])
+		}
+
+		if and, ok := unwrap(syntax[2]).(*AND); !ok || and.LITERAL != 0x1f || and.SYMBOL != "" {
+			t.Errorf("and: want: literal 0x1f, got: %#v", syntax[2])
+		}
+
+		if fill, ok := unwrap(syntax[3]).(*FILL); !ok || len(fill.LITERAL) != 1 || fill.LITERAL[0] != 10 {
+			t.Errorf("fill: want: literal 10, got: %#v", syntax[3])
+		}
+	})
+
+	tt.Run("redefined", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		in := t.inputString(`
+MAXLEN  .EQU #10
+MAXLEN  .EQU #20
+`)
+
+		parser := t.ParseStream(in)
+
+		if err := parser.Err(); !errors.Is(err, ErrConstant) {
+			t.Errorf("expected constant error, got: %v", err)
+		}
+
+		assertSymbol(t, parser.Constants(), "MAXLEN", 10)
+	})
+
+	tt.Run("redefined label", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		in := t.inputString(`
+        .ORIG x3000
+MAXLEN  .EQU #10
+MAXLEN  ADD R0,R0,#1
+`)
+
+		parser := t.ParseStream(in)
+
+		if err := parser.Err(); !errors.Is(err, ErrConstant) {
+			t.Errorf("expected constant error, got: %v", err)
+		}
+	})
+
+	tt.Run("not a label", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		in := t.inputString(`
+        .ORIG x3000
+MAXLEN  .EQU #10
+        BR MAXLEN
+`)
+
+		parser := t.ParseStream(in)
+
+		if err := parser.Err(); !errors.Is(err, ErrConstant) {
+			t.Errorf("expected constant error, got: %v", err)
+		}
+	})
+}
+
+func TestParser_SlashComment(tt *testing.T) {
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+// A full-line comment.
+        .ORIG x3000   // The origin.
+START   LEA R0,TEXT   // End-of-line comment.
+        BR START// No space.
+TEXT    .STRINGZ "http://example.com" ; Slashes in a string.
+SLASH   .FILL '/'     // A slash literal.
+        // An indented comment.
+END     HALT          ; Mixed; with // both.
+`)
+
+	parser := t.ParseStream(in)
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSymbol(t, parser.Symbols(), "START", 0x3000)
+	assertSymbol(t, parser.Symbols(), "TEXT", 0x3002)
+	assertSymbol(t, parser.Symbols(), "SLASH", 0x3015)
+	assertSymbol(t, parser.Symbols(), "END", 0x3016)
+
+	syntax := parser.Syntax()
+
+	if str, ok := unwrap(syntax[3]).(*STRINGZ); !ok || str.LITERAL != "http://example.com" {
+		t.Errorf("stringz: want: %q, got: %#v", "http://example.com", syntax[3])
+	} else if fill, ok := unwrap(syntax[4]).(*FILL); !ok || fill.LITERAL[0] != '/' {
+		t.Errorf("fill: want: %0#4x, got: %#v", '/', syntax[4])
+	}
+}
+
+func TestParser_CharLiteral(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("literals", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		in := t.inputString(`
+        .ORIG x3000
+        .FILL 'A'
+        .FILL '\n'
+        .FILL '\''
+        .FILL ';'     ; not a comment
+        .FILL ','
+        ADD R0,R0,'\t'
+`)
+
+		parser := t.ParseStream(in)
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		syntax := parser.Syntax()
+		want := []uint16{'A', '\n', '\'', ';', ','}
+
+		for i, w := range want {
+			if fill, ok := unwrap(syntax[i+1]).(*FILL); !ok || len(fill.LITERAL) != 1 || fill.LITERAL[0] != w {
+				t.Errorf("fill: want: literal %0#4x, got: %#v", w, syntax[i+1])
+			}
+		}
+
+		if add, ok := unwrap(syntax[6]).(*ADD); !ok || add.LITERAL != '\t' {
+			t.Errorf("add: want: literal %0#4x, got: %#v", '\t', syntax[6])
+		}
+	})
+
+	tt.Run("range", func(tt *testing.T) {
+		for _, src := range []string{
+			"        .FILL 'AB'",
+			"        ADD R1,R1,'A'",
+		} {
+			t := ParserHarness{T: tt}
+			parser := t.ParseStream(t.inputString(".ORIG x3000\n" + src + "\n"))
+
+			var litErr *LiteralRangeError
+			if err := parser.Err(); !errors.As(err, &litErr) {
+				t.Errorf("%q: expected literal range error, got: %v", src, err)
+			}
+		}
+	})
+}
+
+func TestParser_ImplicitOrigin(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	in := t.inputString(`
+START   ADD R0,R0,#1
+        .FILL x1234
+NEXT    HALT
+`)
+
+	parser := t.ParseStream(in)
+	err := parser.Err()
+
+	var se *SyntaxError
+	if !errors.As(err, &se) || !errors.Is(err, ErrOrigin) {
+		t.Fatalf("expected origin error, got: %v", err)
+	} else if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 1 {
+		t.Errorf("expected a single error, got: %v", errs)
+	}
+
+	assertSymbol(t, parser.Symbols(), "START", 0x3000)
+	assertSymbol(t, parser.Symbols(), "NEXT", 0x3002)
+
+	if orig, ok := unwrap(parser.Syntax()[0]).(*ORIG); !ok || orig.LITERAL != 0x3000 {
+		t.Errorf("orig: want: %0#4x, got: %#v", 0x3000, parser.Syntax()[0])
+	}
+
+	if sections := parser.Sections(); len(sections) != 1 || sections[0] != (Section{Orig: 0x3000, Size: 3}) {
+		t.Errorf("sections: want: one at %0#4x, got: %+v", 0x3000, sections)
+	}
+}
+
+func TestParser_Progress(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+
+	var (
+		locs  []vm.Word
+		lines []string
+	)
+
+	parser := NewParser(t.logger(), WithParseProgress(func(loc vm.Word, line string) {
+		locs = append(locs, loc)
+		lines = append(lines, line)
+	}))
+
+	parser.Parse(t.inputString(`
+        .ORIG x3000
+
+START   ADD R0,R0,#1
+        ; a comment
+        MUL R2,R0,R1
+        .FILL 1, 2
+        HALT
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{0x3000, 0x3000, 0x3001, 0x3001, 0x300f, 0x3011}
+
+	if !slices.Equal(locs, want) {
+		t.Errorf("locs: want: %v, got: %v", want, locs)
+	}
+
+	if len(lines) != 6 || lines[1] != "START   ADD R0,R0,#1" {
+		t.Errorf("lines: got: %q", lines)
+	}
+
+	for i := 1; i < len(locs); i++ {
+		if locs[i] < locs[i-1] {
+			t.Errorf("loc: not monotonic: %v", locs)
+		}
+	}
+}
+
+func TestSyntaxTable_Walk(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+START   ADD R0,R0,#1
+        .BLKW 2
+        .STRINGZ "Hi"
+        HALT
+        .END
+        .ORIG x4000
+        .FILL x1234
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		locs  []vm.Word
+		lines []vm.Word
+	)
+
+	err := parser.Syntax().Walk(func(si *SourceInfo) error {
+		locs = append(locs, si.Loc)
+		lines = append(lines, si.Pos)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantLocs := []vm.Word{0x3000, 0x3000, 0x3001, 0x3003, 0x3006, 0x3007, 0x4000, 0x4000}
+	if !slices.Equal(locs, wantLocs) {
+		t.Errorf("locs: want: %v, got: %v", wantLocs, locs)
+	}
+
+	wantLines := []vm.Word{2, 3, 4, 5, 6, 7, 8, 9}
+	if !slices.Equal(lines, wantLines) {
+		t.Errorf("lines: want: %v, got: %v", wantLines, lines)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+
+	err = parser.Syntax().Walk(func(si *SourceInfo) error {
+		count++
+
+		if _, ok := si.Operation.(*BLKW); ok {
+			return stop
+		}
+
+		return nil
+	})
+
+	if !errors.Is(err, stop) || count != 3 {
+		t.Errorf("stop: want: %v after 3, got: %v after %d", stop, err, count)
+	}
+}
+
+func TestParser_Aliases(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        nop
+        NOP
+        Getc
+        out
+        PUTS
+        IN
+        putsp
+        halt
+        jmp R7
+        RET
+        brNZ #-1
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := NewGenerator(parser.Symbols(), parser.Syntax()).generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{
+		0x0000, 0x0000, // NOP
+		0xf020, 0xf021, 0xf022, 0xf023, 0xf024, 0xf025, // Traps.
+		0xc1c0, 0xc1c0, // RET
+		0x0dff, // BRnz #-1
+	}
+
+	if len(code) != 1 || !slices.Equal(code[0].Code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
+func TestParser_RegisterAliases(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        ADD SP,SP,#-1
+        STR LR,SP,#0
+        JSRR LR
+        JMP ret
+        RET
+SP      .FILL x1234
+        LD R0,SP
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	syntax := parser.Syntax()
+
+	if add, ok := unwrap(syntax[1]).(*ADD); !ok || add.DR != "R6" || add.SR1 != "R6" {
+		t.Errorf("want: ADD R6,R6,#-1, got: %#v", syntax[1])
+	}
+
+	if jsrr, ok := unwrap(syntax[3]).(*JSRR); !ok || jsrr.SR != "R7" {
+		t.Errorf("want: JSRR R7, got: %#v", syntax[3])
+	}
+
+	code, err := NewGenerator(parser.Symbols(), parser.Syntax()).generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{
+		0x1dbf, // ADD R6,R6,#-1
+		0x7f80, // STR R7,R6,#0
+		0x41c0, // JSRR R7
+		0xc1c0, // JMP R7
+		0xc1c0, // RET
+		0x1234, // SP
+		0x21fe, // LD R0,SP
+	}
+
+	if len(code) != 1 || !slices.Equal(code[0].Code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
+func TestParser_DuplicateLabel(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+LOOP    ADD R0,R0,#1
+LOOP    ADD R0,R0,#-1
+DONE    HALT
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+
+	warnings := parser.Warnings()
+
+	if len(warnings) != 1 {
+		t.Fatalf("warnings: want: 1, got: %v", warnings)
+	} else if warn := warnings[0]; warn.Pos != 4 || warn.Loc != 0x3001 {
+		t.Errorf("want: warning on line 4 at 0x3001, got: %v", warn)
+	}
+
+	want := &DuplicateSymbolError{Symbol: "LOOP", Loc: 0x3001, Prev: 0x3000}
+
+	if msg := warnings[0].Msg; msg != want.Error() {
+		t.Errorf("message: want: %q, got: %q", want.Error(), msg)
+	}
+
+	// The last definition is kept.
+	assertSymbol(t, parser.Symbols(), "LOOP", 0x3001)
+	assertSymbol(t, parser.Symbols(), "DONE", 0x3002)
+}
+
+func TestParser_ErrorColumn(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		col  vm.Word
+	}{
+		{name: "unknown opcode", in: "LOOP: XOR R1,R2", col: 7},
+		{name: "unknown opcode, indented", in: "\tLOOP  XOR R1,R2", col: 8},
+		{name: "immediate out of range", in: "  AND R1, R1, #x7000", col: 15},
+		{name: "immediate out of range, no spaces", in: "ADD R1,R1,#-100", col: 11},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := ParserHarness{T: tt}
+			t.Parallel()
+
+			parser := t.ParseStream(t.inputString(".ORIG x3000\n" + tc.in))
+			err := parser.Err()
+
+			var se *SyntaxError
+
+			if !errors.As(err, &se) {
+				t.Fatalf("expected syntax error, got: %v", err)
+			}
+
+			if se.Col != tc.col {
+				t.Errorf("col: want: %d, got: %d", tc.col, se.Col)
+			}
+
+			if !strings.Contains(se.Error(), fmt.Sprintf("col %d", tc.col)) {
+				t.Errorf("error: missing col: %s", se.Error())
+			}
+		})
+	}
+}
+
+func TestParser_Macro(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+.MACRO PUSH reg
+        ADD R6,R6,#-1
+        STR reg,R6,#0   ; store reg
+.ENDM
+
+        .ORIG x3000
+START   PUSH R1
+        push R2
+        HALT
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		loc  vm.Word
+		pos  vm.Word
+		oper Operation
+	}{
+		{0x3000, 7, &ORIG{LITERAL: 0x3000}},
+		{0x3000, 8, &ADD{DR: "R6", SR1: "R6", LITERAL: 0x1f}},
+		{0x3001, 8, &STR{SR1: "R1", SR2: "R6"}},
+		{0x3002, 9, &ADD{DR: "R6", SR1: "R6", LITERAL: 0x1f}},
+		{0x3003, 9, &STR{SR1: "R2", SR2: "R6"}},
+		{0x3004, 10, &TRAP{LITERAL: 0x25}},
+	}
+
+	syntax := parser.Syntax()
+
+	if len(syntax) != len(want) {
+		t.Fatalf("syntax: want: %d operations, got: %d: %v", len(want), len(syntax), syntax)
+	}
+
+	for i := range want {
+		src, ok := syntax[i].(*SourceInfo)
+
+		switch {
+		case !ok:
+			t.Errorf("%d: want: *SourceInfo, got: %T", i, syntax[i])
+		case src.Loc != want[i].loc || src.Pos != want[i].pos:
+			t.Errorf("%d: want: loc %s, line %d, got: loc %s, line %d",
+				i, want[i].loc, want[i].pos, src.Loc, src.Pos)
+		case !reflect.DeepEqual(src.Operation, want[i].oper):
+			t.Errorf("%d: want: %#v, got: %#v", i, want[i].oper, src.Operation)
+		}
+	}
+
+	assertSymbol(t, parser.Symbols(), "START", 0x3000)
+}
+
+func TestSubstituteParams(tt *testing.T) {
+	tt.Parallel()
+
+	params, args := []string{"reg", "msg"}, []string{"R1", "GREETING"}
+
+	tcs := []struct {
+		in, want string
+	}{
+		{in: "STR reg,R6,#0", want: "STR R1,R6,#0"},
+		{in: "LEA R0,MSG ; print msg", want: "LEA R0,GREETING ; print msg"},
+		{in: "LEA R0,msg // load msg", want: "LEA R0,GREETING // load msg"},
+		{in: `.STRINGZ "reg: msg"`, want: `.STRINGZ "reg: msg"`},
+		{in: `.STRINGZ "say \"msg\"" ; msg`, want: `.STRINGZ "say \"msg\"" ; msg`},
+		{in: `.FILL 'r' ; reg`, want: `.FILL 'r' ; reg`},
+		{in: "ADD reg,reg,regs", want: "ADD R1,R1,regs"},
+	}
+
+	for _, tc := range tcs {
+		if got := substituteParams(tc.in, params, args); got != tc.want {
+			tt.Errorf("%q: want: %q, got: %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestParser_MacroErrors(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		pos  vm.Word
+		want error
+	}{
+		{
+			name: "recursive",
+			in:   ".MACRO LOOP\nLOOP\n.ENDM\n.ORIG x3000\nLOOP\n",
+			pos:  5,
+			want: ErrMacro,
+		},
+		{
+			name: "wrong arguments",
+			in:   ".MACRO INC reg\nADD reg,reg,#1\n.ENDM\n.ORIG x3000\nINC R1,R2\n",
+			pos:  5,
+			want: ErrMacro,
+		},
+		{
+			name: "error in expansion",
+			in:   ".MACRO INC reg\nADD reg,reg,#100\n.ENDM\n.ORIG x3000\nINC R1\n",
+			pos:  5,
+			want: &LiteralRangeError{},
+		},
+		{
+			name: "unterminated",
+			in:   ".ORIG x3000\n.MACRO INC reg\nADD reg,reg,#1\n",
+			pos:  4,
+			want: ErrMacro,
+		},
+		{
+			name: "reserved name",
+			in:   ".MACRO ADD reg\n.ENDM\n",
+			pos:  1,
+			want: ErrMacro,
+		},
+		{
+			name: "stray end",
+			in:   ".ENDM\n",
+			pos:  1,
+			want: ErrMacro,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := ParserHarness{T: tt}
+			t.Parallel()
+
+			parser := t.ParseStream(t.inputString(tc.in))
+			err := parser.Err()
+
+			var se *SyntaxError
+
+			if !errors.As(err, &se) {
+				t.Fatalf("expected syntax error, got: %v", err)
+			} else if se.Pos != tc.pos {
+				t.Errorf("line: want: %d, got: %d: %v", tc.pos, se.Pos, err)
+			}
+
+			if le := (*LiteralRangeError)(nil); errors.As(tc.want, &le) {
+				if !errors.As(err, &le) {
+					t.Errorf("want: %T, got: %v", le, err)
+				}
+			} else if !errors.Is(err, tc.want) {
+				t.Errorf("want: %v, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParser_Space(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+BUF     .SPACE 4
+ZEROS   .ZERO 2
+AFTER   HALT
+        .SPACE 2, xffff
+`))
+
+	err := parser.Err()
+	if !errors.Is(err, ErrOperand) {
+		t.Errorf("want: %v, got: %v", ErrOperand, err)
+	}
+
+	symbols := parser.Symbols()
+
+	if symbols["ZEROS"] != 0x3004 || symbols["AFTER"] != 0x3006 {
+		t.Errorf("symbols: want: ZEROS: 0x3004, AFTER: 0x3006, got: %v", symbols)
+	}
+
+	code, err := NewGenerator(symbols, parser.Syntax()).ObjectCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{0, 0, 0, 0, 0, 0, 0xf025}
+	if len(code) != 1 || !slices.Equal(code[0].Code, want) {
+		t.Errorf("code: want: %v, got: %v", want, code)
+	}
+}
+
+func TestParser_Imm5Range(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		in      string
+		want    vm.Word
+		wantErr bool
+	}{
+		{in: "ADD R0,R0,#15", want: 0x102f},
+		{in: "ADD R0,R0,#-16", want: 0x1030},
+		{in: "ADD R0,R0,#16", wantErr: true},
+		{in: "ADD R0,R0,#-17", wantErr: true},
+		{in: "AND R0,R0,#15", want: 0x502f},
+		{in: "AND R0,R0,#-16", want: 0x5030},
+		{in: "AND R0,R0,#16", wantErr: true},
+		{in: "AND R0,R0,#x1f", want: 0x503f},
+		{in: "ADD R0,R0,#1_0", want: 0x102a},
+		{in: "ADD R0,R0,#-x1", want: 0x103f},
+		{in: "ADD R0,R0,#1_6", wantErr: true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.in, func(tt *testing.T) {
+			t := ParserHarness{T: tt}
+			t.Parallel()
+
+			parser := t.ParseStream(t.inputString(".ORIG x3000\n" + tc.in + "\n"))
+			err := parser.Err()
+
+			if tc.wantErr {
+				var (
+					se *SyntaxError
+					le *LiteralRangeError
+				)
+
+				if !errors.As(err, &se) || !errors.As(err, &le) {
+					t.Fatalf("want: literal range syntax error, got: %v", err)
+				} else if se.Pos != 2 || se.Col != 11 {
+					t.Errorf("pos: want: 2:11, got: %d:%d", se.Pos, se.Col)
+				} else if want := strings.TrimPrefix(tc.in[10:], "#"); le.Literal != want {
+					t.Errorf("literal: want: %q, got: %q", want, le.Literal)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			code, err := NewGenerator(parser.Symbols(), parser.Syntax()).ObjectCode()
+			if err != nil {
+				t.Fatal(err)
+			} else if got := code[0].Code[0]; got != tc.want {
+				t.Errorf("code: want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParser_OrigExpression(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("literal", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        HALT
+`))
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		} else if sections := parser.Sections(); len(sections) != 1 || sections[0].Orig != 0x3000 {
+			t.Errorf("sections: want: 0x3000, got: %v", sections)
+		}
+	})
+
+	tt.Run("symbol", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        HALT
+PREVEND .FILL x1234
+        .ORIG PREVEND+1
+NEXT    HALT
+GAP     .EQU #16
+        .ORIG NEXT+GAP
+LAST    HALT
+`))
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]vm.Word{"PREVEND": 0x3001, "NEXT": 0x3002, "LAST": 0x3012}
+
+		for name, loc := range want {
+			if got := parser.Symbols()[name]; got != loc {
+				t.Errorf("%s: want: %s, got: %s", name, loc, got)
+			}
+		}
+
+		code, err := NewGenerator(parser.Symbols(), parser.Syntax()).ObjectCode()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(code) != 3 || code[1].Orig != 0x3002 || code[2].Orig != 0x3012 {
+			t.Errorf("code: want: 3 sections at 0x3000, 0x3002, 0x3012, got: %v", code)
+		}
+	})
+
+	tt.Run("literals", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+        .ORIG x3000+x10
+        HALT
+        .ORIG x4000-#1
+        HALT
+`))
+
+		if err := parser.Err(); err != nil {
+			t.Fatal(err)
+		} else if sections := parser.Sections(); len(sections) != 2 ||
+			sections[0].Orig != 0x3010 || sections[1].Orig != 0x3fff {
+			t.Errorf("sections: want: 0x3010, 0x3fff, got: %v", sections)
+		}
+	})
+
+	tt.Run("forward", func(tt *testing.T) {
+		t := ParserHarness{T: tt}
+		parser := t.ParseStream(t.inputString(`
+        .ORIG LATER
+LATER   HALT
+`))
+
+		if err := parser.Err(); !errors.Is(err, ErrOrigin) {
+			t.Errorf("want: %v, got: %v", ErrOrigin, err)
+		}
+	})
+}
+
+func TestParser_Limits(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name string
+		opts []ParserOption
+		line string
+	}{
+		{"default", nil, "; " + strings.Repeat("x", DefaultMaxLineLength)},
+		{"line", []ParserOption{WithLimits(64, 0)}, "    .FILL " + strings.Repeat("1,", 40) + "1"},
+		{"operands", []ParserOption{WithLimits(0, 4)}, "    .FILL 1,2,3,4,5"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.name, func(tt *testing.T) {
+			tt.Parallel()
+
+			t := ParserHarness{T: tt}
+			parser := NewParser(t.logger(), tc.opts...)
+			parser.Parse(t.inputString(".ORIG x3000\n" + tc.line + "\nNEXT HALT\n"))
+
+			var syntaxErr *SyntaxError
+
+			if err := parser.Err(); !errors.Is(err, ErrLimit) {
+				t.Errorf("want: %v, got: %v", ErrLimit, err)
+			} else if !errors.As(err, &syntaxErr) || syntaxErr.Pos != 2 {
+				t.Errorf("want: syntax error at line 2, got: %v", err)
+			}
+
+			// Parsing continues after the line, which is not parsed.
+			if loc := parser.Symbols()["NEXT"]; loc != 0x3000 {
+				t.Errorf("NEXT: want: %s, got: %s", vm.Word(0x3000), loc)
+			}
+		})
+	}
+}
+
+func TestParser_Radix(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        .FILL 10            ; Decimal, by default.
+        .RADIX 16
+        .FILL 1f00
+        .FILL #10, -10      ; Always decimal, but not negated bare numbers.
+        .FILL x10, 0ff      ; Prefixed, and with a leading zero.
+        .STRINGZ "10"       ; Quoted text is unchanged.
+        ADD R1,R1,-1
+        ADD R2,R2,#-2
+        .RADIX 2
+        .FILL 101
+        .RADIX 8
+        .FILL 17
+        .RADIX 10
+        .FILL 10
+        .RADIX 3
+`))
+
+	if err := parser.Err(); !errors.Is(err, ErrOperand) {
+		t.Errorf("want: %v, got: %v", ErrOperand, err)
+	} else if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 1 {
+		t.Errorf("errors: want: 1, got: %v", errs)
+	}
+
+	code, err := NewGenerator(parser.Symbols(), parser.Syntax()).ObjectCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vm.Word{10, 0x1f00, 10, 0xfff0, 0x10, 0xff, '1', '0', 0, 0x127f, 0x14be, 5, 0o17, 10}
+	if len(code) != 1 || !slices.Equal(code[0].Code, want) {
+		t.Errorf("code:\nwant: %v\ngot:  %v", want, code)
+	}
+}
+
+func TestParser_RadixInvalid(tt *testing.T) {
+	tt.Parallel()
+	t := ParserHarness{T: tt}
+	parser := t.ParseStream(t.inputString(`
+        .ORIG x3000
+        .RADIX 8
+        .FILL 9
+        .RADIX 2
+        ADD R1,R1,2
+        .RADIX 16
+        .FILL 10000
+        .FILL 7
+`))
+
+	err := parser.Err()
+	if !errors.Is(err, ErrOperand) {
+		t.Fatalf("want: %v, got: %v", ErrOperand, err)
+	}
+
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 3 {
+		t.Errorf("errors: want: 3, got: %v", errs)
+	}
+
+	if size := parser.Syntax().Size(); size != 2 { // .ORIG and .FILL 7
+		t.Errorf("size: want: 2, got: %d", size)
+	}
+}
@@ -0,0 +1,22 @@
+;;; A messy program to be formatted.
+   .ORIG x3000
+        ;; Indented comment.
+count   .EQU   #3
+.MACRO push reg
+  add r6,r6,#-1
+      str reg ,r6, #0
+.ENDM
+
+start:    lea r0 , msg   ; Load message.
+  ld R1,COUNT_PTR// Loop counter.
+loop PUTS
+ add r1,r1 ,#-1
+         brp   loop
+    push r0
+a_very_long_label halt   ; Done.
+msg .STRINGZ "Hello, world!"   ; With a comma.
+COUNT_PTR	.FILL	count , x0
+buffer
+	.BLKW 2
+
+  .END
@@ -0,0 +1,12 @@
+;;; Code section.
+        .ORIG   x3000
+        LD      R0,DATA
+        HALT
+DATA    .FILL   x8000
+        .END
+
+;;; Data section.
+        .ORIG   x8000
+TABLE   .FILL   x2364
+        .FILL   x2365
+        .END
@@ -0,0 +1,8 @@
+;;; Sections overlap.
+        .ORIG   x3000
+        .BLKW   4
+        .END
+
+        .ORIG   x3002
+        .FILL   x2364
+        .END
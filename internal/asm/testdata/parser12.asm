@@ -0,0 +1,12 @@
+;;; Labels on the same line as directives.
+BEGIN   .ORIG   x3000
+WORDS   .FILL   x1, x2, x3
+BLOCK   .BLKW   4
+TEXT    .STRINGZ "abc"
+PACKED  .STRINGP "abcd"
+AFTER:  .FILL   BEGIN
+        .END
+
+NEXT    .ORIG   x4000
+        HALT
+        .END
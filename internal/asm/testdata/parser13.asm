@@ -0,0 +1,7 @@
+;;; Binary data included from a file.
+        .ORIG   x3000
+        LEA     R0,TABLE
+        HALT
+TABLE   .INCBIN "parser13.bin"
+AFTER   .FILL   TABLE
+        .END
@@ -1,3 +1,4 @@
+        .ORIG       x3000
 TEST    ADD         R3,R3,#-1
         LDR         R1,R2,#0
         BRnzp       TEST
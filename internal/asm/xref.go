@@ -0,0 +1,89 @@
+package asm
+
+// xref.go contains a cross-reference of the symbols in the syntax table.
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// CrossReference returns the locations of the operations that refer to each symbol, in ascending
+// order. Symbols that are defined but never referenced are not included; symbols that are referred
+// to but never defined are. An operation that refers to a symbol more than once, e.g. a .FILL
+// expression, is listed once.
+func (gen *Generator) CrossReference() map[string][]vm.Word {
+	xref := make(map[string][]vm.Word)
+
+	_ = gen.syntax.Walk(func(si *SourceInfo) error {
+		for _, sym := range references(unwrap(si)) {
+			xref[sym] = append(xref[sym], si.Loc)
+		}
+
+		return nil
+	})
+
+	for sym := range xref {
+		slices.Sort(xref[sym])
+		xref[sym] = slices.Compact(xref[sym])
+	}
+
+	return xref
+}
+
+// references returns the symbols an operation refers to. Like those in the symbol table, the names are
+// upper case.
+func references(oper Operation) []string {
+	var sym string
+
+	switch oper := oper.(type) {
+	case *BR:
+		sym = oper.SYMBOL
+	case *AND:
+		sym = oper.SYMBOL
+	case *LD:
+		sym = oper.SYMBOL
+	case *LDR:
+		sym = oper.SYMBOL
+	case *LEA:
+		sym = oper.SYMBOL
+	case *LDI:
+		sym = oper.SYMBOL
+	case *ST:
+		sym = oper.SYMBOL
+	case *STI:
+		sym = oper.SYMBOL
+	case *STR:
+		sym = oper.SYMBOL
+	case *JSR:
+		sym = oper.SYMBOL
+	case *ENTRY:
+		sym = oper.SYMBOL
+	case *TRAPVEC:
+		sym = oper.SYMBOL
+	case *FILL:
+		var syms []string
+
+		for _, expr := range oper.SYMBOL {
+			terms, err := parseExpression(expr)
+			if err != nil {
+				continue
+			}
+
+			for _, term := range terms {
+				if term.sym != "" {
+					syms = append(syms, strings.ToUpper(term.sym))
+				}
+			}
+		}
+
+		return syms
+	}
+
+	if sym == "" {
+		return nil
+	}
+
+	return []string{strings.ToUpper(sym)}
+}
@@ -67,14 +67,42 @@ func (cli *Commander) Execute(args []string) int {
 	fs := found.FlagSet()
 	args = args[1:]
 
+	verbose, quiet := cli.levelFlags(fs)
+
 	if err := fs.Parse(args); err != nil {
 		cli.log.Error("parse error", "err", err)
 		return 1
 	}
 
+	switch {
+	case *verbose && *quiet:
+		cli.log.Error("parse error", "err", "-v and -q are mutually exclusive")
+		return 1
+	case *verbose:
+		log.FixLevel(log.Debug)
+	case *quiet:
+		log.FixLevel(log.Warn)
+	}
+
 	return found.Run(cli.ctx, fs.Args(), os.Stdout, cli.log)
 }
 
+// levelFlags adds the flags shared by all commands that choose the log level: -v for debug logs and
+// -q for warnings and errors only. A command's own flag of the same name takes precedence.
+func (cli *Commander) levelFlags(fs *FlagSet) (verbose, quiet *bool) {
+	verbose, quiet = new(bool), new(bool)
+
+	if fs.Lookup("v") == nil {
+		fs.BoolVar(verbose, "v", false, "verbose: enable debug logging")
+	}
+
+	if fs.Lookup("q") == nil {
+		fs.BoolVar(quiet, "q", false, "quiet: log warnings and errors only")
+	}
+
+	return verbose, quiet
+}
+
 // WithCommands adds a list of commands as sub-commands.
 func (cli *Commander) WithCommands(cmds []Command) *Commander {
 	cli.commands = append([]Command(nil), cmds...)
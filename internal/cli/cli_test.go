@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+// testCommand is a command that records whether it ran.
+type testCommand struct {
+	ran bool
+}
+
+func (testCommand) FlagSet() *flag.FlagSet    { return flag.NewFlagSet("test", flag.ContinueOnError) }
+func (testCommand) Description() string       { return "test command" }
+func (testCommand) Usage(out io.Writer) error { return nil }
+func (cmd *testCommand) Run(context.Context, []string, io.Writer, *log.Logger) int {
+	cmd.ran = true
+	return 0
+}
+
+func TestCommander_Quiet(t *testing.T) {
+	prev := log.LogLevel.Level()
+	t.Cleanup(func() { log.LogLevel.Set(prev) })
+
+	log.LogLevel.Set(log.Debug)
+
+	cmd := &testCommand{}
+	result := New(context.Background()).
+		WithLogger(os.Stderr).
+		WithCommands([]Command{cmd}).
+		WithHelp(cmd).
+		Execute([]string{"test", "-q"})
+
+	if result != 0 || !cmd.ran {
+		t.Fatalf("execute: want: 0, got: %d", result)
+	}
+
+	if level := log.LogLevel.Level(); level < log.Warn {
+		t.Errorf("level: want: >= %s, got: %s", log.Warn, level)
+	}
+
+	// Commands' default levels do not override the flag.
+	log.SetDefaultLevel(log.Debug)
+
+	if level := log.LogLevel.Level(); level < log.Warn {
+		t.Errorf("default level: want: >= %s, got: %s", log.Warn, level)
+	}
+}
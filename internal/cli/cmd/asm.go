@@ -4,14 +4,20 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/smoynes/elsie/internal/asm"
 	"github.com/smoynes/elsie/internal/cli"
 	"github.com/smoynes/elsie/internal/log"
+	"github.com/smoynes/elsie/internal/monitor"
 )
 
 // Assembler is the command that translates LC3ASM source code into executable object code.
@@ -22,9 +28,17 @@ func Assembler() cli.Command {
 }
 
 type assembler struct {
-	log    bool
-	debug  bool
-	output string
+	log         bool
+	debug       bool
+	output      string
+	format      string
+	diagnostics string
+	pool        bool
+	traps       bool
+	lint        bool
+	header      bool
+	checksum    bool
+	optimize    bool
 }
 
 func (assembler) Description() string {
@@ -33,9 +47,33 @@ func (assembler) Description() string {
 
 func (assembler) Usage(out io.Writer) error {
 	var err error
-	_, err = fmt.Fprintln(out, `asm [-o file.o] file.asm
+	_, err = fmt.Fprintln(out, `asm [-o file.o] [-format hex|obj|bin] [-diagnostics text|json] [-pool] [-traps] [-lint] [-header] [-checksum] [-optimize] file.asm
 
-Assemble source into object code.`)
+Assemble source into object code.
+
+The default format, hex, is hex-encoded ASCII object code that may contain multiple sections. The
+obj and bin formats are compatible with other LC-3 tools: obj is binary object code and bin is
+ASCII binary, i.e. a word of '0' and '1' characters per line. Both support only a single section
+and also write a symbol file, named after the output file with a .sym extension. With -header, obj
+files begin with a header that identifies them as object code. With -checksum, obj files also end
+with a checksum of the origin and code that is verified when the file is loaded; it implies -header.
+The entry point of a program with an .ENTRY directive is recorded in hex files and in the header
+of obj files, so it, too, implies -header. bin files do not record it.
+
+With -diagnostics json, errors are written to standard output as a JSON array of objects with
+the fields: file, line, col, loc, message and kind.
+
+With -pool, LEA instructions with labels that are out of range are rewritten to load the label's
+address from a literal pool at the end of the section.
+
+With -traps, a warning is logged for each TRAP instruction whose vector is neither a system call
+of the default system image nor declared with a .TRAP directive.
+
+With -lint, warnings are logged for subroutines that fall through without returning and for tail
+calls, i.e. a JSR followed by RET.
+
+With -optimize, operations with no effect, e.g. a branch to the next instruction, are removed and
+each removal is logged.`)
 
 	return err
 }
@@ -45,6 +83,14 @@ func (a *assembler) FlagSet() *cli.FlagSet {
 	fs.BoolVar(&a.log, "log", false, "enable logging")
 	fs.BoolVar(&a.debug, "debug", false, "enable debug logging")
 	fs.StringVar(&a.output, "o", "a.o", "output `filename`")
+	fs.StringVar(&a.format, "format", "hex", "output `format`: hex, obj or bin")
+	fs.StringVar(&a.diagnostics, "diagnostics", "text", "error `format`: text or json")
+	fs.BoolVar(&a.pool, "pool", false, "use a literal pool for out-of-range LEA")
+	fs.BoolVar(&a.traps, "traps", false, "warn about undefined trap vectors")
+	fs.BoolVar(&a.lint, "lint", false, "warn about dubious calling conventions")
+	fs.BoolVar(&a.header, "header", false, "write obj files with a header")
+	fs.BoolVar(&a.checksum, "checksum", false, "write obj files with a header and checksum")
+	fs.BoolVar(&a.optimize, "optimize", false, "remove operations with no effect")
 
 	return fs
 }
@@ -52,9 +98,23 @@ func (a *assembler) FlagSet() *cli.FlagSet {
 // Run calls the assembler to assemble the assembly.
 func (a *assembler) Run(ctx context.Context, args []string, stdout io.Writer, logger *log.Logger) int {
 	if a.log {
-		log.LogLevel.Set(log.Info)
+		log.SetDefaultLevel(log.Info)
 	} else if a.debug {
-		log.LogLevel.Set(log.Debug)
+		log.SetDefaultLevel(log.Debug)
+	}
+
+	switch a.format {
+	case "hex", "obj", "bin":
+	default:
+		logger.Error("Unknown format", "format", a.format)
+		return 1
+	}
+
+	switch a.diagnostics {
+	case "text", "json":
+	default:
+		logger.Error("Unknown diagnostics format", "diagnostics", a.diagnostics)
+		return 1
 	}
 
 	// First pass: parse source and create symbol table.
@@ -80,7 +140,7 @@ func (a *assembler) Run(ctx context.Context, args []string, stdout io.Writer, lo
 	}
 
 	if parser.Err() != nil {
-		logger.Error("Parse error", "err", parser.Err())
+		a.report(stdout, logger, "Parse error", parser.Err())
 		return 1
 	}
 
@@ -93,21 +153,75 @@ func (a *assembler) Run(ctx context.Context, args []string, stdout io.Writer, lo
 	// Second pass: generate code.
 	symbols := parser.Symbols()
 	syntax := parser.Syntax()
-	generator := asm.NewGenerator(symbols, syntax)
+	opts := []asm.GeneratorOption(nil)
+
+	if a.pool {
+		opts = append(opts, asm.WithLiteralPool())
+	}
+
+	if a.header {
+		opts = append(opts, asm.WithObjectHeader())
+	}
+
+	if a.checksum {
+		opts = append(opts, asm.WithObjectChecksum())
+	}
+
+	if a.traps {
+		opts = append(opts, asm.WithTrapCheck(monitor.NewSystemImage(logger).TrapVectors()...))
+	}
+
+	generator := asm.NewGenerator(symbols, syntax, opts...)
+
+	if a.optimize {
+		var changes []asm.Warning
+
+		syntax, changes = generator.Optimize()
+
+		for _, change := range changes {
+			logger.Info("Optimized", "file", change.File, "line", change.Pos, "change", change.Msg)
+		}
+	}
+
 	buf := bufio.NewWriter(out)
 
-	logger.Debug("Writing object", "file", a.output)
+	logger.Debug("Writing object", "file", a.output, "format", a.format)
 
-	objCode, err := generator.Encode()
-	if err != nil {
-		logger.Error("Compile error", "out", a.output, "err", err)
-		return -1
+	var wrote int64
+
+	switch a.format {
+	case "obj":
+		wrote, err = generator.WriteTo(buf)
+	case "bin":
+		wrote, err = generator.WriteBinary(buf)
+	default:
+		var objCode []byte
+
+		objCode, err = generator.Encode()
+		if err == nil {
+			wrote, err = io.Copy(buf, bytes.NewBuffer(objCode))
+		}
 	}
 
-	wrote, err := io.Copy(buf, bytes.NewBuffer(objCode))
-	if err != nil {
+	var ioErr *fs.PathError
+
+	if errors.As(err, &ioErr) {
 		logger.Error("I/O error", "out", a.output, "err", err)
 		return -1
+	} else if err != nil {
+		a.report(stdout, logger, "Compile error", err)
+		return -1
+	}
+
+	warnings := append([]asm.Warning{}, parser.Warnings()...)
+	warnings = append(warnings, generator.Warnings()...)
+
+	if a.lint {
+		warnings = append(warnings, generator.Lint()...)
+	}
+
+	for _, warning := range warnings {
+		logger.Warn("Compile warning", "file", warning.File, "warning", warning)
 	}
 
 	logger.Debug("Wrote object", "file", a.output, "size", wrote)
@@ -117,6 +231,17 @@ func (a *assembler) Run(ctx context.Context, args []string, stdout io.Writer, lo
 		return -1
 	}
 
+	if a.format != "hex" {
+		symFile := strings.TrimSuffix(a.output, filepath.Ext(a.output)) + ".sym"
+
+		if err := a.writeSymbols(symFile, generator); err != nil {
+			logger.Error("I/O error", "out", symFile, "err", err)
+			return -1
+		}
+
+		logger.Debug("Wrote symbols", "file", symFile)
+	}
+
 	logger.Info("Compiled object",
 		"out", a.output,
 		"size", wrote,
@@ -126,3 +251,103 @@ func (a *assembler) Run(ctx context.Context, args []string, stdout io.Writer, lo
 
 	return 0
 }
+
+// writeSymbols writes the generator's symbol table to a file.
+func (a *assembler) writeSymbols(filename string, generator *asm.Generator) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err := generator.WriteSymbolTable(out); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// report writes errors either as logs or, if configured, as JSON diagnostics.
+func (a *assembler) report(stdout io.Writer, logger *log.Logger, msg string, err error) {
+	if a.diagnostics != "json" {
+		logger.Error(msg, "out", a.output, "err", err)
+		return
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(diagnose(err)); err != nil {
+		logger.Error("I/O error", "err", err)
+	}
+}
+
+// diagnostic is a structured representation of an assembler error.
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    uint16 `json:"line"`
+	Col     uint16 `json:"col"`
+	Loc     uint16 `json:"loc"`
+	Message string `json:"message"`
+	Kind    string `json:"kind"`
+}
+
+// diagnose flattens joined errors into a list of diagnostics.
+func diagnose(err error) []diagnostic {
+	diags := []diagnostic{}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+		for _, err := range joined.Unwrap() {
+			diags = append(diags, diagnose(err)...)
+		}
+
+		return diags
+	}
+
+	diag := diagnostic{
+		Message: err.Error(),
+		Kind:    errorKind(err),
+	}
+
+	if se := (*asm.SyntaxError)(nil); errors.As(err, &se) {
+		diag.File = se.File
+		diag.Line = uint16(se.Pos)
+		diag.Col = uint16(se.Col)
+		diag.Loc = uint16(se.Loc)
+
+		if se.Err != nil {
+			diag.Message = se.Err.Error()
+		}
+	}
+
+	return append(diags, diag)
+}
+
+// errorKind returns the name of the most specific type of assembler error.
+func errorKind(err error) string {
+	var (
+		offsetErr   *asm.OffsetRangeError
+		registerErr *asm.RegisterError
+		symbolErr   *asm.SymbolError
+		dupErr      *asm.DuplicateSymbolError
+		literalErr  *asm.LiteralRangeError
+		syntaxErr   *asm.SyntaxError
+	)
+
+	switch {
+	case errors.As(err, &offsetErr):
+		return "OffsetRangeError"
+	case errors.As(err, &registerErr):
+		return "RegisterError"
+	case errors.As(err, &symbolErr):
+		return "SymbolError"
+	case errors.As(err, &dupErr):
+		return "DuplicateSymbolError"
+	case errors.As(err, &literalErr):
+		return "LiteralRangeError"
+	case errors.As(err, &syntaxErr):
+		return "SyntaxError"
+	default:
+		return "Error"
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+func TestAssembler_Diagnostics(t *testing.T) {
+	tcs := []struct {
+		name   string
+		source string
+		want   []diagnostic
+	}{
+		{
+			name: "parse errors",
+			source: `.ORIG x3000
+  ADD R1,R1,#-100
+  AND R1,R1
+  HALT
+`,
+			want: []diagnostic{
+				{Line: 2, Col: 13, Kind: "LiteralRangeError"},
+				{Line: 3, Col: 3, Kind: "SyntaxError"},
+			},
+		},
+		{
+			name: "symbol error",
+			source: `.ORIG x3000
+  LD R0,MISSING
+`,
+			want: []diagnostic{{Line: 2, Loc: 0x3000, Kind: "SymbolError"}},
+		},
+		{
+			name: "offset error",
+			source: `.ORIG x3000
+  LD R0,FAR
+  .BLKW 512
+FAR .FILL x1
+`,
+			want: []diagnostic{{Line: 2, Loc: 0x3000, Kind: "OffsetRangeError"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "test.asm")
+
+			if err := os.WriteFile(src, []byte(tc.source), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := Assembler()
+			fs := cmd.FlagSet()
+
+			if err := fs.Parse([]string{"-diagnostics", "json", "-o", filepath.Join(dir, "a.o")}); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := bytes.Buffer{}
+			logger := log.NewFormattedLogger(io.Discard)
+
+			if code := cmd.Run(context.Background(), []string{src}, &stdout, logger); code == 0 {
+				t.Fatalf("expected failure, got: %d", code)
+			}
+
+			var got []diagnostic
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON: %s: %q", err, stdout.String())
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("want: %d diagnostics, got: %+v", len(tc.want), got)
+			}
+
+			for i, want := range tc.want {
+				diag := got[i]
+
+				switch {
+				case diag.File != src:
+					t.Errorf("%d: file: want: %q, got: %q", i, src, diag.File)
+				case diag.Kind != want.Kind:
+					t.Errorf("%d: kind: want: %s, got: %s", i, want.Kind, diag.Kind)
+				case diag.Line != want.Line:
+					t.Errorf("%d: line: want: %d, got: %d", i, want.Line, diag.Line)
+				case want.Col != 0 && diag.Col != want.Col:
+					t.Errorf("%d: col: want: %d, got: %d", i, want.Col, diag.Col)
+				case want.Loc != 0 && diag.Loc != want.Loc:
+					t.Errorf("%d: loc: want: %#04x, got: %#04x", i, want.Loc, diag.Loc)
+				case diag.Message == "":
+					t.Errorf("%d: empty message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestAssembler_DiagnosticsUnknown(t *testing.T) {
+	dir := t.TempDir()
+	cmd := Assembler()
+	fs := cmd.FlagSet()
+
+	if err := fs.Parse([]string{"-diagnostics", "xml", "-o", filepath.Join(dir, "a.hex")}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewFormattedLogger(io.Discard)
+	args := []string{filepath.Join("..", "..", "asm", "testdata", "parser6.asm")}
+
+	if code := cmd.Run(context.Background(), args, io.Discard, logger); code != 1 {
+		t.Errorf("exit code: want: 1, got: %d", code)
+	}
+}
+
+func TestAssembler_Format(t *testing.T) {
+	for _, format := range []string{"obj", "bin"} {
+		format := format
+
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			output := filepath.Join(dir, "prog."+format)
+
+			cmd := Assembler()
+			fs := cmd.FlagSet()
+
+			if err := fs.Parse([]string{"-format", format, "-o", output}); err != nil {
+				t.Fatal(err)
+			}
+
+			logger := log.NewFormattedLogger(io.Discard)
+			args := []string{filepath.Join("..", "..", "asm", "testdata", "parser6.asm")}
+
+			if code := cmd.Run(context.Background(), args, io.Discard, logger); code != 0 {
+				t.Fatalf("exit code: %d", code)
+			}
+
+			for _, fn := range []string{output, filepath.Join(dir, "prog.sym")} {
+				if info, err := os.Stat(fn); err != nil {
+					t.Error(err)
+				} else if info.Size() == 0 {
+					t.Errorf("%s: empty", fn)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/smoynes/elsie/internal/cli"
+	"github.com/smoynes/elsie/internal/log"
+	"github.com/smoynes/elsie/internal/monitor"
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// Debugger is the command that runs a program interactively, one command at a time.
+//
+//	elsie debug program.bin
+func Debugger() cli.Command {
+	return &debugger{
+		in: os.Stdin,
+	}
+}
+
+type debugger struct {
+	in io.Reader // Command input.
+
+	machine *vm.LC3
+	breaks  map[vm.Word]struct{}
+}
+
+// maxContinue limits the number of instructions executed by a single continue command, lest a
+// program loop forever.
+const maxContinue = 1_000_000
+
+// journalDepth is the number of steps that may be undone.
+const journalDepth = 1_000
+
+func (debugger) Description() string {
+	return "run a program in the debugger"
+}
+
+func (debugger) Usage(out io.Writer) error {
+	var err error
+	_, err = fmt.Fprintln(out, `debug program.bin
+
+Loads an executable and reads debugger commands from standard input:
+
+  step [n]        execute one or n instructions
+  back [n]        undo one or n instructions
+  continue        execute until a breakpoint or the machine halts
+  break ADDR      set a breakpoint at an address
+  regs            print the registers
+  mem START END   print memory from START to END, inclusive
+  disasm ADDR     disassemble the instruction at an address
+  quit            exit the debugger
+
+Addresses are hexadecimal, e.g. x3000.`)
+
+	return err
+}
+
+func (d *debugger) FlagSet() *cli.FlagSet {
+	return flag.NewFlagSet("debug", flag.ExitOnError)
+}
+
+// Run loads the program and runs the debugger's command loop.
+func (d *debugger) Run(ctx context.Context, args []string, stdout io.Writer, logger *log.Logger) int {
+	if len(args) == 0 {
+		logger.Error("Missing object-code argument. Run elsie help debug for usage.")
+		return -1
+	}
+
+	code, err := executor{logger: logger}.loadCode(args[0])
+	if err != nil {
+		logger.Error("Error loading code", "err", err)
+		return -1
+	}
+
+	d.breaks = make(map[vm.Word]struct{})
+	d.machine = vm.New(
+		vm.WithLogger(logger),
+		vm.WithJournal(journalDepth),
+		monitor.WithDefaultSystemImage(),
+		vm.WithDisplayListener(func(char uint16) {
+			fmt.Fprintf(stdout, "%c", rune(char))
+		}),
+	)
+
+	loader := vm.NewLoader(d.machine)
+
+	if _, err := loader.LoadAll(code); err != nil {
+		logger.Error("Error loading code", "err", err)
+		return 1
+	}
+
+	d.status(stdout)
+
+	lines := bufio.NewScanner(d.in)
+
+	for {
+		fmt.Fprint(stdout, "> ")
+
+		if !lines.Scan() {
+			break
+		}
+
+		fields := strings.Fields(lines.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		quit, err := d.command(ctx, stdout, fields[0], fields[1:])
+		if err != nil {
+			fmt.Fprintf(stdout, "error: %s\n", err)
+		}
+
+		if quit {
+			return 0
+		}
+	}
+
+	fmt.Fprintln(stdout)
+
+	if err := lines.Err(); err != nil {
+		logger.Error("Error reading commands", "err", err)
+		return 1
+	}
+
+	return 0
+}
+
+// command executes a single debugger command. It returns true if the debugger should quit.
+func (d *debugger) command(ctx context.Context, out io.Writer, cmd string, args []string,
+) (bool, error) {
+	switch cmd {
+	case "step", "s":
+		n := 1
+
+		if len(args) > 0 {
+			var err error
+
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return false, fmt.Errorf("step: invalid count: %s", args[0])
+			}
+		}
+
+		return false, d.run(ctx, out, n)
+	case "back":
+		n := 1
+
+		if len(args) > 0 {
+			var err error
+
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return false, fmt.Errorf("back: invalid count: %s", args[0])
+			}
+		}
+
+		defer d.status(out)
+
+		for i := 0; i < n; i++ {
+			if err := d.machine.StepBack(); err != nil {
+				return false, fmt.Errorf("back: %w", err)
+			}
+		}
+	case "continue", "c":
+		return false, d.run(ctx, out, maxContinue)
+	case "break", "b":
+		if len(args) != 1 {
+			return false, errors.New("break: expected address")
+		}
+
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return false, fmt.Errorf("break: %w", err)
+		}
+
+		d.breaks[addr] = struct{}{}
+		fmt.Fprintf(out, "breakpoint at %s\n", addr)
+	case "regs", "r":
+		fmt.Fprintln(out, d.machine.String())
+		fmt.Fprint(out, d.machine.REG.String())
+	case "mem", "m":
+		if len(args) != 2 {
+			return false, errors.New("mem: expected start and end addresses")
+		}
+
+		start, err := parseAddress(args[0])
+		if err != nil {
+			return false, fmt.Errorf("mem: %w", err)
+		}
+
+		end, err := parseAddress(args[1])
+		if err != nil {
+			return false, fmt.Errorf("mem: %w", err)
+		} else if end < start || end >= vm.IOPageAddr {
+			return false, fmt.Errorf("mem: invalid range: %s-%s", start, end)
+		}
+
+		d.dumpMemory(out, start, end)
+	case "disasm", "d":
+		if len(args) != 1 {
+			return false, errors.New("disasm: expected address")
+		}
+
+		addr, err := parseAddress(args[0])
+		if err != nil {
+			return false, fmt.Errorf("disasm: %w", err)
+		} else if addr >= vm.IOPageAddr {
+			return false, fmt.Errorf("disasm: invalid address: %s", addr)
+		}
+
+		word := d.machine.Mem.View()[addr]
+		fmt.Fprintf(out, "%s: %s  %s\n", addr, word, vm.Instruction(word).Disassemble())
+	case "quit", "q":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown command: %s", cmd)
+	}
+
+	return false, nil
+}
+
+// run steps the machine up to n times, stopping early at a breakpoint, if the machine halts or if
+// an error occurs. The status of the machine is printed afterwards.
+func (d *debugger) run(ctx context.Context, out io.Writer, n int) error {
+	defer d.status(out)
+
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := d.machine.Step()
+
+		if errors.Is(err, vm.ErrHalted) {
+			fmt.Fprintln(out, "halted")
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if _, ok := d.breaks[vm.Word(d.machine.PC)]; ok {
+			fmt.Fprintf(out, "breakpoint at %s\n", vm.Word(d.machine.PC))
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// status prints the program counter and the next instruction.
+func (d *debugger) status(out io.Writer) {
+	pc := vm.Word(d.machine.PC)
+
+	if pc >= vm.IOPageAddr {
+		fmt.Fprintf(out, "PC: %s\n", pc)
+		return
+	}
+
+	word := d.machine.Mem.View()[pc]
+	fmt.Fprintf(out, "PC: %s  %s  %s\n", pc, word, vm.Instruction(word).Disassemble())
+}
+
+// dumpMemory prints memory from start to end, inclusive, eight words per line.
+func (d *debugger) dumpMemory(out io.Writer, start, end vm.Word) {
+	view := d.machine.Mem.View()
+
+	for addr := start; addr <= end; addr++ {
+		if (addr-start)%8 == 0 {
+			if addr != start {
+				fmt.Fprintln(out)
+			}
+
+			fmt.Fprintf(out, "%s:", addr)
+		}
+
+		fmt.Fprintf(out, " %s", view[addr])
+
+		if addr == end {
+			break
+		}
+	}
+
+	fmt.Fprintln(out)
+}
+
+// parseAddress parses a hexadecimal address, e.g. x3000 or 0x3000.
+func parseAddress(s string) (vm.Word, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "0"), "x")
+
+	addr, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	return vm.Word(addr), nil
+}
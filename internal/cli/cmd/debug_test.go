@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+func TestDebugger(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "prog.asm")
+	obj := filepath.Join(dir, "prog.bin")
+
+	source := `.ORIG x3000
+  ADD R1,R1,#5
+  ADD R1,R1,#1
+  HALT
+.END
+`
+	if err := os.WriteFile(src, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewFormattedLogger(io.Discard)
+	asm := Assembler()
+
+	if err := asm.FlagSet().Parse([]string{"-o", obj}); err != nil {
+		t.Fatal(err)
+	} else if code := asm.Run(context.Background(), []string{src}, io.Discard, logger); code != 0 {
+		t.Fatalf("assembler exit code: %d", code)
+	}
+
+	script := strings.NewReader(`break x3001
+continue
+regs
+step
+regs
+back
+regs
+step
+disasm x3000
+mem x3000 x3002
+bogus
+quit
+`)
+
+	debug := &debugger{in: script}
+	out := strings.Builder{}
+
+	if code := debug.Run(context.Background(), []string{obj}, &out, logger); code != 0 {
+		t.Fatalf("exit code: %d", code)
+	}
+
+	got := out.String()
+
+	for _, want := range []string{
+		"PC: 0x3000  0x1265  ADD R1,R1,#5",
+		"breakpoint at 0x3001",
+		"PC: 0x3001  0x1261  ADD R1,R1,#1",
+		"R1:  0x0005",
+		"PC: 0x3002  0xf025  TRAP x25",
+		"R1:  0x0006",
+		"0x3000: 0x1265  ADD R1,R1,#5",
+		"0x3000: 0x1265 0x1261 0xf025",
+		"error: unknown command: bogus",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing output: %q\n%s", want, got)
+		}
+	}
+
+	// Stepping back restores the register.
+	if strings.Index(got, "R1:  0x0006") > strings.LastIndex(got, "R1:  0x0005") {
+		t.Errorf("back: register not restored:\n%s", got)
+	}
+}
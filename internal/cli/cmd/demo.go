@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/smoynes/elsie/internal/cli"
@@ -24,8 +26,9 @@ func Demo() cli.Command {
 }
 
 type demo struct {
-	log   bool
-	debug bool
+	log       bool
+	debug     bool
+	dashboard bool
 }
 
 func (demo) Description() string {
@@ -34,9 +37,10 @@ func (demo) Description() string {
 
 func (d demo) Usage(out io.Writer) error {
 	var err error
-	_, err = fmt.Fprintln(out, `demo [ -log | -debug ]
+	_, err = fmt.Fprintln(out, `demo [ -log | -debug ] [ -dashboard ]
 
-Run demonstration program.`)
+Run demonstration program. With -dashboard, the machine's registers, status flags and run state are
+redrawn after each instruction at the top of the terminal, above the program's output.`)
 
 	return err
 }
@@ -46,6 +50,7 @@ func (d *demo) FlagSet() *cli.FlagSet {
 
 	fs.BoolVar(&d.log, "log", false, "log execution state")
 	fs.BoolVar(&d.debug, "debug", false, "verbose execution state")
+	fs.BoolVar(&d.dashboard, "dashboard", false, "display a live view of the machine")
 
 	return fs
 }
@@ -68,8 +73,10 @@ func (d demo) Run(ctx context.Context, args []string, out io.Writer, _ *log.Logg
 	// Use a channel to send displayed values to a background thread.
 	dispCh := make(chan uint16)
 
-	// Create virtual machine.
-	machine := vm.New(
+	// The display thread and the dashboard both write to the output.
+	out = &syncWriter{out: out}
+
+	opts := []vm.OptionFn{
 		// Use default BIOS.
 		monitor.WithDefaultSystemImage(),
 
@@ -80,7 +87,23 @@ func (d demo) Run(ctx context.Context, args []string, out io.Writer, _ *log.Logg
 		vm.WithDisplayListener(func(displayed uint16) {
 			dispCh <- displayed
 		}),
-	)
+	}
+
+	// Redraw the dashboard after each step. Program output scrolls in the region below it.
+	if d.dashboard {
+		_, _ = fmt.Fprintf(out, clearScreen+scrollRegion+moveCursor, dashboardLines+2, dashboardLines+2)
+
+		defer func() {
+			_, _ = io.WriteString(out, resetScroll)
+		}()
+
+		opts = append(opts, vm.WithStepListener(func(_ vm.Word, machine *vm.LC3) {
+			_ = drawDashboard(out, machine)
+		}))
+	}
+
+	// Create virtual machine.
+	machine := vm.New(opts...)
 
 	logger.Info("Loading program")
 
@@ -126,7 +149,7 @@ func (d demo) Run(ctx context.Context, args []string, out io.Writer, _ *log.Logg
 			select {
 			case disp := <-dispCh:
 				r := rune(disp)
-				fmt.Printf("%c", r)
+				_, _ = fmt.Fprintf(out, "%c", r)
 				<-timer.C
 			case <-ctx.Done():
 				return
@@ -177,12 +200,94 @@ func (d demo) configureLogger(out io.Writer) *log.Logger {
 
 	switch {
 	case d.debug == true:
-		log.LogLevel.Set(log.Debug)
+		log.SetDefaultLevel(log.Debug)
 	case d.log == true:
-		log.LogLevel.Set(log.Info)
+		log.SetDefaultLevel(log.Info)
 	default:
-		log.LogLevel.Set(log.Error)
+		log.SetDefaultLevel(log.Error)
 	}
 
 	return logger
 }
+
+// Terminal escape sequences used to draw the dashboard.
+const (
+	clearScreen   = "\033[H\033[2J" // Move the cursor home and clear the screen.
+	clearLine     = "\033[K"        // Clear from the cursor to the end of the line.
+	saveCursor    = "\0337"         // Save the cursor position.
+	restoreCursor = "\0338"         // Restore the saved cursor position.
+	scrollRegion  = "\033[%d;r"     // Scroll only from a line to the bottom of the screen.
+	moveCursor    = "\033[%d;1H"    // Move the cursor to the start of a line.
+	resetScroll   = "\033[r"        // Scroll the whole screen.
+)
+
+// dashboardLines is the number of lines in a frame of the dashboard.
+const dashboardLines = 7
+
+// syncWriter serialises writes to an output stream that is shared by goroutines.
+type syncWriter struct {
+	mut sync.Mutex
+	out io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	return w.out.Write(p)
+}
+
+// drawDashboard redraws the dashboard at the top of the terminal and returns the cursor to where it
+// was, so that the program's output is not overwritten. The frame is written in a single write so
+// that it is not interleaved with other output.
+func drawDashboard(out io.Writer, machine *vm.LC3) error {
+	var frame strings.Builder
+
+	if err := renderDashboard(&frame, machine); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, saveCursor+"\033[H"+
+		strings.ReplaceAll(frame.String(), "\n", clearLine+"\n")+restoreCursor)
+
+	return err
+}
+
+// renderDashboard writes a frame of the demo's dashboard: the program counter, the run state, the
+// status register and its flags, and the general purpose registers.
+func renderDashboard(out io.Writer, machine *vm.LC3) error {
+	var (
+		psr   = machine.PSR
+		flags = []byte("---")
+	)
+
+	if psr.Negative() {
+		flags[0] = 'N'
+	}
+
+	if psr.Zero() {
+		flags[1] = 'Z'
+	}
+
+	if psr.Positive() {
+		flags[2] = 'P'
+	}
+
+	privilege := "SYS"
+	if psr.Privilege() == vm.PrivilegeUser {
+		privilege = "USR"
+	}
+
+	_, err := fmt.Fprintf(out,
+		"PC:  %s  IR: %s  MCR: %s\n"+
+			"PSR: %s\n"+
+			"     [%s] %s PL%d\n"+
+			"%s",
+		machine.PC, vm.Word(machine.IR), machine.MCR.String(),
+		psr.String(),
+		flags, privilege, psr.Priority(),
+		machine.REG.String(),
+	)
+
+	return err
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+func TestDemo_Dashboard(t *testing.T) {
+	var buf bytes.Buffer
+
+	machine := vm.New(vm.WithLogger(log.NewFormattedLogger(io.Discard)))
+	machine.PC = 0x3000
+	machine.PSR = vm.StatusUser | vm.StatusNormal | vm.StatusZero
+
+	if err := renderDashboard(&buf, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := buf.String()
+
+	for _, want := range []string{"PC:  0x3000", "[-Z-] USR PL3", "(RUN)", "R7:"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame: want: %q, got:\n%s", want, frame)
+		}
+	}
+
+	if lines := strings.Count(frame, "\n"); lines != dashboardLines {
+		t.Errorf("lines: want: %d, got: %d", dashboardLines, lines)
+	}
+
+	buf.Reset()
+
+	if err := drawDashboard(&buf, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	// The frame is redrawn in place, leaving the program's output and the cursor where they were.
+	drawn := buf.String()
+
+	if !strings.HasPrefix(drawn, saveCursor) || !strings.HasSuffix(drawn, restoreCursor) {
+		t.Errorf("want: cursor saved and restored, got: %q", drawn)
+	} else if strings.Contains(drawn, clearScreen) {
+		t.Errorf("want: screen not cleared, got: %q", drawn)
+	}
+}
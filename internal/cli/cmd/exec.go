@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/smoynes/elsie/internal/asm"
 	"github.com/smoynes/elsie/internal/cli"
 	"github.com/smoynes/elsie/internal/encoding"
 	"github.com/smoynes/elsie/internal/log"
@@ -29,6 +33,10 @@ type executor struct {
 	logger *log.Logger // Log destination
 	log    string      // Log output path
 	debug  string      // Debug log path
+	trace  string      // Execution trace path
+	start  string      // Start address override
+	syms   string      // Symbol table path
+	labels labels      // Symbolic names of addresses
 }
 
 func (executor) Description() string {
@@ -37,9 +45,11 @@ func (executor) Description() string {
 
 func (executor) Usage(out io.Writer) error {
 	var err error
-	_, err = fmt.Fprintln(out, `exec program.bin
+	_, err = fmt.Fprintln(out, `exec [-symbols program.sym] program.bin
 
-Runs an executable in the emulator.`)
+Runs an executable in the emulator. Execution begins at the program's entry point, its origin, or
+the -start address, if given. If a symbol table is given, e.g. one written by the assembler, the
+execution trace and errors show symbols instead of addresses.`)
 
 	return err
 }
@@ -49,6 +59,9 @@ func (ex *executor) FlagSet() *cli.FlagSet {
 
 	fs.StringVar(&ex.log, "log", "", "write log to `file`")
 	fs.StringVar(&ex.debug, "debug", "", "write debug log `file`")
+	fs.StringVar(&ex.trace, "trace", "", "append CSV execution trace to `file`")
+	fs.StringVar(&ex.start, "start", "", "begin execution at `address`, e.g. x3000")
+	fs.StringVar(&ex.syms, "symbols", "", "read symbol table from `file`")
 
 	return fs
 }
@@ -68,6 +81,13 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 		return -1
 	}
 
+	if ex.syms != "" {
+		if ex.labels, err = loadLabels(ex.syms); err != nil {
+			logger.Error("Error loading symbols", "err", err)
+			return -1
+		}
+	}
+
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(context.Canceled)
 
@@ -102,7 +122,7 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 
 	ex.logger = log.NewFormattedLogger(logFile)
 	log.SetDefault(ex.logger)
-	log.LogLevel.Set(logLevel)
+	log.SetDefaultLevel(logLevel)
 
 	ex.logger.Debug("Initializing machine")
 	logger.Debug("Initializing machine")
@@ -113,23 +133,40 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 		return 1
 	}
 
-	machine := vm.New(
+	opts := []vm.OptionFn{
 		vm.WithLogger(ex.logger),
 		monitor.WithDefaultSystemImage(),
 		console.WithTerminal(ctx),
-	)
+	}
 
-	loader := vm.NewLoader(machine)
-	count := uint16(0)
+	var tracer *traceWriter
 
-	for i := range code {
-		n, err := loader.Load(code[i])
-		count += n
+	if ex.trace != "" {
+		var traceFile *os.File
 
+		traceFile, tracer, err = openTrace(ex.trace)
 		if err != nil {
-			ex.logger.Error(err.Error())
-			return 1
+			console.Restore()
+			logger.Error(err.Error())
+
+			return -1
 		}
+
+		defer traceFile.Close()
+
+		tracer.labels = ex.labels
+		opts = append(opts, vm.WithStepListener(tracer.step))
+	}
+
+	machine := vm.New(opts...)
+
+	count, err := ex.load(machine, code)
+	if err != nil {
+		console.Restore()
+		ex.logger.Error(err.Error())
+		logger.Error(err.Error())
+
+		return 1
 	}
 
 	ex.logger.Debug("Loaded program", "file", args[0], "loaded", count)
@@ -139,12 +176,18 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 
 		err := machine.Run(ctx)
 
+		if tracer != nil {
+			if err := tracer.Flush(); err != nil {
+				ex.logger.Error("Error writing trace", "err", err)
+			}
+		}
+
 		switch {
 		case errors.Is(err, context.DeadlineExceeded):
 			ex.logger.Warn("Exec timeout")
 			return
 		case err != nil:
-			ex.logger.Error(err.Error())
+			ex.logger.Error(err.Error(), ex.errorLabel(err)...)
 			cancel(err)
 
 			return
@@ -167,8 +210,8 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 		logger.Debug("Program completed")
 		return 0
 	} else if err != nil {
-		ex.logger.Error("Program error", "ERR", err)
-		logger.Error("Program error", "ERR", err)
+		ex.logger.Error("Program error", append([]any{"ERR", err}, ex.errorLabel(err)...)...)
+		logger.Error("Program error", append([]any{"ERR", err}, ex.errorLabel(err)...)...)
 		return 2
 	} else {
 		ex.logger.Info("Terminated")
@@ -177,6 +220,28 @@ func (ex *executor) Run(ctx context.Context, args []string, stdout io.Writer, lo
 	}
 }
 
+// load loads code into the machine and, if a start address was given, sets the program counter to
+// it. The start address must not be in the I/O page; a warning is logged if it is in system space.
+func (ex *executor) load(machine *vm.LC3, code []vm.ObjectCode) (uint16, error) {
+	count, err := vm.NewLoader(machine).LoadAll(code)
+	if err != nil || ex.start == "" {
+		return count, err
+	}
+
+	addr, err := parseAddress(ex.start)
+	if err != nil {
+		return count, fmt.Errorf("start: %w", err)
+	} else if addr >= vm.IOPageAddr {
+		return count, fmt.Errorf("start: invalid address: %s", addr)
+	} else if addr < vm.UserSpaceAddr {
+		ex.logger.Warn("Start address is in system space", "addr", addr)
+	}
+
+	machine.PC = vm.ProgramCounter(addr)
+
+	return count, nil
+}
+
 func (ex executor) loadCode(fn string) ([]vm.ObjectCode, error) {
 	ex.logger.Debug("Loading executable", "file", fn)
 
@@ -202,3 +267,123 @@ func (ex executor) loadCode(fn string) ([]vm.ObjectCode, error) {
 
 	return hex.Code, nil
 }
+
+// errorLabel returns log attributes with the symbolic address of a failing instruction, if any.
+func (ex *executor) errorLabel(err error) []any {
+	var execErr *vm.ExecError
+
+	if ex.labels == nil || !errors.As(err, &execErr) {
+		return nil
+	}
+
+	return []any{"SYMBOL", ex.labels.address(execErr.PC)}
+}
+
+// labels maps addresses to symbol names.
+type labels map[vm.Word]string
+
+// loadLabels reads a symbol table file. If several symbols have the same address, the first in
+// alphabetical order names it.
+func loadLabels(fn string) (labels, error) {
+	file, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	symbols, err := asm.ReadSymbolTable(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return symbols.Labels(), nil
+}
+
+// address returns the symbol naming an address or, if there is none, the address itself.
+func (l labels) address(addr vm.Word) string {
+	if name, ok := l[addr]; ok {
+		return name
+	}
+
+	return addr.String()
+}
+
+// traceWriter writes an execution trace as CSV: one row per executed instruction with the step
+// number, the instruction's address, its encoding and mnemonic, and the general-purpose registers
+// after it executed. If the writer has labels, addresses are shown as symbols. Rows are buffered and
+// flushed when the machine halts.
+type traceWriter struct {
+	csv    *csv.Writer
+	labels labels
+	steps  uint64
+	err    error
+}
+
+// newTraceWriter creates a trace writer and, if header is true, writes the header row.
+func newTraceWriter(out io.Writer, header bool) *traceWriter {
+	tw := &traceWriter{csv: csv.NewWriter(out)}
+
+	if header {
+		tw.write([]string{"step", "pc", "ir", "mnemonic", "r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7"})
+	}
+
+	return tw
+}
+
+// openTrace opens a trace file for appending and creates a trace writer for it. The header row is
+// written only if the file is empty, so that a file with several traces has a single header.
+func openTrace(name string) (*os.File, *traceWriter, error) {
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return file, newTraceWriter(file, info.Size() == 0), nil
+}
+
+// step is a step listener that records an executed instruction.
+func (tw *traceWriter) step(pc vm.Word, machine *vm.LC3) {
+	tw.steps++
+
+	mnemonic, _, _ := strings.Cut(machine.IR.Disassemble(), " ")
+	row := []string{
+		strconv.FormatUint(tw.steps, 10),
+		tw.labels.address(pc),
+		vm.Word(machine.IR).String(),
+		mnemonic,
+	}
+
+	for _, reg := range machine.REG {
+		row = append(row, vm.Word(reg).String())
+	}
+
+	tw.write(row)
+
+	if !machine.MCR.Running() {
+		_ = tw.Flush()
+	}
+}
+
+func (tw *traceWriter) write(row []string) {
+	if tw.err == nil {
+		tw.err = tw.csv.Write(row)
+	}
+}
+
+// Flush writes buffered rows and returns the first error, if any.
+func (tw *traceWriter) Flush() error {
+	tw.csv.Flush()
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.csv.Error()
+}
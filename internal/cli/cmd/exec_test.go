@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+func TestExecutor_Trace(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		tracer = newTraceWriter(&buf, true)
+	)
+
+	machine := vm.New(
+		vm.WithLogger(log.NewFormattedLogger(io.Discard)),
+		vm.WithStepListener(tracer.step),
+	)
+
+	code := vm.ObjectCode{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			0x5260, // AND R1,R1,#0
+			0x1265, // ADD R1,R1,#5
+			0x14a1, // ADD R2,R2,#1
+		},
+	}
+
+	if _, err := vm.NewLoader(machine).Load(code); err != nil {
+		t.Fatal(err)
+	}
+
+	machine.REG[vm.R2] = 0x0010
+
+	if err := machine.RunN(context.Background(), 3); !errors.Is(err, vm.ErrStepLimit) {
+		t.Fatalf("want: %v, got: %v", vm.ErrStepLimit, err)
+	}
+
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 4 {
+		t.Fatalf("want: 4 rows, got: %d: %v", len(rows), rows)
+	}
+
+	header, last := rows[0], rows[3]
+
+	if header[0] != "step" || header[3] != "mnemonic" || header[11] != "r7" {
+		t.Errorf("header: %v", header)
+	}
+
+	want := []string{"3", "0x3002", "0x14a1", "ADD"}
+
+	for i := range want {
+		if last[i] != want[i] {
+			t.Errorf("column %s: want: %s, got: %s", header[i], want[i], last[i])
+		}
+	}
+
+	if r1 := last[5]; r1 != "0x0005" {
+		t.Errorf("R1: want: 0x0005, got: %s", r1)
+	}
+
+	if r2 := last[6]; r2 != "0x0011" {
+		t.Errorf("R2: want: 0x0011, got: %s", r2)
+	}
+}
+
+func TestExecutor_TraceAppend(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "trace.csv")
+
+	// Append two traces of one row each to the same file.
+	for i := 0; i < 2; i++ {
+		file, tracer, err := openTrace(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tracer.write([]string{"1", "0x3000", "0xf025", "TRAP", "", "", "", "", "", "", "", ""})
+
+		if err := tracer.Flush(); err != nil {
+			t.Fatal(err)
+		} else if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 3 {
+		t.Fatalf("want: 3 rows, got: %d: %v", len(rows), rows)
+	} else if rows[0][0] != "step" || rows[1][0] != "1" || rows[2][0] != "1" {
+		t.Errorf("want: one header and two rows, got: %v", rows)
+	}
+}
+
+func TestExecutor_Start(t *testing.T) {
+	code := []vm.ObjectCode{{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			0x5260, // AND R1,R1,#0
+			0x1265, // ADD R1,R1,#5
+			0x14a1, // ADD R2,R2,#1
+		},
+	}}
+
+	logger := log.NewFormattedLogger(io.Discard)
+
+	ex := &executor{logger: logger}
+	if err := ex.FlagSet().Parse([]string{"-start", "x3002", "program.bin"}); err != nil {
+		t.Fatal(err)
+	}
+
+	machine := vm.New(vm.WithLogger(logger))
+	machine.REG[vm.R1] = 0x0001
+	machine.REG[vm.R2] = 0x0000
+
+	if _, err := ex.load(machine, code); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x3002 {
+		t.Fatalf("PC: want: 0x3002, got: %s", machine.PC)
+	}
+
+	if err := machine.RunN(context.Background(), 1); !errors.Is(err, vm.ErrStepLimit) {
+		t.Fatalf("want: %v, got: %v", vm.ErrStepLimit, err)
+	}
+
+	if machine.PC != 0x3003 || machine.REG[vm.R2] != 0x0001 || machine.REG[vm.R1] != 0x0001 {
+		t.Errorf("want: PC: 0x3003, R1: 0x0001, R2: 0x0001, got: PC: %s, R1: %s, R2: %s",
+			machine.PC, machine.REG[vm.R1], machine.REG[vm.R2])
+	}
+
+	ex.start = "xfe00"
+
+	if _, err := ex.load(vm.New(vm.WithLogger(logger)), code); err == nil {
+		t.Error("want: error for I/O page address")
+	}
+}
+
+func TestExecutor_Symbols(t *testing.T) {
+	symFile := filepath.Join(t.TempDir(), "prog.sym")
+	symbols := `// Symbol table
+// Symbol Name       Page Address
+// ----------------  ------------
+// START             3000
+// LOOP              300A
+`
+
+	if err := os.WriteFile(symFile, []byte(symbols), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	labels, err := loadLabels(symFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	tracer := newTraceWriter(&buf, true)
+	tracer.labels = labels
+
+	machine := vm.New(
+		vm.WithLogger(log.NewFormattedLogger(io.Discard)),
+		vm.WithStepListener(tracer.step),
+	)
+
+	entry := vm.Word(0x300a)
+	code := vm.ObjectCode{
+		Orig:  0x300a,
+		Entry: &entry,
+		Code: []vm.Word{
+			0x14a1, // ADD R2,R2,#1
+			0x14a1, // ADD R2,R2,#1
+		},
+	}
+
+	if _, err := vm.NewLoader(machine).Load(code); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := machine.RunN(context.Background(), 2); !errors.Is(err, vm.ErrStepLimit) {
+		t.Fatalf("want: %v, got: %v", vm.ErrStepLimit, err)
+	}
+
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 3 {
+		t.Fatalf("want: 3 rows, got: %d: %v", len(rows), rows)
+	}
+
+	if pc := rows[1][1]; pc != "LOOP" {
+		t.Errorf("pc: want: LOOP, got: %s", pc)
+	} else if pc := rows[2][1]; pc != "0x300b" {
+		t.Errorf("pc: want: 0x300b, got: %s", pc)
+	}
+}
+
+func TestExecutor_Entry(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "prog.asm")
+	output := filepath.Join(dir, "prog.bin")
+	source := `.ORIG x3000
+       .ENTRY START
+DATA   .FILL x0007
+START  LD R1,DATA
+       HALT
+.END
+`
+
+	if err := os.WriteFile(src, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.NewFormattedLogger(io.Discard)
+	asm := Assembler()
+
+	if err := asm.FlagSet().Parse([]string{"-o", output}); err != nil {
+		t.Fatal(err)
+	} else if code := asm.Run(context.Background(), []string{src}, io.Discard, logger); code != 0 {
+		t.Fatalf("asm: exit code: %d", code)
+	}
+
+	ex := &executor{logger: logger}
+
+	code, err := ex.loadCode(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machine := vm.New(vm.WithLogger(logger))
+
+	if _, err := ex.load(machine, code); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x3001 {
+		t.Fatalf("PC: want: 0x3001, got: %s", machine.PC)
+	}
+
+	if err := machine.RunN(context.Background(), 1); !errors.Is(err, vm.ErrStepLimit) {
+		t.Fatalf("want: %v, got: %v", vm.ErrStepLimit, err)
+	} else if machine.REG[vm.R1] != 0x0007 {
+		t.Errorf("R1: want: 0x0007, got: %s", machine.REG[vm.R1])
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/smoynes/elsie/internal/asm"
+	"github.com/smoynes/elsie/internal/cli"
+	"github.com/smoynes/elsie/internal/log"
+)
+
+// Formatter is the command that reformats LC3ASM source code.
+//
+//	elsie fmt [-w] FILE.asm
+func Formatter() cli.Command {
+	return new(formatter)
+}
+
+type formatter struct {
+	write    bool
+	tabWidth int
+	opcode   int
+	operands int
+	comment  int
+}
+
+func (formatter) Description() string {
+	return "format source code"
+}
+
+func (formatter) Usage(out io.Writer) error {
+	var err error
+	_, err = fmt.Fprintln(out, `fmt [-w] [-tabs width] [-opcode col] [-operands col] [-comment col] file.asm ...
+
+Format source code with consistent alignment: labels in the first column, then opcodes, operands and
+end-of-line comments, each aligned to a column. Labels and directives are written in upper case.
+
+The formatted source is written to standard output or, with -w, back to the source file. With
+-tabs, columns are aligned with tabs of the given width where possible.
+
+Source that does not parse is not formatted. Neither is source whose meaning would change, which
+is a bug in the formatter.`)
+
+	return err
+}
+
+func (f *formatter) FlagSet() *cli.FlagSet {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	fs.BoolVar(&f.write, "w", false, "write result to source file")
+	fs.IntVar(&f.tabWidth, "tabs", 0, "align with tabs of `width`; zero for spaces")
+	fs.IntVar(&f.opcode, "opcode", 8, "opcode `column`")
+	fs.IntVar(&f.operands, "operands", 16, "operand `column`")
+	fs.IntVar(&f.comment, "comment", 32, "comment `column`")
+
+	return fs
+}
+
+// Run formats each source file.
+func (f *formatter) Run(_ context.Context, args []string, stdout io.Writer, logger *log.Logger) int {
+	if len(args) == 0 {
+		logger.Error("No source files")
+		return 1
+	}
+
+	opts := []asm.FormatOption{
+		asm.WithColumns(f.opcode, f.operands, f.comment),
+		asm.WithTabs(f.tabWidth),
+	}
+
+	for _, fn := range args {
+		in, err := os.Open(fn)
+		if err != nil {
+			logger.Error("I/O error", "file", fn, "err", err)
+			return 1
+		}
+
+		var out bytes.Buffer
+
+		err = asm.Format(&out, in, opts...)
+		in.Close()
+
+		if err != nil {
+			logger.Error("Format error", "file", fn, "err", err)
+			return 1
+		}
+
+		if f.write {
+			err = os.WriteFile(fn, out.Bytes(), 0o644)
+		} else {
+			_, err = out.WriteTo(stdout)
+		}
+
+		if err != nil {
+			logger.Error("I/O error", "file", fn, "err", err)
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+func TestFormatter_Write(t *testing.T) {
+	var (
+		testdata = filepath.Join("..", "..", "asm", "testdata")
+		src      = filepath.Join(t.TempDir(), "format1.asm")
+	)
+
+	messy, err := os.ReadFile(filepath.Join(testdata, "format1.asm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(testdata, "format1.fmt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(src, messy, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := Formatter()
+	fs := cmd.FlagSet()
+
+	if err := fs.Parse([]string{"-w"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.Buffer{}
+	logger := log.NewFormattedLogger(io.Discard)
+
+	if code := cmd.Run(context.Background(), []string{src}, &stdout, logger); code != 0 {
+		t.Fatalf("exit code: %d", code)
+	} else if stdout.Len() != 0 {
+		t.Errorf("stdout: want: empty, got: %q", stdout.String())
+	}
+
+	if got, err := os.ReadFile(src); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(want, got) {
+		t.Errorf("formatted:\nwant: %q\ngot:  %q", want, got)
+	}
+}
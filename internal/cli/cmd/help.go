@@ -62,6 +62,7 @@ Commands:`)
 	fmt.Fprintf(out, "        %-8s %s\n", h.FlagSet().Name(), h.Description())
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Use `elsie help <command>` to get help for a command.")
+	fmt.Fprintln(out, "All commands accept -v for debug logs and -q to log warnings and errors only.")
 
 	return err
 }
@@ -0,0 +1,186 @@
+package cmd
+
+// run.go holds a command to run programs without a terminal.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/smoynes/elsie/internal/cli"
+	"github.com/smoynes/elsie/internal/encoding"
+	"github.com/smoynes/elsie/internal/log"
+	"github.com/smoynes/elsie/internal/monitor"
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// Runner is the command that runs a program in batch mode, i.e. without a terminal. Keyboard input
+// is read from the standard input and display output is written to the standard output.
+//
+//	elsie run prog.obj < input > output
+func Runner() cli.Command {
+	return &runner{}
+}
+
+type runner struct {
+	timeout time.Duration
+}
+
+// errInputExhausted is returned when a program waits for input after the input stream has ended.
+var errInputExhausted = errors.New("input exhausted")
+
+func (runner) Description() string {
+	return "run a program in batch mode"
+}
+
+func (runner) Usage(out io.Writer) error {
+	var err error
+	_, err = fmt.Fprintln(out, `run [-timeout duration] program.obj < input > output
+
+Runs a program without a terminal. Keyboard input is read from standard input and display output is
+written to standard output. The program may be binary object code, i.e. a file with a .obj
+extension, or hex-encoded object code.
+
+Execution stops when the program halts. It is an error if the program waits for input after
+standard input is exhausted or if it does not halt before the timeout.`)
+
+	return err
+}
+
+func (r *runner) FlagSet() *cli.FlagSet {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.DurationVar(&r.timeout, "timeout", 10*time.Second, "stop execution after `duration`")
+
+	return fs
+}
+
+// Run loads the program and runs it until it halts.
+func (r *runner) Run(ctx context.Context, args []string, stdout io.Writer, logger *log.Logger) int {
+	if len(args) != 1 {
+		logger.Error("Missing object-code argument. Run elsie help run for usage.")
+		return -1
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		logger.Error("Error loading code", "err", err)
+		return -1
+	}
+
+	object := filepath.Ext(args[0]) == ".obj" || bytes.HasPrefix(data, []byte(vm.ObjectMagic))
+
+	if err := r.run(ctx, data, object, os.Stdin, stdout); err != nil {
+		logger.Error("Program error", "err", err)
+		return 2
+	}
+
+	return 0
+}
+
+// run loads code into a new machine and runs it, reading keyboard input from in and writing display
+// output to out. The code is binary object code if object is true and, otherwise, hex-encoded.
+func (r *runner) run(ctx context.Context, code []byte, object bool, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(context.Canceled)
+
+	if r.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+
+		ctx, cancelTimeout = context.WithTimeout(ctx, r.timeout)
+		defer cancelTimeout()
+	}
+
+	var (
+		mut    sync.Mutex
+		buf    = bufio.NewWriter(out)
+		kbd    *vm.Keyboard
+		logger = log.NewFormattedLogger(io.Discard)
+	)
+
+	machine := vm.New(
+		vm.WithLogger(logger),
+		monitor.WithDefaultSystemImage(),
+		vm.WithKeyboardScript(in),
+		vm.WithDisplayListener(func(char uint16) {
+			mut.Lock()
+			defer mut.Unlock()
+
+			_, _ = buf.WriteRune(rune(char))
+		}),
+		vm.WithStepListener(func(_ vm.Word, _ *vm.LC3) {
+			if kbd.EOF() {
+				cancel(errInputExhausted)
+			}
+		}),
+	)
+
+	kbd = machine.Mem.Devices.Get(vm.KBSRAddr).(*vm.Keyboard)
+
+	if err := r.load(machine, code, object); err != nil {
+		return err
+	}
+
+	err := machine.Run(ctx)
+
+	r.drain(machine)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if flushErr := buf.Flush(); err == nil {
+		err = flushErr
+	}
+
+	if errors.Is(err, context.Canceled) {
+		err = context.Cause(ctx)
+	}
+
+	return err
+}
+
+// load loads binary or hex-encoded object code into the machine.
+func (r *runner) load(machine *vm.LC3, code []byte, object bool) error {
+	loader := vm.NewLoader(machine)
+
+	if object {
+		_, err := loader.LoadObject(code)
+		return err
+	}
+
+	hex := encoding.HexEncoding{}
+
+	if err := hex.UnmarshalText(code); err != nil {
+		return err
+	}
+
+	_, err := loader.LoadAll(hex.Code)
+
+	return err
+}
+
+// drainTimeout limits how long to wait for the display to output the last character.
+const drainTimeout = time.Second
+
+// drain waits for the display to become ready, i.e. for the listeners to have been notified of the
+// last character written.
+func (r *runner) drain(machine *vm.LC3) {
+	driver := machine.Mem.Devices.Get(vm.DDRAddr).(*vm.DisplayDriver)
+	deadline := time.Now().Add(drainTimeout)
+
+	for time.Now().Before(deadline) {
+		dsr, err := driver.Read(vm.DSRAddr)
+		if err != nil || vm.Register(dsr)&vm.DisplayReady != 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
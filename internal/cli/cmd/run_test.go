@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// echo is a program that echoes its input until it reads a newline.
+var echo = []vm.Word{
+	0x3000, // .ORIG x3000
+	0xf020, // LOOP GETC
+	0xf021, //      OUT
+	0x1236, //      ADD R1,R0,#-10
+	0x0bfc, //      BRnp LOOP
+	0xf025, //      HALT
+}
+
+func TestRunner_Echo(t *testing.T) {
+	var (
+		obj bytes.Buffer
+		out bytes.Buffer
+	)
+
+	if err := binary.Write(&obj, binary.BigEndian, echo); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runner{}
+	in := strings.NewReader("hello\nignored")
+
+	if err := r.run(context.Background(), obj.Bytes(), true, in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "hello\n\n\nMACHINE HALTED!\n\n"; out.String() != want {
+		t.Errorf("output: want: %q, got: %q", want, out.String())
+	}
+}
+
+func TestRunner_InputExhausted(t *testing.T) {
+	var (
+		obj bytes.Buffer
+		out bytes.Buffer
+	)
+
+	if err := binary.Write(&obj, binary.BigEndian, echo); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runner{}
+	in := strings.NewReader("no newline")
+
+	if err := r.run(context.Background(), obj.Bytes(), true, in, &out); !errors.Is(err, errInputExhausted) {
+		t.Errorf("want: %v, got: %v", errInputExhausted, err)
+	}
+
+	if want := "no newline"; out.String() != want {
+		t.Errorf("output: want: %q, got: %q", want, out.String())
+	}
+}
@@ -12,7 +12,9 @@
 // # Bugs
 //
 // This is not a complete implementation Intel Hex encoding; it is for internal use, only. It
-// supports minimal record types, specifically just the data and end-of-file record types.
+// supports minimal record types, specifically just the data, end-of-file and start linear address
+// record types. A start record holds an object's entry point and follows the object's data record.
+// Its 32-bit address must fit in 16 bits.
 package encoding
 
 import (
@@ -53,6 +55,7 @@ func (h *HexEncoding) MarshalText() ([]byte, error) {
 
 	for i := range h.Code {
 		code := h.Code[i]
+		check = 0
 
 		_ = buf.WriteByte(':')
 
@@ -88,6 +91,16 @@ func (h *HexEncoding) MarshalText() ([]byte, error) {
 		_, _ = hexEnc.Write(val[:1])
 
 		buf.WriteByte('\n')
+
+		if code.Entry != nil {
+			val[0] = byte(*code.Entry >> 8)
+			val[1] = byte(*code.Entry & 0x00ff)
+			check = 0x04 + byte(kindStart) + val[0] + val[1]
+
+			buf.WriteString(":04000005")
+			_, _ = hexEnc.Write([]byte{0, 0, val[0], val[1], 1 + ^check})
+			buf.WriteByte('\n')
+		}
 	}
 
 	buf.Write([]byte(":00000001ff\n"))
@@ -100,6 +113,7 @@ func (h *HexEncoding) MarshalText() ([]byte, error) {
 // must contain complete records: partial files and re-entrant unmarshalling are not supported.
 func (h *HexEncoding) UnmarshalText(buf []byte) error {
 	line := bufio.NewScanner(bytes.NewReader(buf))
+	first := len(h.Code) // Index of the first object decoded from buf.
 
 	for line.Scan() {
 		var (
@@ -177,6 +191,31 @@ func (h *HexEncoding) UnmarshalText(buf []byte) error {
 				Orig: vm.Word(recAddr),
 				Code: code,
 			})
+		} else if recKind == kindStart {
+			if recLen != 4 || len(rec) < 9+2*4+2 {
+				return fmt.Errorf("%w: start record length: %d", errInvalidHex, recLen)
+			} else if len(h.Code) == first {
+				return fmt.Errorf("%w: start record without data", errInvalidHex)
+			}
+
+			addr := make([]byte, 4)
+
+			if _, err := hex.Decode(addr, rec[9:17]); err != nil {
+				return fmt.Errorf("%w: start: %s", errInvalidHex, err.Error())
+			} else if addr[0] != 0 || addr[1] != 0 {
+				return fmt.Errorf("%w: start address out of range", errInvalidHex)
+			}
+
+			check += addr[2] + addr[3]
+			check = 1 + ^check
+
+			if check != recCheck {
+				return fmt.Errorf("%w: checksum invalid: %02x != %02x",
+					errInvalidHex, check, recCheck)
+			}
+
+			entry := vm.Word(binary.BigEndian.Uint16(addr[2:]))
+			h.Code[len(h.Code)-1].Entry = &entry
 		} else if recKind == kindEOF {
 			check = 1 + ^check
 			if check != recCheck {
@@ -201,8 +240,9 @@ func (h *HexEncoding) UnmarshalText(buf []byte) error {
 type kind byte
 
 const (
-	kindData kind = 0
-	kindEOF  kind = 1
+	kindData  kind = 0
+	kindEOF   kind = 1
+	kindStart kind = 5
 )
 
 type decodingError struct{}
@@ -65,6 +65,21 @@ func TestHexEncoder_UnmarshalText(t *testing.T) {
 			input:       ":10246200464C5549442050524F46494C4500464C33\n:10246200464C5549442050524F46494C4500464C33\n",
 			expectCodes: 2,
 		},
+		{
+			name:        "start record",
+			input:       ":02300000236447\n:0400000500003002C5\n",
+			expectCodes: 1,
+		},
+		{
+			name:      "start record without data",
+			input:     ":0400000500003002C5\n",
+			expectErr: errInvalidHex,
+		},
+		{
+			name:      "start record out of range",
+			input:     ":02300000236447\n:0400000500013002C4\n",
+			expectErr: errInvalidHex,
+		},
 		{
 			// Our ISA is 16 bit
 			name:      "odd length",
@@ -180,6 +195,8 @@ type marshalTestCase struct {
 func TestHexEncoder_MarshalText(t *testing.T) {
 	t.Parallel()
 
+	entry := vm.Word(0x3002)
+
 	tcs := []marshalTestCase{
 		{
 			name:         "nil",
@@ -198,6 +215,21 @@ func TestHexEncoder_MarshalText(t *testing.T) {
 			},
 			expectOutput: ":10246200464c5549442050524f46494c4500464c33\n:00000001ff\n",
 		},
+		{
+			name: "multiple records",
+			input: []vm.ObjectCode{
+				{Orig: vm.Word(0x3000), Code: []vm.Word{0x2364}},
+				{Orig: vm.Word(0x3100), Code: []vm.Word{0x2365}},
+			},
+			expectOutput: ":02300000236447\n:02310000236545\n:00000001ff\n",
+		},
+		{
+			name: "entry point",
+			input: []vm.ObjectCode{
+				{Orig: vm.Word(0x3000), Code: []vm.Word{0x2364}, Entry: &entry},
+			},
+			expectOutput: ":02300000236447\n:0400000500003002c5\n:00000001ff\n",
+		},
 	}
 
 	for _, tc := range tcs {
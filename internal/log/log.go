@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,6 +31,23 @@ var (
 // SetDefault overrides the default log output.
 func SetDefault(defaultLogger *Logger) { logger = defaultLogger }
 
+// levelFixed is true if the log level was chosen by the user, e.g. with a command-line flag.
+var levelFixed atomic.Bool
+
+// FixLevel sets the log level as chosen by the user. Subsequent calls to SetDefaultLevel have no
+// effect.
+func FixLevel(level Level) {
+	levelFixed.Store(true)
+	LogLevel.Set(level)
+}
+
+// SetDefaultLevel sets the log level unless the user has chosen one with FixLevel.
+func SetDefaultLevel(level Level) {
+	if !levelFixed.Load() {
+		LogLevel.Set(level)
+	}
+}
+
 // NewFormattedLogger returns a logger that uses a Handler to format and write logs to a Writer.
 func NewFormattedLogger(out io.Writer) *Logger {
 	handler := NewHandler(out)
@@ -83,7 +101,7 @@ func (h *Handler) Handle(ctx context.Context, rec slog.Record) error {
 		fmt.Fprintf(out, "%10s : %s\n", "TIMESTAMP", rec.Time.Format(time.RFC3339Nano))
 	}
 
-	fmt.Fprintf(out, "%10s : %s\n", "LEVEL", rec.Level.String())
+	fmt.Fprintf(out, "%10s : %s\n", "LEVEL", LevelString(rec.Level))
 
 	if h.opts.AddSource && rec.PC != 0 {
 		frames := runtime.CallersFrames([]uintptr{rec.PC})
@@ -202,6 +220,15 @@ func (h *Handler) appendAttr(out io.Writer, attr slog.Attr, grouped bool) error
 	return nil
 }
 
+// LevelString returns the name of a log level, including the levels that are not defined by slog.
+func LevelString(level Level) string {
+	if level == Trace {
+		return "TRACE"
+	}
+
+	return level.String()
+}
+
 type Loggable interface {
 	WithLogger(*Logger)
 }
@@ -223,6 +250,7 @@ var (
 )
 
 const (
+	Trace = slog.LevelDebug - 4 // More verbose than debug, e.g. every executed instruction.
 	Debug = slog.LevelDebug
 	Info  = slog.LevelInfo
 	Warn  = slog.LevelWarn
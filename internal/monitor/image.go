@@ -2,7 +2,11 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
 	"github.com/smoynes/elsie/internal/asm"
 	"github.com/smoynes/elsie/internal/log"
@@ -77,9 +81,13 @@ func NewSystemImage(logger *log.Logger) *SystemImage {
 		Symbols: sym,
 		Data:    data,
 		Traps: []Routine{
+			TrapGetc,
 			TrapHalt,
 			TrapOut,
 			TrapPuts,
+			TrapIn,
+			TrapPutsp,
+			TrapCount,
 		},
 		ISRs:       []Routine{},
 		Exceptions: []Routine{},
@@ -87,9 +95,124 @@ func NewSystemImage(logger *log.Logger) *SystemImage {
 	}
 }
 
+// TrapVectors returns the vectors of the image's system calls, e.g. to check that a program's TRAP
+// instructions call routines that exist.
+func (img *SystemImage) TrapVectors() []uint8 {
+	vectors := make([]uint8, 0, len(img.Traps))
+
+	for _, trap := range img.Traps {
+		vectors = append(vectors, uint8(trap.Vector-vm.TrapTable))
+	}
+
+	return vectors
+}
+
+// Label wraps an operation of a routine to annotate it with the name of the symbol that addresses
+// it. Validate uses the labels to check the routine's hand-written symbol table.
+type Label struct {
+	Name string
+
+	asm.Operation
+}
+
+// Unwrap returns the operation which the label wraps.
+func (l *Label) Unwrap() asm.Operation {
+	return l.Operation
+}
+
+// Validate assembles the routine and checks that each of its symbols is the address of the operation
+// it labels. The symbol tables of hand-written routines drift as code changes, so a symbol that does
+// not label an operation, that differs from the address of its labelled operation, or a label that
+// is not in the symbol table is an error.
+func (r Routine) Validate() error {
+	var (
+		labels = make(map[string]vm.Word, len(r.Symbols))
+		pc     = r.Orig
+		errs   []error
+	)
+
+	for _, oper := range r.Code {
+		if oper == nil {
+			return fmt.Errorf("validate: %s: operation is nil", r.Name)
+		}
+
+		if label, ok := oper.(*Label); ok {
+			if _, dup := labels[label.Name]; dup {
+				errs = append(errs, fmt.Errorf("validate: %s: %s: duplicate label", r.Name, label.Name))
+			} else if _, found := r.Symbols[label.Name]; !found {
+				errs = append(errs, fmt.Errorf("validate: %s: %s: label is not a symbol",
+					r.Name, label.Name))
+			}
+
+			labels[label.Name] = pc
+		}
+
+		encoded, err := oper.Generate(r.Symbols, pc+1)
+		if err != nil {
+			return fmt.Errorf("validate: %s: %s: %w", r.Name, oper, err)
+		}
+
+		pc += vm.Word(len(encoded))
+	}
+
+	names := make([]string, 0, len(r.Symbols))
+
+	for name := range r.Symbols {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if addr, found := labels[name]; !found {
+			errs = append(errs, fmt.Errorf("validate: %s: %s: no operation is labelled", r.Name, name))
+		} else if sym := r.Symbols[name]; sym != addr {
+			errs = append(errs, fmt.Errorf("validate: %s: %s: symbol: %s, label: %s",
+				r.Name, name, sym, addr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate validates each of the image's traps, interrupt service routines and exception handlers.
+func (img *SystemImage) Validate() error {
+	var errs []error
+
+	for _, routines := range [][]Routine{img.Traps, img.ISRs, img.Exceptions} {
+		for _, routine := range routines {
+			errs = append(errs, routine.Validate())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GenerateOption configures code generation for a routine.
+type GenerateOption func(*generateConfig)
+
+type generateConfig struct {
+	listing io.Writer
+}
+
+// WithListing configures GenerateRoutine to write a listing of the generated code: a line for each
+// word with its address, the routine's symbols at that address, if any, the word and its
+// disassembly.
+func WithListing(out io.Writer) GenerateOption {
+	return func(config *generateConfig) {
+		config.listing = out
+	}
+}
+
 // GenerateRoutine takes a monitor routine, i.e. a trap, interrupt, or exception handler, and
 // generates the code for it.
-func GenerateRoutine(routine Routine) (vm.ObjectCode, error) {
+func GenerateRoutine(routine Routine, opts ...GenerateOption) (vm.ObjectCode, error) {
+	config := generateConfig{}
+
+	for _, fn := range opts {
+		fn(&config)
+	}
+
 	obj := vm.ObjectCode{
 		Orig: routine.Orig,
 		Code: make([]vm.Word, 0, len(routine.Code)),
@@ -112,9 +235,39 @@ func GenerateRoutine(routine Routine) (vm.ObjectCode, error) {
 		pc += vm.Word(len(encoded))
 	}
 
+	if config.listing != nil {
+		if err := writeListing(config.listing, routine.Symbols, obj); err != nil {
+			return obj, fmt.Errorf("generate: %w", err)
+		}
+	}
+
 	return obj, nil
 }
 
+// writeListing writes a listing of object code, labelling each address with its symbols.
+func writeListing(out io.Writer, symbols asm.SymbolTable, obj vm.ObjectCode) error {
+	labels := make(map[vm.Word][]string, len(symbols))
+
+	for name, addr := range symbols {
+		labels[addr] = append(labels[addr], name)
+	}
+
+	for i, word := range obj.Code {
+		addr := obj.Orig + vm.Word(i)
+		names := labels[addr]
+
+		sort.Strings(names)
+
+		_, err := fmt.Fprintf(out, "%s  %-12s  %s  %s\n",
+			addr, strings.Join(names, ","), word, vm.Instruction(word).Disassemble())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func loadImage(loader *vm.Loader, image *SystemImage) error {
 	for _, trap := range image.Traps {
 		image.logger.Debug("loading trap", "TRAP", trap.Name)
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/smoynes/elsie/internal/asm"
@@ -52,7 +53,7 @@ func TestWithSystemImage(tt *testing.T) {
 		t.Error(err)
 	}
 
-	for i, exp := range []vm.Word{0x0e01, 0x0e00, 0x0fff, 0x2361, 0x2361, 0x0ffc} {
+	for i, exp := range []vm.Word{0x0e01, 0x0e00, 0x0fff, 0x0000, 0x0000, 0x0ffc} {
 		if got := obj.Code[i]; got != exp {
 			t.Errorf("obj.Orig[%d]: want: %v got: %v", i, exp, got)
 		}
@@ -84,7 +85,7 @@ func TestWithSystemImage(tt *testing.T) {
 			&asm.BR{NZP: asm.CondNP, SYMBOL: "LABEL2"},
 		},
 		Symbols: asm.SymbolTable{
-			"LABEL":  0x0400,
+			"LABEL":  0x0401,
 			"LABEL2": 0x0501,
 		},
 	}
@@ -102,7 +103,7 @@ func TestWithSystemImage(tt *testing.T) {
 		Symbols: asm.SymbolTable{
 			"LABEL":  0x0700,
 			"LABEL2": 0x0700,
-			"LABEL3": 0x0502,
+			"LABEL3": 0x0503,
 		},
 	}
 	image.ISRs = []Routine{routine}
@@ -127,12 +128,12 @@ func TestWithSystemImage(tt *testing.T) {
 		{0x0402, 0x0ffe},
 		{0x0405, 0x0ffb},
 
-		{0x0500, 0x0aff},
+		{0x0500, 0x0b00},
 		{0x0501, 0x0bff},
 
 		{0x0600, 0x0eff},
 		{0x0601, 0x2efe}, // 0x2eff + 0x0601 - 1
-		{0x0602, 0x00ff}, // 0x0602 + 0x00ff - 1
+		{0x0602, 0x0100}, // 0x0602 + 1 - 0x0100
 	} {
 		want := vm.Instruction(tc.want)
 		got := view[tc.addr]
@@ -144,3 +145,105 @@ func TestWithSystemImage(tt *testing.T) {
 
 	t.Logf("%+v", view[0x0600:0x060f])
 }
+
+func TestGenerateRoutine_Listing(tt *testing.T) {
+	t := testHarness{tt}
+	listing := strings.Builder{}
+
+	obj, err := GenerateRoutine(TrapHalt, WithListing(&listing))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(listing.String(), "\n"), "\n")
+
+	if len(lines) != len(obj.Code) {
+		t.Fatalf("lines: want: %d, got: %d:\n%s", len(obj.Code), len(lines), listing.String())
+	}
+
+	for name, addr := range TrapHalt.Symbols {
+		line := lines[addr-obj.Orig]
+
+		if fields := strings.Fields(line); len(fields) < 2 || fields[0] != addr.String() ||
+			fields[1] != name {
+			t.Errorf("%s: want: %s label, got: %q", addr, name, line)
+		}
+	}
+
+	if first := strings.Fields(lines[0]); len(first) < 3 || first[0] != "0x0520" || first[2] != "LEA" {
+		t.Errorf("want: unlabelled LEA at 0x0520, got: %q", lines[0])
+	}
+}
+
+func TestSystemImage_TrapVectors(tt *testing.T) {
+	t := testHarness{tt}
+	parser := asm.NewParser(log.DefaultLogger())
+	parser.Parse(strings.NewReader(`
+        .ORIG x3000
+        GETC
+        TRAP x41
+        HALT
+        .END
+`))
+
+	if err := parser.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := NewSystemImage(log.DefaultLogger()).TrapVectors()
+	gen := asm.NewGenerator(parser.Symbols(), parser.Syntax(), asm.WithTrapCheck(vectors...))
+
+	if _, err := gen.ObjectCode(); err != nil {
+		t.Fatal(err)
+	} else if warnings := gen.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings: want: none, got: %v", warnings)
+	}
+}
+
+func TestRoutine_Validate(tt *testing.T) {
+	t := testHarness{tt}
+
+	if err := NewSystemImage(log.DefaultLogger()).Validate(); err != nil {
+		t.Error(err)
+	}
+
+	routine := Routine{
+		Name: "Drifted",
+		Orig: 0x0400,
+		Code: []asm.Operation{
+			/* 0x0400 */ &Label{"START", &asm.BR{NZP: asm.CondNZP, SYMBOL: "DATA"}},
+			/* 0x0401 */ &Label{"NEXT", &asm.ADD{DR: "R0", SR1: "R0", LITERAL: 1}},
+			/* 0x0402 */ &Label{"DATA", &asm.FILL{LITERAL: []uint16{0x1234}}},
+			/* 0x0403 */ &Label{"MISSING", &asm.FILL{LITERAL: []uint16{0x5678}}},
+		},
+		Symbols: asm.SymbolTable{
+			"START": 0x0400,
+			"NEXT":  0x0402, // Off by one: the address of the next operation.
+			"DATA":  0x0402,
+			"END":   0x0404, // Not a label.
+		},
+	}
+
+	err := routine.Validate()
+	if err == nil {
+		t.Fatal("want: error")
+	}
+
+	for _, name := range []string{"NEXT", "END", "MISSING"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("want: %s in error, got: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"START", "DATA"} {
+		if strings.Contains(err.Error(), name) {
+			t.Errorf("want: %s valid, got: %v", name, err)
+		}
+	}
+
+	image := SystemImage{Exceptions: []Routine{routine}}
+
+	if err := image.Validate(); err == nil {
+		t.Error("image: want: error for exception handler")
+	}
+}
@@ -5,6 +5,47 @@ import (
 	"github.com/smoynes/elsie/internal/vm"
 )
 
+// TrapGetc is the system call to read a single character from the keyboard. Unlike IN, there is no
+// prompt and the character is not echoed. Only R0 is modified.
+//
+//   - Table:   0x0000
+//   - Vector:  0x20
+//   - Handler: 0x0400
+//   - Output:  R0, character read.
+//
+// Adapted from Fig. 9.22, 3/e.
+var TrapGetc = Routine{
+	Name:   "GETC",
+	Vector: vm.TrapTable + vm.Word(vm.TrapGETC),
+	Orig:   0x0400,
+	Symbols: asm.SymbolTable{
+		"POLL": 0x0400,
+		"KBSR": 0x0404,
+		"KBDR": 0x0405,
+	},
+	Code: []asm.Operation{
+		// POLL
+		/*0x0400 */
+		&Label{"POLL", &asm.LDI{DR: "R0", SYMBOL: "KBSR"}}, // Fetch R0 <- [KBSR] ; Check status.
+		&asm.BR{ // Branch if top bit is 0, i.e. keyboard not-ready.
+			NZP:    uint8(vm.ConditionZero | vm.ConditionPositive),
+			SYMBOL: "POLL",
+		},
+
+		// R0 <- [KBDR] ; Read the character.
+		/*0x0402 */
+		&asm.LDI{DR: "R0", SYMBOL: "KBDR"},
+
+		// Return from trap.
+		/*0x0403 */
+		&asm.RTI{},
+
+		// Trap-scoped variables: I/O addresses of the keyboard status- and data-registers.
+		/*0x0404 */ &Label{"KBSR", &asm.FILL{LITERAL: []uint16{uint16(vm.KBSRAddr)}}},
+		/*0x0405 */ &Label{"KBDR", &asm.FILL{LITERAL: []uint16{uint16(vm.KBDRAddr)}}},
+	},
+}
+
 // TrapHalt is the system call to stop the machine.
 //   - Table:   0x0000
 //   - Vector:  0x25
@@ -29,10 +70,10 @@ var TrapHalt = Routine{
 
 		// Clear RUN flag in Machine Control Register.
 		/* 0x0522 */
-		&asm.LDI{DR: "R0", SYMBOL: "MCR"},        // R0 <- [MCR]   ; Load MCR.
-		&asm.LD{DR: "R1", SYMBOL: "MASK"},        // R1 <- MASK    ; Load bitmask.
-		&asm.AND{DR: "R0", SR1: "R0", SR2: "R1"}, // R0 <- R0 & R1 ; Clear top bit using bit mask
-		&asm.STI{SR: "R0", SYMBOL: "MCR"},        // [MCR]<- R0    ; Replace value in MCR.
+		&Label{"RETRY", &asm.LDI{DR: "R0", SYMBOL: "MCR"}}, // R0 <- [MCR]   ; Load MCR.
+		&asm.LD{DR: "R1", SYMBOL: "MASK"},                  // R1 <- MASK    ; Load bitmask.
+		&asm.AND{DR: "R0", SR1: "R0", SR2: "R1"},           // R0 <- R0 & R1 ; Clear top bit.
+		&asm.STI{SR: "R0", SYMBOL: "MCR"},                  // [MCR]<- R0    ; Replace value in MCR.
 
 		// Halt again, if we reach here, forever.
 		/* 0x0526 */
@@ -41,10 +82,10 @@ var TrapHalt = Routine{
 			SYMBOL: "RETRY",
 		},
 
-		// Routine data.
-		/* 0x0527 */ &asm.FILL{LITERAL: uint16(vm.MCRAddr)}, // I/O address of MCR.
-		/* 0x0528 */ &asm.FILL{LITERAL: 0x7fff}, // MASK to clear top bit.
-		/* 0x0529 */ &asm.STRINGZ{LITERAL: "\n\nMACHINE HALTED!\n\n"},
+		// Routine data: the I/O address of MCR and the MASK to clear its top bit.
+		/* 0x0527 */ &Label{"MCR", &asm.FILL{LITERAL: []uint16{uint16(vm.MCRAddr)}}},
+		/* 0x0528 */ &Label{"MASK", &asm.FILL{LITERAL: []uint16{0x7fff}}},
+		/* 0x0529 */ &Label{"HALTMESSAGE", &asm.STRINGZ{LITERAL: "\n\nMACHINE HALTED!\n\n"}},
 	},
 }
 
@@ -86,10 +127,11 @@ var TrapOut = Routine{
 		&asm.LD{DR: "R2", SYMBOL: "INTMASK"},
 		&asm.AND{DR: "R2", SR1: "R1", SR2: "R2"},
 
-		// POLL
+		// POLL: Store R1 -> [PSR] to enable interrupts, if previously enabled, then store R2 -> [PSR]
+		// to disable them.
 		/*0x0429 */
-		&asm.STI{SR: "R1", SYMBOL: "PSR"}, // Store R1 -> [PSR] ; Enable interrupts, if prev enabled.
-		&asm.STI{SR: "R2", SYMBOL: "PSR"}, // Store R2 -> [PSR] ; Disable interrupts.
+		&Label{"POLL", &asm.STI{SR: "R1", SYMBOL: "PSR"}},
+		&asm.STI{SR: "R2", SYMBOL: "PSR"},
 
 		/*0x042b */
 		&asm.LDI{DR: "R3", SYMBOL: "DSR"}, // Fetch R3 <- [DSR] ; Check status.
@@ -119,11 +161,12 @@ var TrapOut = Routine{
 		/*0x0435 */
 		&asm.RTI{},
 
-		// Trap-scoped variables.
-		/*0x0436 */ &asm.FILL{LITERAL: 0xbfff}, // MASK to disable interrupts.
-		/*0x0437 */ &asm.FILL{LITERAL: uint16(vm.PSRAddr)}, // I/O addresses: processor status-,
-		/*0x0438 */ &asm.FILL{LITERAL: uint16(vm.DSRAddr)}, // display status-, and
-		/*0x0439 */ &asm.FILL{LITERAL: uint16(vm.DDRAddr)}, // display data-registers
+		// Trap-scoped variables: the MASK to disable interrupts and the I/O addresses of the processor
+		// status-, display status-, and display data-registers.
+		/*0x0436 */ &Label{"INTMASK", &asm.FILL{LITERAL: []uint16{0xbfff}}},
+		/*0x0437 */ &Label{"PSR", &asm.FILL{LITERAL: []uint16{uint16(vm.PSRAddr)}}},
+		/*0x0438 */ &Label{"DSR", &asm.FILL{LITERAL: []uint16{uint16(vm.DSRAddr)}}},
+		/*0x0439 */ &Label{"DDR", &asm.FILL{LITERAL: []uint16{uint16(vm.DDRAddr)}}},
 	},
 }
 
@@ -161,7 +204,7 @@ var TrapPuts = Routine{
 
 		// Loop over in array and write each value to DDR.
 		/*LOOP: 0x0465*/
-		&asm.LDR{DR: "R0", SR: "R1"},
+		&Label{"LOOP", &asm.LDR{DR: "R0", SR: "R1"}},
 
 		// Return if value is zero.
 		&asm.BR{NZP: uint8(vm.ConditionZero), SYMBOL: "RETURN"},
@@ -174,15 +217,215 @@ var TrapPuts = Routine{
 
 		// Restore stack.
 		/*RETURN: 0x046a*/
-		&asm.LDR{DR: "R1", SR: "R6", OFFSET: 0},
+		&Label{"RETURN", &asm.LDR{DR: "R1", SR: "R6", OFFSET: 0}},
 		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
 		&asm.LDR{DR: "R0", SR: "R6", OFFSET: 0},
 		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
 
 		&asm.RTI{},
 
-		// Trap-scoped variables.
-		/*0x046f */ &asm.FILL{LITERAL: uint16(vm.DSRAddr)}, // display status-, and
-		/*0x0470 */ &asm.FILL{LITERAL: uint16(vm.DDRAddr)}, // data-registers.
+		// Trap-scoped variables: I/O addresses of the display status- and data-registers.
+		/*0x046f */ &Label{"DSR", &asm.FILL{LITERAL: []uint16{uint16(vm.DSRAddr)}}},
+		/*0x0470 */ &Label{"DDR", &asm.FILL{LITERAL: []uint16{uint16(vm.DDRAddr)}}},
+	},
+}
+
+// TrapIn is the system call to prompt for and read a single character from the keyboard. The
+// character is echoed to the display, followed by a newline.
+//
+//   - Table:   0x0000
+//   - Vector:  0x23
+//   - Handler: 0x04a0
+//   - Output:  R0, character read.
+//
+// Adapted from Fig. 9.22, 3/e.
+var TrapIn = Routine{
+	Name:   "IN",
+	Vector: vm.TrapTable + vm.Word(vm.TrapIN),
+	Orig:   0x04a0,
+	Symbols: asm.SymbolTable{
+		"POLL":    0x04a4,
+		"KBSR":    0x04af,
+		"KBDR":    0x04b0,
+		"NEWLINE": 0x04b1,
+		"PROMPT":  0x04b2,
+	},
+	Code: []asm.Operation{
+		// Push R1 onto the stack.
+		/*0x04a0 */
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R1", SR2: "R6"},
+
+		// Display the prompt.
+		/*0x04a2 */
+		&asm.LEA{DR: "R0", SYMBOL: "PROMPT"},
+		&asm.TRAP{LITERAL: uint16(vm.TrapPUTS)},
+
+		// POLL
+		/*0x04a4 */
+		&Label{"POLL", &asm.LDI{DR: "R1", SYMBOL: "KBSR"}}, // Fetch R1 <- [KBSR] ; Check status.
+		&asm.BR{ // Branch if top bit is 0, i.e. keyboard not-ready.
+			NZP:    uint8(vm.ConditionZero | vm.ConditionPositive),
+			SYMBOL: "POLL",
+		},
+
+		// R0 <- [KBDR] ; Read the character and echo it.
+		/*0x04a6 */
+		&asm.LDI{DR: "R0", SYMBOL: "KBDR"},
+		&asm.TRAP{LITERAL: uint16(vm.TrapOUT)},
+
+		// Keep the character in R1 while writing a newline.
+		/*0x04a8 */
+		&asm.ADD{DR: "R1", SR1: "R0", LITERAL: 0},
+		&asm.LD{DR: "R0", SYMBOL: "NEWLINE"},
+		&asm.TRAP{LITERAL: uint16(vm.TrapOUT)},
+		&asm.ADD{DR: "R0", SR1: "R1", LITERAL: 0},
+
+		// Restore R1 from stack.
+		/*0x04ac */
+		&asm.LDR{DR: "R1", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+
+		// Return from trap.
+		/*0x04ae */
+		&asm.RTI{},
+
+		// Trap-scoped variables: I/O addresses of the keyboard status- and data-registers, a newline and
+		// the prompt.
+		/*0x04af */ &Label{"KBSR", &asm.FILL{LITERAL: []uint16{uint16(vm.KBSRAddr)}}},
+		/*0x04b0 */ &Label{"KBDR", &asm.FILL{LITERAL: []uint16{uint16(vm.KBDRAddr)}}},
+		/*0x04b1 */ &Label{"NEWLINE", &asm.FILL{LITERAL: []uint16{uint16('\n')}}},
+		/*0x04b2 */ &Label{"PROMPT", &asm.STRINGZ{LITERAL: "\nInput a character> "}},
+	},
+}
+
+// TrapPutsp is the system call to write a packed string to the display. Each word holds two
+// characters: the low byte is written first, then the high byte. The string ends with a zero word
+// or, if it has an odd length, a zero high byte. This is the same order in which the assembler's
+// .STRINGP directive packs strings.
+//
+//   - Table:   0x0000
+//   - Vector:  0x24
+//   - Handler: 0x0560
+//   - Input:   R0, address of packed string.
+//
+// Adapted from github.com/chiragsakhuja/lc3tools.
+var TrapPutsp = Routine{
+	Name:   "PUTSP",
+	Vector: vm.TrapTable + vm.Word(vm.TrapPUTSP),
+	Orig:   0x0560,
+	Symbols: asm.SymbolTable{
+		"LOOP":    0x056d,
+		"SHIFT":   0x0575,
+		"SKIP":    0x0578,
+		"RETURN":  0x0580,
+		"LOWMASK": 0x058d,
+		"HIGHBIT": 0x058e,
+	},
+	Code: []asm.Operation{
+		// Push R0-R5 onto the stack.
+		/*0x0560*/
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R0", SR2: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R1", SR2: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R2", SR2: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R3", SR2: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R4", SR2: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 0xffff},
+		&asm.STR{SR1: "R5", SR2: "R6"},
+
+		// Move input pointer R0 to R1.
+		/*0x056c*/
+		&asm.ADD{DR: "R1", SR1: "R0", LITERAL: 0},
+
+		// Loop over the string, fetching each word into R2. Return if the word is zero.
+		/*LOOP: 0x056d*/
+		&Label{"LOOP", &asm.LDR{DR: "R2", SR: "R1"}},
+		&asm.BR{NZP: uint8(vm.ConditionZero), SYMBOL: "RETURN"},
+
+		// Write the low byte.
+		/*0x056f*/
+		&asm.LD{DR: "R0", SYMBOL: "LOWMASK"},
+		&asm.AND{DR: "R0", SR1: "R2", SR2: "R0"},
+		&asm.TRAP{LITERAL: uint16(vm.TrapOUT)},
+
+		// Shift the high byte right into R0, one bit at a time: R3 is the bit tested in the word and
+		// R4 is the bit set in R0. The loop ends when R3 overflows to zero.
+		/*0x0572*/
+		&asm.AND{DR: "R0", SR1: "R0", LITERAL: 0},
+		&asm.LD{DR: "R3", SYMBOL: "HIGHBIT"},
+		&asm.ADD{DR: "R4", SR1: "R0", LITERAL: 1},
+
+		/*SHIFT: 0x0575*/
+		&Label{"SHIFT", &asm.AND{DR: "R5", SR1: "R2", SR2: "R3"}},
+		&asm.BR{NZP: uint8(vm.ConditionZero), SYMBOL: "SKIP"},
+		&asm.ADD{DR: "R0", SR1: "R0", SR2: "R4"},
+
+		/*SKIP: 0x0578*/
+		&Label{"SKIP", &asm.ADD{DR: "R4", SR1: "R4", SR2: "R4"}},
+		&asm.ADD{DR: "R3", SR1: "R3", SR2: "R3"},
+		&asm.BR{NZP: uint8(vm.ConditionNegative | vm.ConditionPositive), SYMBOL: "SHIFT"},
+
+		// Return if the high byte is zero. Otherwise, write it, increment the pointer and loop.
+		/*0x057b*/
+		&asm.ADD{DR: "R0", SR1: "R0", LITERAL: 0},
+		&asm.BR{NZP: uint8(vm.ConditionZero), SYMBOL: "RETURN"},
+		&asm.TRAP{LITERAL: uint16(vm.TrapOUT)},
+		&asm.ADD{DR: "R1", SR1: "R1", LITERAL: 0x0001},
+		&asm.BR{NZP: asm.CondNZP, SYMBOL: "LOOP"},
+
+		// Restore R5-R0 from the stack.
+		/*RETURN: 0x0580*/
+		&Label{"RETURN", &asm.LDR{DR: "R5", SR: "R6"}},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+		&asm.LDR{DR: "R4", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+		&asm.LDR{DR: "R3", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+		&asm.LDR{DR: "R2", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+		&asm.LDR{DR: "R1", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+		&asm.LDR{DR: "R0", SR: "R6"},
+		&asm.ADD{DR: "R6", SR1: "R6", LITERAL: 1},
+
+		&asm.RTI{},
+
+		// Trap-scoped variables: the mask of the low byte and the lowest bit of the high byte.
+		/*0x058d */ &Label{"LOWMASK", &asm.FILL{LITERAL: []uint16{0x00ff}}},
+		/*0x058e */ &Label{"HIGHBIT", &asm.FILL{LITERAL: []uint16{0x0100}}},
+	},
+}
+
+// TrapCount is the system call to read the number of instructions the machine has executed, e.g. to
+// measure the cost of a program. The low 32 bits of the count are split across R0 and R1. The count
+// is read from the counter device, low word first, and includes the instructions executed so far in
+// the trap. Only R0 and R1 are modified.
+//
+//   - Table:   0x0000
+//   - Vector:  0x41
+//   - Handler: 0x05a0
+//   - Output:  R0, low word of count; R1, high word of count.
+var TrapCount = Routine{
+	Name:   "COUNT",
+	Vector: vm.TrapTable + vm.Word(vm.TrapCOUNT),
+	Orig:   0x05a0,
+	Symbols: asm.SymbolTable{
+		"CNTL": 0x05a3,
+		"CNTH": 0x05a4,
+	},
+	Code: []asm.Operation{
+		/*0x05a0*/
+		&asm.LDI{DR: "R0", SYMBOL: "CNTL"}, // R0 <- [CNTL] ; Read low word, latching high word.
+		&asm.LDI{DR: "R1", SYMBOL: "CNTH"}, // R1 <- [CNTH] ; Read high word.
+		&asm.RTI{},
+
+		// Routine data: I/O addresses of the counter low- and high-words.
+		/*0x05a3*/ &Label{"CNTL", &asm.FILL{LITERAL: []uint16{uint16(vm.CNTLAddr)}}},
+		/*0x05a4*/ &Label{"CNTH", &asm.FILL{LITERAL: []uint16{uint16(vm.CNTHAddr)}}},
 	},
 }
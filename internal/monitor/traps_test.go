@@ -129,19 +129,11 @@ func TestTrap_Out(tt *testing.T) {
 		},
 	}
 
-	displayed := make(chan uint16, 10)
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
-
+	withDisplay, display := vm.WithStringDisplay()
 	machine := vm.New(
 		WithSystemImage(&image),
-		vm.WithDisplayListener(func(out uint16) {
-			select {
-			case <-ctx.Done():
-				return
-			case displayed <- out:
-			}
-		}),
+		withDisplay,
+		vm.WithSynchronousIO(),
 	)
 
 	loader := vm.NewLoader(machine)
@@ -158,43 +150,60 @@ func TestTrap_Out(tt *testing.T) {
 
 	machine.REG[vm.R0] = 0x2365
 
-	for i := 0; i < 1000; i++ {
-		err = machine.Step()
+	runUntil(t, machine, 0x3001, 100)
 
-		if testing.Verbose() {
-			t.Logf("Stepped\n%s\n%s\nerr %v", machine, machine.REG, err)
-		}
+	if got := display.String(); got != "\u2365" {
+		t.Errorf("displayed %q", got)
+	}
+}
 
-		if err != nil {
-			t.Errorf("Step error %s", err)
-			cancel()
+func TestTrap_OutBuffer(tt *testing.T) {
+	t := NewHarness(tt)
 
-			break
-		} else if machine.PC > 0x3001 {
-			t.Log("Instruction completed")
-			cancel()
+	image := SystemImage{
+		logger:  t.Logger(),
+		Symbols: nil,
+		Traps: []Routine{
+			TrapOut,
+			TrapPuts,
+			TrapHalt,
+		},
+	}
 
-			break
-		} else if !machine.MCR.Running() {
-			t.Log("Machine stopped")
-			cancel()
+	withDisplay, display := vm.WithStringDisplay()
+	machine := vm.New(
+		WithSystemImage(&image),
+		withDisplay,
+	)
 
-			break
-		}
+	code := vm.ObjectCode{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			vm.NewInstruction(vm.AND, 0x0020).Encode(), // AND R0,R0,#0
+			vm.NewInstruction(vm.ADD, 0x0021).Encode(), // ADD R0,R0,#1
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapOUT)).Encode(),
+			vm.NewInstruction(vm.ADD, 0x0021).Encode(), // ADD R0,R0,#1
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapOUT)).Encode(),
+			vm.NewInstruction(vm.ADD, 0x0021).Encode(), // ADD R0,R0,#1
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapOUT)).Encode(),
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapHALT)).Encode(),
+		},
 	}
 
-	cancel()
-	<-ctx.Done()
-	time.Sleep(time.Millisecond)
-	close(displayed)
+	unsafeLoad(vm.NewLoader(machine), code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
 
-	vals := make([]uint16, 0, len(displayed))
-	for out := range displayed {
-		vals = append(vals, out)
+	if err := machine.Run(ctx); err != nil {
+		t.Fatal(err)
+	} else if reason := machine.LastHalt(); reason != vm.HaltTrap {
+		t.Errorf("halt: want: %s, got: %s", vm.HaltTrap, reason)
 	}
 
-	if len(vals) != 1 || vals[0] != 0x2365 {
-		t.Errorf("displayed %+v", vals)
+	// The output is captured as it is written, so it is complete without waiting for the display.
+	if got, want := display.String(), "\x01\x02\x03\n\nMACHINE HALTED!\n\n"; got != want {
+		t.Errorf("displayed: want: %q, got: %q", want, got)
 	}
 }
 
@@ -228,18 +237,11 @@ func TestTrap_Puts(tt *testing.T) {
 		},
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	displayed := make(chan uint16, 10)
+	withDisplay, display := vm.WithStringDisplay()
 	machine := vm.New(
 		WithSystemImage(&image),
-
-		// TODO: the names out and displayed are inverted by meaning here.
-		vm.WithDisplayListener(func(out uint16) {
-			select {
-			case displayed <- out:
-			case <-ctx.Done():
-			}
-		}),
+		withDisplay,
+		vm.WithSynchronousIO(),
 	)
 	loader := vm.NewLoader(machine)
 	code := vm.ObjectCode{
@@ -259,51 +261,108 @@ func TestTrap_Puts(tt *testing.T) {
 	}
 
 	unsafeLoad(loader, code)
+	runUntil(t, machine, 0x3001, 1000)
 
-	for i := 0; i < 1000; i++ {
-		err = machine.Step()
+	if got := display.String(); got != "!\"#" {
+		t.Errorf("displayed: want: %q, got: %q", "!\"#", got)
+	}
+}
 
-		if testing.Verbose() {
-			t.Logf("Stepped\n%s\n%s\nerr %v", machine.String(), machine.REG, err)
-		}
+func TestTrap_In(tt *testing.T) {
+	t := NewHarness(tt)
 
-		if err != nil {
-			t.Errorf("Step error %s", err)
-			cancel()
+	obj, err := GenerateRoutine(TrapIn)
 
-			break
-		} else if machine.PC > 0x3000 {
-			t.Logf("Instruction complete")
-			cancel()
+	if err != nil {
+		t.Error(err)
+	}
 
-			break
-		} else if !machine.MCR.Running() {
-			t.Logf("Machine halted")
-			cancel()
+	if want := int(TrapIn.Symbols["PROMPT"] - TrapIn.Orig); len(obj.Code) <= want {
+		t.Error("code too short", len(obj.Code))
+	}
 
-			break
-		}
+	image := SystemImage{
+		logger:  t.Logger(),
+		Symbols: nil,
+		Traps: []Routine{
+			TrapIn,
+			TrapPuts,
+			TrapOut,
+		},
 	}
 
-	cancel()
-	<-ctx.Done()
-	time.Sleep(time.Millisecond)
-	close(displayed)
+	withDisplay, display := vm.WithStringDisplay()
+	machine := vm.New(
+		WithSystemImage(&image),
+		withDisplay,
+		vm.WithSynchronousIO(),
+	)
 
-	vals := make([]uint16, 0, len(displayed))
-	for out := range displayed {
-		vals = append(vals, out)
+	loader := vm.NewLoader(machine)
+	code := vm.ObjectCode{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapIN)).Encode(),
+		},
 	}
 
-	t.Log("displayed", len(vals), "values:")
+	unsafeLoad(loader, code)
+
+	kbd := machine.Mem.Devices.Get(vm.KBDRAddr).(*vm.Keyboard)
+	kbd.Update('x')
+
+	runUntil(t, machine, 0x3001, 10_000)
 
-	if len(vals) != 3 {
-		t.Errorf("expected 3 displayed values, got %d", len(vals))
+	if got := machine.REG[vm.R0]; got != vm.Register('x') {
+		t.Errorf("R0 want: %s, got: %s", vm.Register('x'), got)
 	}
 
-	for i := range vals {
-		if vals[i] != 0x0021+uint16(i) {
-			t.Errorf("vals[%d] != 0x0022, got: %04x", i, vals[i])
+	if got, want := display.String(), "\nInput a character> x\n"; got != want {
+		t.Errorf("displayed: want: %q, got: %q", want, got)
+	}
+}
+
+func TestTrap_Getc(tt *testing.T) {
+	t := NewHarness(tt)
+
+	if err := TrapGetc.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	image := SystemImage{
+		logger: t.Logger(),
+		Traps:  []Routine{TrapGetc},
+	}
+
+	machine := vm.New(WithSystemImage(&image))
+	loader := vm.NewLoader(machine)
+
+	unsafeLoad(loader, vm.ObjectCode{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapGETC)).Encode(),
+		},
+	})
+
+	// Registers other than R0 are restored when the trap returns.
+	sentinels := []vm.Register{0x1111, 0x2222, 0x3333, 0x4444, 0x5555, 0x2f00}
+
+	for i, val := range sentinels {
+		machine.REG[vm.R1+vm.GPR(i)] = val
+	}
+
+	kbd := machine.Mem.Devices.Get(vm.KBDRAddr).(*vm.Keyboard)
+	kbd.Update('x')
+
+	runUntil(t, machine, 0x3001, 100)
+
+	if got := machine.REG[vm.R0]; got != vm.Register('x') {
+		t.Errorf("R0 want: %s, got: %s", vm.Register('x'), got)
+	}
+
+	for i, want := range sentinels {
+		if got := machine.REG[vm.R1+vm.GPR(i)]; got != want {
+			t.Errorf("R%d: want: %s, got: %s", i+1, want, got)
 		}
 	}
 }
@@ -314,3 +373,144 @@ func unsafeLoad(loader *vm.Loader, code vm.ObjectCode) {
 		panic(err.Error())
 	}
 }
+
+// runUntil steps the machine until the program counter reaches an address, failing the test if a
+// step fails or if it is not reached within a number of steps. Routines that output characters, i.e.
+// OUT and those that call it, poll the display until it is ready. By default, the display becomes
+// ready asynchronously and the number of steps varies, so tests of these routines configure the
+// machine with synchronous I/O.
+func runUntil(t testing.TB, machine *vm.LC3, pc vm.Word, maxSteps int) {
+	t.Helper()
+
+	for i := 0; i < maxSteps && machine.PC != vm.ProgramCounter(pc); i++ {
+		err := machine.Step()
+
+		if testing.Verbose() {
+			t.Logf("Stepped\n%s\n%s\nerr %v", machine, machine.REG, err)
+		}
+
+		if err != nil {
+			t.Fatalf("Step error %s", err)
+		}
+	}
+
+	if machine.PC != vm.ProgramCounter(pc) {
+		t.Fatalf("trap did not return: PC: %s", machine.PC)
+	}
+}
+
+func TestTrap_Putsp(tt *testing.T) {
+	// Strings packed by the assembler must print in the order they were written.
+	stringp := func(text string) []vm.Word {
+		code, err := (&asm.STRINGP{LITERAL: text}).Generate(asm.SymbolTable{}, 0x3100)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		return code
+	}
+
+	tcs := []struct {
+		name string
+		data []vm.Word
+		want string
+	}{
+		{name: "odd length", data: []vm.Word{0x4241, 0x0043}, want: "ABC"},
+		{name: "even length", data: []vm.Word{0x4241, 0x4443, 0x0000}, want: "ABCD"},
+		{name: "stringp odd length", data: stringp("Hello"), want: "Hello"},
+		{name: "stringp even length", data: stringp("Hello!"), want: "Hello!"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := NewHarness(tt)
+
+			obj, err := GenerateRoutine(TrapPutsp)
+			if err != nil {
+				t.Fatal(err)
+			} else if want := int(TrapPutsp.Symbols["HIGHBIT"]-TrapPutsp.Orig) + 1; len(obj.Code) != want {
+				t.Errorf("code length: want: %d, got: %d", want, len(obj.Code))
+			}
+
+			image := SystemImage{
+				logger: t.Logger(),
+				Traps: []Routine{
+					TrapPutsp,
+					TrapOut,
+				},
+			}
+
+			withDisplay, display := vm.WithStringDisplay()
+			machine := vm.New(
+				WithSystemImage(&image),
+				withDisplay,
+				vm.WithSynchronousIO(),
+			)
+			loader := vm.NewLoader(machine)
+
+			unsafeLoad(loader, vm.ObjectCode{
+				Orig: 0x3000,
+				Code: []vm.Word{
+					vm.NewInstruction(vm.TRAP, uint16(vm.TrapPUTSP)).Encode(),
+				},
+			})
+			unsafeLoad(loader, vm.ObjectCode{Orig: 0x3100, Code: tc.data})
+
+			machine.REG[vm.R0] = 0x3100
+
+			runUntil(t, machine, 0x3001, 10_000)
+
+			if machine.REG[vm.R0] != 0x3100 {
+				t.Errorf("R0: want: 0x3100, got: %s", machine.REG[vm.R0])
+			}
+
+			if got := display.String(); got != tc.want {
+				t.Errorf("displayed: want: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTrap_Count(tt *testing.T) {
+	t := NewHarness(tt)
+
+	if err := TrapCount.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	image := SystemImage{
+		logger: t.Logger(),
+		Traps:  []Routine{TrapCount},
+	}
+
+	machine := vm.New(WithSystemImage(&image))
+	loader := vm.NewLoader(machine)
+
+	unsafeLoad(loader, vm.ObjectCode{
+		Orig: 0x3000,
+		Code: []vm.Word{
+			vm.NewInstruction(vm.AND, 0x0020).Encode(), // AND R0,R0,#0
+			vm.NewInstruction(vm.AND, 0x0020).Encode(),
+			vm.NewInstruction(vm.AND, 0x0020).Encode(),
+			vm.NewInstruction(vm.TRAP, uint16(vm.TrapCOUNT)).Encode(),
+		},
+	})
+
+	start := machine.InstructionCount()
+
+	runUntil(t, machine, 0x3004, 100)
+
+	// The count includes three ANDs, the TRAP and the first LDI in the trap.
+	want := start + 5
+	got := uint64(machine.REG[vm.R1])<<16 | uint64(machine.REG[vm.R0])
+
+	if got != want {
+		t.Errorf("count: want: %d, got: %d (R1: %s, R0: %s)", want, got, machine.REG[vm.R1], machine.REG[vm.R0])
+	}
+
+	if total := machine.InstructionCount(); total != want+2 {
+		t.Errorf("total count: want: %d, got: %d", want+2, total)
+	}
+}
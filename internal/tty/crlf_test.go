@@ -0,0 +1,73 @@
+package tty
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smoynes/elsie/internal/vm"
+)
+
+// syncBuffer is a buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mut sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mut.Lock()
+	defer sb.mut.Unlock()
+
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mut.Lock()
+	defer sb.mut.Unlock()
+
+	return sb.buf.String()
+}
+
+func TestConsole_TranslateNewlines(t *testing.T) {
+	for _, translate := range []bool{true, false} {
+		out := &syncBuffer{}
+		console := &Console{raw: out, termCh: make(chan rune, 80)}
+		console.TranslateNewlines(translate)
+
+		display := vm.NewDisplay()
+		driver := vm.NewDisplayDriver(display)
+		driver.Init(nil, []vm.Word{vm.DSRAddr, vm.DDRAddr})
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		go console.updateTerminal(ctx, driver, cancel)
+
+		// Wait for the console to listen to the display.
+		time.Sleep(10 * time.Millisecond)
+
+		for _, char := range "a\nb\r\n" {
+			if err := driver.Write(vm.DDRAddr, vm.Register(char)); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		want := "a\nb\r\n"
+		if translate {
+			want = "a\r\nb\r\n"
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for out.String() != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel(nil)
+
+		if got := out.String(); got != want {
+			t.Errorf("translate: %t: want: %q, got: %q", translate, want, got)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package tty
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestEchoFlags(t *testing.T) {
+	const other = uint32(unix.ICANON | unix.ISIG)
+
+	if got := echoFlags(other, true); got != other|unix.ECHO {
+		t.Errorf("enabled: want: %#x, got: %#x", other|unix.ECHO, got)
+	}
+
+	if got := echoFlags(other|unix.ECHO, false); got != other {
+		t.Errorf("disabled: want: %#x, got: %#x", other, got)
+	}
+
+	if got := echoFlags(echoFlags(other, true), true); got != other|unix.ECHO {
+		t.Errorf("idempotent: want: %#x, got: %#x", other|unix.ECHO, got)
+	}
+}
@@ -26,9 +26,11 @@ import (
 // [2]: These systems, themselves, emulating electromecahnical teletype devices, of course.
 type Console struct {
 	in    *os.File
-	out   *term.Terminal
+	out   io.Writer // Display output, possibly translated.
+	raw   io.Writer // Untranslated display output.
 	fd    int
 	state *term.State
+	echo  bool // Whether the terminal echoes keys.
 
 	// I/O buffers.
 	keyCh  chan uint8
@@ -104,12 +106,16 @@ func NewConsole(sin, sout, serr *os.File) (*Console, error) {
 	cons := Console{
 		fd:     fd,
 		in:     sin,
-		out:    term.NewTerminal(sin, ""),
+		out:    sout,
+		raw:    sout,
 		state:  saved,
 		keyCh:  make(chan uint8, 1),
 		termCh: make(chan rune, 80),
 	}
 
+	// In raw mode, the terminal does not return the cursor to the first column on a line feed.
+	cons.TranslateNewlines(term.IsTerminal(int(sout.Fd())))
+
 	err = cons.setTerminalParams(1, 0)
 	if err != nil {
 		return nil, err
@@ -118,6 +124,16 @@ func NewConsole(sin, sout, serr *os.File) (*Console, error) {
 	return &cons, nil
 }
 
+// TranslateNewlines configures whether line feeds written to the display are output as carriage
+// return, line feed pairs. NewConsole enables translation when the output stream is a terminal.
+func (c *Console) TranslateNewlines(enabled bool) {
+	if enabled {
+		c.out = &crlfWriter{w: c.raw}
+	} else {
+		c.out = c.raw
+	}
+}
+
 // Press injects a key press into the input stream.
 func (c Console) Press(key byte) {
 	c.keyCh <- key
@@ -134,25 +150,54 @@ func (c *Console) Restore() {
 	_ = term.Restore(c.fd, c.state)
 }
 
+// SetEcho configures whether the terminal echoes keys as they are typed. Echo is disabled by
+// default because programs, e.g. those using the GETC and IN traps, echo their own input; with both
+// enabled, characters would appear twice.
+func (c *Console) SetEcho(enabled bool) error {
+	c.echo = enabled
+
+	return c.updateTermios(func(termIO *unix.Termios) {
+		termIO.Lflag = echoFlags(termIO.Lflag, c.echo)
+	})
+}
+
 func (c *Console) setTerminalParams(vmin, vtime byte) error {
 	_ = syscall.SetNonblock(c.fd, true)
 
-	termIO, err := unix.IoctlGetTermios(c.fd, getTermiosIoctl)
+	err := c.updateTermios(func(termIO *unix.Termios) {
+		termIO.Cc[unix.VMIN] = vmin
+		termIO.Cc[unix.VTIME] = vtime
+		termIO.Lflag = echoFlags(termIO.Lflag, c.echo)
+	})
 	if err != nil {
 		return err
 	}
 
-	termIO.Cc[unix.VMIN] = vmin
-	termIO.Cc[unix.VTIME] = vtime
+	_ = os.Stdin.SetReadDeadline(time.Time{})
+
+	return nil
+}
 
-	err = unix.IoctlSetTermios(c.fd, setTermiosIoctl, termIO)
+// updateTermios gets the terminal's parameters, modifies them, and sets them.
+func (c *Console) updateTermios(fn func(*unix.Termios)) error {
+	termIO, err := unix.IoctlGetTermios(c.fd, getTermiosIoctl)
 	if err != nil {
 		return err
 	}
 
-	_ = os.Stdin.SetReadDeadline(time.Time{})
+	fn(termIO)
 
-	return nil
+	return unix.IoctlSetTermios(c.fd, setTermiosIoctl, termIO)
+}
+
+// echoFlags returns the terminal's local mode flags with echo enabled or disabled. Other flags are
+// unchanged. The type of the flags depends on the OS.
+func echoFlags[T uint32 | uint64](lflag T, enabled bool) T {
+	if enabled {
+		return lflag | T(unix.ECHO)
+	}
+
+	return lflag &^ T(unix.ECHO)
 }
 
 // readTerminal reads bytes from the terminal and writes them to the key channel until the context
@@ -224,3 +269,29 @@ func (c Console) updateTerminal(ctx context.Context, disp *vm.DisplayDriver, can
 		}
 	}
 }
+
+// crlfWriter translates line feeds to carriage return, line feed pairs. Line feeds already preceded
+// by a carriage return are not translated.
+type crlfWriter struct {
+	w    io.Writer
+	prev byte
+}
+
+func (cw *crlfWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, 0, len(p)+1)
+
+	for _, b := range p {
+		if b == '\n' && cw.prev != '\r' {
+			buf = append(buf, '\r')
+		}
+
+		buf = append(buf, b)
+		cw.prev = b
+	}
+
+	if _, err := cw.w.Write(buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,55 @@
+package vm
+
+// counter.go defines a read-only device that exposes the instruction count to programs.
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Counter is a device for reading the number of instructions the machine has executed. The count is
+// 64 bits wide but programs can only read the low 32 bits, split across two registers: reading the
+// low word latches the high word so that the two halves are consistent, even if the low word wraps
+// between reads. Programs should, therefore, read CNTL before CNTH.
+//
+// The count includes the instruction that reads the low word.
+type Counter struct {
+	stats *stats
+	high  Word // High word latched when the low word was read.
+}
+
+// ErrReadOnly is returned when writing to a read-only device register.
+var ErrReadOnly = errors.New("read-only register")
+
+// NewCounter creates a counter for the machine's instruction count.
+func NewCounter(vm *LC3) *Counter {
+	return &Counter{stats: &vm.stats}
+}
+
+// Read returns a word of the instruction count.
+func (c *Counter) Read(addr Word) (Word, error) {
+	switch addr {
+	case CNTLAddr:
+		count := c.stats.instructions
+		c.high = Word(count >> 16)
+
+		return Word(count), nil
+	case CNTHAddr:
+		return c.high, nil
+	default:
+		return 0, fmt.Errorf("%w: counter: %s", ErrNoDevice, addr)
+	}
+}
+
+// Write returns an error: the count cannot be changed by programs.
+func (c *Counter) Write(addr Word, _ Register) error {
+	return fmt.Errorf("%w: counter: %s", ErrReadOnly, addr)
+}
+
+func (c *Counter) device() string {
+	return "CNT(instructions)"
+}
+
+func (c *Counter) String() string {
+	return fmt.Sprintf("Counter(%d)", c.stats.instructions)
+}
@@ -0,0 +1,116 @@
+package vm
+
+// decode.go exposes decoded instructions for tools, e.g. disassemblers and debuggers.
+
+// DecodedInstruction is an instruction decoded into its fields. Unlike the operations the CPU
+// executes, it has no behaviour: it only describes the instruction.
+type DecodedInstruction struct {
+	Instruction Instruction    // Encoded instruction.
+	Opcode      Opcode         // Operation code.
+	Mode        AddressingMode // How the instruction's operand is located.
+
+	// Register operands, in the order they are written in assembly language, e.g. DR, SR1 and SR2
+	// for ADD. A base register, for LDR and STR, is included.
+	Registers []GPR
+
+	// Condition codes tested by BR.
+	Cond Condition
+
+	// Sign-extended PC-relative or base offset, for PC-relative, indirect and base-offset modes.
+	Offset Word
+
+	// Sign-extended immediate value or zero-extended trap vector, for immediate and vector modes.
+	Literal Word
+
+	// Address of a PC-relative or indirect operand, i.e. the sum of the program counter and offset.
+	Target Word
+}
+
+// AddressingMode identifies how an instruction locates its operand.
+type AddressingMode uint8
+
+// Addressing modes.
+const (
+	// No operand, e.g. RTI.
+	ModeNone AddressingMode = iota // none
+
+	// Register operands only, e.g. NOT or JMP.
+	ModeRegister // register
+
+	// Immediate value, e.g. ADD R0,R0,#1.
+	ModeImmediate // immediate
+
+	// PC-relative address, e.g. LD or BR.
+	ModePCRelative // pc-relative
+
+	// PC-relative address of an address, i.e. LDI or STI.
+	ModeIndirect // indirect
+
+	// Base register and offset, i.e. LDR or STR.
+	ModeBaseOffset // base+offset
+
+	// Trap vector.
+	ModeVector // vector
+)
+
+// Decode decodes an instruction without executing it. Targets of PC-relative and indirect operands
+// are computed from pc, which should be the incremented program counter, i.e. the address following
+// the instruction. Reserved instructions are decoded with no operands.
+func (ir Instruction) Decode(pc Word) DecodedInstruction {
+	dec := DecodedInstruction{
+		Instruction: ir,
+		Opcode:      ir.Opcode(),
+	}
+
+	relative := func(mode AddressingMode, n offset, regs ...GPR) {
+		dec.Mode = mode
+		dec.Registers = regs
+		dec.Offset = ir.Offset(n)
+		dec.Target = pc + dec.Offset
+	}
+
+	switch dec.Opcode {
+	case BR:
+		dec.Cond = ir.Cond()
+		relative(ModePCRelative, OFFSET9)
+	case ADD, AND:
+		if ir.Imm() {
+			dec.Mode = ModeImmediate
+			dec.Registers = []GPR{ir.DR(), ir.SR1()}
+			dec.Literal = ir.Literal(IMM5)
+		} else {
+			dec.Mode = ModeRegister
+			dec.Registers = []GPR{ir.DR(), ir.SR1(), ir.SR2()}
+		}
+	case NOT:
+		dec.Mode = ModeRegister
+		dec.Registers = []GPR{ir.DR(), ir.SR1()}
+	case LD, LEA:
+		relative(ModePCRelative, OFFSET9, ir.DR())
+	case ST:
+		relative(ModePCRelative, OFFSET9, ir.SR())
+	case LDI:
+		relative(ModeIndirect, OFFSET9, ir.DR())
+	case STI:
+		relative(ModeIndirect, OFFSET9, ir.SR())
+	case LDR, STR:
+		dec.Mode = ModeBaseOffset
+		dec.Registers = []GPR{ir.DR(), ir.SR1()}
+		dec.Offset = ir.Offset(OFFSET6)
+	case JMP:
+		dec.Mode = ModeRegister
+		dec.Registers = []GPR{ir.SR1()}
+	case JSR:
+		if ir.Relative() {
+			relative(ModePCRelative, OFFSET11)
+		} else {
+			dec.Mode = ModeRegister
+			dec.Registers = []GPR{ir.SR1()}
+		}
+	case TRAP:
+		dec.Mode = ModeVector
+		dec.Literal = ir.Vector(VECTOR8)
+	}
+
+	return dec
+}
@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstruction_Decode(tt *testing.T) {
+	t := NewTestHarness(tt)
+
+	tcs := []struct {
+		ins  Instruction
+		want DecodedInstruction
+	}{
+		{0x03fd, DecodedInstruction{
+			Opcode: BR, Mode: ModePCRelative, Cond: ConditionPositive, Offset: 0xfffd, Target: 0x2ffe,
+		}},
+		{0x1265, DecodedInstruction{
+			Opcode: ADD, Mode: ModeImmediate, Registers: []GPR{R1, R1}, Literal: 5,
+		}},
+		{0x5e07, DecodedInstruction{
+			Opcode: AND, Mode: ModeRegister, Registers: []GPR{R7, R0, R7},
+		}},
+		{0xa1ff, DecodedInstruction{
+			Opcode: LDI, Mode: ModeIndirect, Registers: []GPR{R0}, Offset: 0xffff, Target: 0x3000,
+		}},
+		{0x7abf, DecodedInstruction{
+			Opcode: STR, Mode: ModeBaseOffset, Registers: []GPR{R5, R2}, Offset: 0xffff,
+		}},
+		{0x4801, DecodedInstruction{
+			Opcode: JSR, Mode: ModePCRelative, Offset: 1, Target: 0x3002,
+		}},
+		{0x4080, DecodedInstruction{
+			Opcode: JSR, Mode: ModeRegister, Registers: []GPR{R2},
+		}},
+		{0xf025, DecodedInstruction{
+			Opcode: TRAP, Mode: ModeVector, Literal: 0x25,
+		}},
+		{0x8000, DecodedInstruction{
+			Opcode: RTI, Mode: ModeNone,
+		}},
+	}
+
+	for _, tc := range tcs {
+		tc.want.Instruction = tc.ins
+
+		if got := tc.ins.Decode(0x3001); !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%s:\nwant: %+v\ngot:  %+v", Word(tc.ins), tc.want, got)
+		}
+	}
+}
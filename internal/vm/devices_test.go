@@ -1,6 +1,9 @@
 package vm
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -22,6 +25,12 @@ var (
 	_ Device      = k
 	_ WriteDriver = k
 	_ ReadDriver  = k
+
+	// Counter is its own driver.
+	c             = &Counter{}
+	_ Device      = c
+	_ WriteDriver = c
+	_ ReadDriver  = c
 )
 
 var uninitialized = Register(0x0101)
@@ -51,8 +60,9 @@ func TestKeyboardDriver(tt *testing.T) {
 		t.Errorf("read status: %s", err)
 	} else if got == Word(uninitialized) {
 		t.Errorf("uninitialized status register: %s", addr)
-	} else if got != Word(0xffff) {
-		t.Errorf("status register unwritten: %s", addr)
+	} else if got != Word(KeyboardEnable) {
+		// Only the interrupt-enable flag is writable.
+		t.Errorf("status register: %s: want: %s, got: %s", addr, Word(KeyboardEnable), got)
 	}
 
 	addr = KBDRAddr
@@ -62,11 +72,184 @@ func TestKeyboardDriver(tt *testing.T) {
 		t.Errorf("uninitialized data register: %s:%s", addr, got)
 	}
 
+	// Reading the data register consumes the key.
 	addr = KBSRAddr
 	if got, err := reader.Read(addr); err != nil {
 		t.Errorf("read error: %s: %s", addr, err)
-	} else if got != Word(KeyboardEnable|KeyboardReady) {
-		t.Errorf("expected status ready: want: %s, got: %s", KeyboardEnable|KeyboardReady, got)
+	} else if Register(got)&KeyboardReady != 0 {
+		t.Errorf("expected status not ready: got: %s", Register(got))
+	}
+}
+
+func TestKeyboardInterrupt(tt *testing.T) {
+	t := NewTestHarness(tt)
+	vm := New(WithLogger(t.logger))
+
+	program := []Word{
+		0x0fff, // LOOP: BRnzp LOOP
+	}
+	isr := []Word{
+		0xa001, // LDI R0,KBDR
+		0x8000, // RTI
+		Word(KBDRAddr),
+	}
+
+	for i, code := range program {
+		_ = vm.Mem.store(0x3000+Word(i), code)
+	}
+
+	for i, code := range isr {
+		_ = vm.Mem.store(0x1000+Word(i), code)
+	}
+
+	_ = vm.Mem.store(ISRTable+KeyboardVector, 0x1000)
+
+	vm.PC = 0x3000
+	vm.REG[R0] = 0x0000
+	vm.PSR |= StatusZero
+	psr := vm.PSR
+
+	kbd := vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+
+	if err := kbd.Write(KBSRAddr, KeyboardEnable); err != nil {
+		t.Fatal(err)
+	}
+
+	kbd.Update('k')
+
+	if !kbd.InterruptRequested() {
+		t.Fatal("expected interrupt request")
+	}
+
+	// Step the program, servicing interrupts as Run does, until the ISR returns to the loop.
+	serviced := false
+
+	for i := 0; i < 10 && !(serviced && vm.PC == 0x3000); i++ {
+		if err := vm.Step(); err != nil {
+			t.Fatal(err)
+		} else if err := vm.serviceInterrupts(); err != nil {
+			t.Fatal(err)
+		}
+
+		if vm.PC == 0x1000 {
+			serviced = true
+
+			if vm.PSR.Priority() != KeyboardPriority || vm.PSR.Privilege() != PrivilegeSystem {
+				t.Errorf("ISR status: want: %s, got: %s", KeyboardPriority, vm.PSR)
+			}
+		}
+	}
+
+	if !serviced {
+		t.Fatal("ISR did not run")
+	} else if vm.REG[R0] != Register('k') {
+		t.Errorf("R0: want: %s, got: %s", Register('k'), vm.REG[R0])
+	} else if kbd.InterruptRequested() {
+		t.Error("KBDR not consumed")
+	} else if vm.PC != 0x3000 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3000), vm.PC)
+	} else if vm.PSR&^StatusCondition != psr&^StatusCondition {
+		t.Errorf("PSR: want: %s, got: %s", psr, vm.PSR)
+	}
+}
+
+func TestInterruptEnable(tt *testing.T) {
+	var (
+		t       = NewTestHarness(tt)
+		vm      = t.Make()
+		kbd     = vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+		display = vm.Mem.Devices.Get(DSRAddr).(*DisplayDriver)
+	)
+
+	kbd.Update('k') // Ready.
+
+	if kbd.InterruptRequested() {
+		t.Error("keyboard: interrupt requested while disabled")
+	} else if display.InterruptRequested() {
+		t.Error("display: interrupt requested while disabled")
+	}
+
+	if err := kbd.Write(KBSRAddr, KeyboardEnable); err != nil {
+		t.Fatal(err)
+	} else if err := display.Write(DSRAddr, DisplayEnabled); err != nil {
+		t.Fatal(err)
+	}
+
+	if !kbd.InterruptRequested() {
+		t.Error("keyboard: interrupt not requested while enabled")
+	} else if !display.InterruptRequested() {
+		t.Error("display: interrupt not requested while enabled")
+	}
+
+	// Writing the status register does not change the ready flag.
+	if err := kbd.Write(KBSRAddr, 0x0000); err != nil {
+		t.Fatal(err)
+	} else if err := display.Write(DSRAddr, 0x0000); err != nil {
+		t.Fatal(err)
+	}
+
+	if kbd.InterruptRequested() {
+		t.Error("keyboard: interrupt requested after disabling")
+	} else if display.InterruptRequested() {
+		t.Error("display: interrupt requested after disabling")
+	}
+
+	if status, _ := kbd.Read(KBSRAddr); Register(status) != KeyboardReady {
+		t.Errorf("keyboard status: want: %s, got: %s", KeyboardReady, status)
+	} else if status, _ := display.Read(DSRAddr); Register(status) != DisplayReady {
+		t.Errorf("display status: want: %s, got: %s", DisplayReady, status)
+	}
+
+	// Consuming the key clears the keyboard's request, even while enabled.
+	_ = kbd.Write(KBSRAddr, KeyboardEnable)
+	_, _ = kbd.Read(KBDRAddr)
+
+	if kbd.InterruptRequested() {
+		t.Error("keyboard: interrupt requested after read")
+	}
+}
+
+func TestSynchronousIO(tt *testing.T) {
+	var (
+		t      = NewTestHarness(tt)
+		events []string
+		vm     = New(WithLogger(t.logger), WithSystemContext(), WithSynchronousIO(),
+			WithDisplayListener(func(char uint16) {
+				events = append(events, "display:"+string(rune(char)))
+			}))
+		kbd = vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+	)
+
+	_ = vm.Mem.store(0x3000, 0xb000) // STI R0,#0
+	_ = vm.Mem.store(0x3001, Word(DDRAddr))
+	_ = vm.Mem.store(ISRTable+KeyboardVector, 0x1000)
+
+	vm.PC = 0x3000
+	vm.REG[R0] = Register('!')
+
+	// A key is pressed, with interrupts enabled, before the program writes to the display.
+	_ = kbd.Write(KBSRAddr, KeyboardEnable)
+	kbd.Update('k')
+
+	if err := vm.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The display is ready as soon as the instruction completes, without waiting.
+	if dsr, err := vm.Mem.Devices.Load(DSRAddr); err != nil {
+		t.Fatal(err)
+	} else if dsr&DisplayReady == 0 {
+		t.Errorf("DSR: want: ready, got: %s", dsr)
+	}
+
+	if err := vm.serviceInterrupts(); err != nil {
+		t.Fatal(err)
+	} else if vm.PC == 0x1000 {
+		events = append(events, "interrupt")
+	}
+
+	if want := []string{"display:!", "interrupt"}; !slices.Equal(want, events) {
+		t.Errorf("events: want: %v, got: %v", want, events)
 	}
 }
 
@@ -124,3 +307,203 @@ func TestDisplayDriver(tt *testing.T) {
 		t.Errorf("expected status: %s, got: %s", Word(DisplayReady), tried)
 	}
 }
+
+func TestStringDisplay(tt *testing.T) {
+	t := NewTestHarness(tt)
+	withDisplay, display := WithStringDisplay()
+	cpu := New(WithLogger(t.logger), withDisplay)
+
+	for _, char := range "hi!" {
+		// Poll until the display is ready, as a program would.
+		for {
+			if dsr, err := cpu.Mem.Devices.Load(DSRAddr); err != nil {
+				t.Fatal(err)
+			} else if dsr&DisplayReady != 0 {
+				break
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		if err := cpu.Mem.Devices.Store(DDRAddr, Register(char)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := display.String(); got != "hi!" {
+		t.Errorf("want: %q, got: %q", "hi!", got)
+	}
+}
+
+func TestScriptedKeyboard(tt *testing.T) {
+	t := NewTestHarness(tt)
+	vm := New(WithLogger(t.logger), WithSystemContext(), WithKeyboardScript(strings.NewReader("abc")))
+
+	// Read characters by polling the keyboard, as GETC does, and store them in a buffer.
+	program := []Word{
+		0xa207, // POLL: LDI R1,KBSR
+		0x07fe, // BRzp POLL
+		0xa006, // LDI R0,KBDR
+		0x7080, // STR R0,R2,#0
+		0x14a1, // ADD R2,R2,#1
+		0x16ff, // ADD R3,R3,#-1
+		0x03f9, // BRp POLL
+		0xffff, // DONE
+		Word(KBSRAddr),
+		Word(KBDRAddr),
+	}
+
+	for i, code := range program {
+		_ = vm.Mem.store(0x3000+Word(i), code)
+	}
+
+	vm.PC = 0x3000
+	vm.REG[R2] = 0x4000
+	vm.REG[R3] = 3
+
+	for vm.PC != 0x3007 {
+		if err := vm.Step(); err != nil {
+			t.Fatal(err)
+		} else if vm.InstructionCount() > 1000 {
+			t.Fatalf("input not read: PC: %s", vm.PC)
+		}
+	}
+
+	for i, want := range "abc" {
+		var got Register
+
+		_ = vm.Mem.load(0x4000+Word(i), &got)
+
+		if got != Register(want) {
+			t.Errorf("buffer[%d]: want: %q, got: %q", i, want, rune(got))
+		}
+	}
+
+	kbd := vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+
+	if kbd.EOF() {
+		t.Error("unexpected EOF")
+	}
+
+	// Once the script is exhausted, the keyboard is never ready.
+	for i := 0; i < 3; i++ {
+		if status, _ := kbd.Read(KBSRAddr); Register(status)&KeyboardReady != 0 {
+			t.Errorf("keyboard ready: %s", Register(status))
+		}
+	}
+
+	if !kbd.EOF() {
+		t.Error("expected EOF")
+	}
+}
+
+func TestKeyboard_Buffer(tt *testing.T) {
+	t := NewTestHarness(tt)
+	vm := New(WithLogger(t.logger))
+	kbd := vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+
+	keys := "hello"
+
+	for _, key := range keys {
+		kbd.Update(uint16(key))
+	}
+
+	if depth := kbd.Depth(); depth != len(keys) {
+		t.Errorf("depth: want: %d, got: %d", len(keys), depth)
+	}
+
+	for _, want := range keys {
+		if status, _ := kbd.Read(KBSRAddr); Register(status)&KeyboardReady == 0 {
+			t.Fatalf("keyboard not ready: %s", Register(status))
+		}
+
+		if got, _ := kbd.Read(KBDRAddr); got != Word(want) {
+			t.Errorf("key: want: %q, got: %q", want, rune(got))
+		}
+	}
+
+	if status, _ := kbd.Read(KBSRAddr); Register(status)&KeyboardReady != 0 {
+		t.Errorf("keyboard ready: %s", Register(status))
+	} else if depth := kbd.Depth(); depth != 0 {
+		t.Errorf("depth: want: 0, got: %d", depth)
+	}
+}
+
+func TestKeyboard_Capacity(tt *testing.T) {
+	t := NewTestHarness(tt)
+
+	const capacity = 4
+
+	kbd := NewKeyboardWithCapacity(capacity)
+	keys := "abcde" // The data register and a full buffer.
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for _, key := range keys {
+			kbd.Update(uint16(key))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("update blocked before buffer was full")
+	}
+
+	// Another key blocks until a key is read.
+	blocked := make(chan struct{})
+
+	go func() {
+		defer close(blocked)
+		kbd.Update('f')
+	}()
+
+	select {
+	case <-blocked:
+		t.Fatal("update did not block while buffer was full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	for _, want := range keys + "f" {
+		if got, _ := kbd.Read(KBDRAddr); got != Word(want) {
+			t.Errorf("key: want: %q, got: %q", want, rune(got))
+		}
+
+		if want == 'a' {
+			<-blocked
+		}
+	}
+
+	if depth := kbd.Depth(); depth != 0 {
+		t.Errorf("depth: want: 0, got: %d", depth)
+	}
+}
+
+func TestCounter(tt *testing.T) {
+	t := NewTestHarness(tt)
+	vm := New(WithLogger(t.logger))
+	vm.stats.instructions = 0x1_2345_ffff
+
+	low, err := vm.Mem.Devices.Load(CNTLAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The low word wraps before the high word is read.
+	vm.stats.instructions++
+
+	high, err := vm.Mem.Devices.Load(CNTHAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if low != 0xffff || high != 0x2345 {
+		t.Errorf("count: want: %s %s, got: %s %s", Register(0x2345), Register(0xffff), high, low)
+	}
+
+	if err := vm.Mem.Devices.Store(CNTLAddr, 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("store: want: %v, got: %v", ErrReadOnly, err)
+	}
+}
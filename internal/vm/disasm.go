@@ -0,0 +1,142 @@
+package vm
+
+// disasm.go translates instructions back into assembly language.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Disassemble returns the instruction as an LCASM source statement, e.g. "ADD R0,R1,#-1". Offsets
+// are relative to the incremented program counter, as they are in source code, and trap vectors are
+// written in hex. Reserved and malformed instructions, and branches with no condition codes but a
+// non-zero offset, are written as data, i.e. ".FILL x...".
+func (i Instruction) Disassemble() string {
+	var (
+		dr  = i.DR()
+		sr1 = i.SR1()
+	)
+
+	switch i.Opcode() {
+	case BR:
+		cond := i.Cond()
+
+		// Without condition codes, the branch is never taken. Only a zero word is written as NOP,
+		// though: otherwise, the offset would be lost, e.g. for data that looks like a branch.
+		if cond == 0 && i.Offset(OFFSET9) == 0 {
+			return "NOP"
+		} else if cond == 0 {
+			return fmt.Sprintf(".FILL x%04X", uint16(i))
+		}
+
+		var flags strings.Builder
+
+		if cond.Negative() {
+			flags.WriteByte('n')
+		}
+
+		if cond.Zero() {
+			flags.WriteByte('z')
+		}
+
+		if cond.Positive() {
+			flags.WriteByte('p')
+		}
+
+		return fmt.Sprintf("BR%s %s", flags.String(), disasmOffset(i, OFFSET9))
+	case ADD, AND:
+		mnemonic := "ADD"
+		if i.Opcode() == AND {
+			mnemonic = "AND"
+		}
+
+		if i.Imm() {
+			return fmt.Sprintf("%s %s,%s,%s", mnemonic, dr, sr1, disasmLiteral(i, IMM5))
+		}
+
+		return fmt.Sprintf("%s %s,%s,%s", mnemonic, dr, sr1, i.SR2())
+	case NOT:
+		return fmt.Sprintf("NOT %s,%s", dr, sr1)
+	case LD:
+		return fmt.Sprintf("LD %s,%s", dr, disasmOffset(i, OFFSET9))
+	case LDI:
+		return fmt.Sprintf("LDI %s,%s", dr, disasmOffset(i, OFFSET9))
+	case LEA:
+		return fmt.Sprintf("LEA %s,%s", dr, disasmOffset(i, OFFSET9))
+	case ST:
+		return fmt.Sprintf("ST %s,%s", i.SR(), disasmOffset(i, OFFSET9))
+	case STI:
+		return fmt.Sprintf("STI %s,%s", i.SR(), disasmOffset(i, OFFSET9))
+	case LDR:
+		return fmt.Sprintf("LDR %s,%s,%s", dr, sr1, disasmOffset(i, OFFSET6))
+	case STR:
+		return fmt.Sprintf("STR %s,%s,%s", i.SR(), sr1, disasmOffset(i, OFFSET6))
+	case JMP:
+		if sr1 == RETP {
+			return "RET"
+		}
+
+		return fmt.Sprintf("JMP %s", sr1)
+	case JSR:
+		if i.Relative() {
+			return fmt.Sprintf("JSR %s", disasmOffset(i, OFFSET11))
+		}
+
+		return fmt.Sprintf("JSRR %s", sr1)
+	case TRAP:
+		return fmt.Sprintf("TRAP x%02X", uint16(i.Vector(VECTOR8)))
+	case RTI:
+		return "RTI"
+	default:
+		return fmt.Sprintf(".FILL x%04X", uint16(i))
+	}
+}
+
+// EntryBranch returns the target of an unconditional branch at the object's origin. Tools such as
+// lc3tools place data before a program's first instruction and begin the object with a branch over
+// it, so the target is the program's real entry point.
+func (obj ObjectCode) EntryBranch() (Word, bool) {
+	if len(obj.Code) == 0 {
+		return 0, false
+	}
+
+	ins := Instruction(obj.Code[0])
+
+	if ins.Opcode() != BR || ins.Cond() != ConditionNegative|ConditionZero|ConditionPositive {
+		return 0, false
+	}
+
+	return obj.Orig + 1 + ins.Offset(OFFSET9), true
+}
+
+// Disassemble writes a listing of the object's code, one word per line, e.g. "0x3000: 0x1265  ADD
+// R1,R1,#5". If entry is true and the object begins with an entry branch, the first line is
+// annotated with the entry point.
+func (obj ObjectCode) Disassemble(out io.Writer, entry bool) error {
+	target, isEntry := obj.EntryBranch()
+
+	for i, word := range obj.Code {
+		line := fmt.Sprintf("%s: %s  %s", obj.Orig+Word(i), word, Instruction(word).Disassemble())
+
+		if i == 0 && entry && isEntry {
+			line += fmt.Sprintf(" ; entry: %s", target)
+		}
+
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disasmOffset formats an n-bit, sign-extended offset as a decimal literal.
+func disasmOffset(i Instruction, n offset) string {
+	return "#" + i.Offset(n).SignedString()
+}
+
+// disasmLiteral formats an n-bit, sign-extended literal as a decimal literal.
+func disasmLiteral(i Instruction, n literal) string {
+	return "#" + i.Literal(n).SignedString()
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smoynes/elsie/internal/log"
+)
+
+func TestDisassemble(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		ins  Instruction
+		want string
+	}{
+		{0x0000, "NOP"},
+		{0x0048, ".FILL x0048"},
+		{0x0e01, "BRnzp #1"},
+		{0x03fd, "BRp #-3"},
+		{0x1265, "ADD R1,R1,#5"},
+		{0x127f, "ADD R1,R1,#-1"},
+		{0x1642, "ADD R3,R1,R2"},
+		{0x5260, "AND R1,R1,#0"},
+		{0x5e07, "AND R7,R0,R7"},
+		{0x96bf, "NOT R3,R2"},
+		{0x2005, "LD R0,#5"},
+		{0xa1ff, "LDI R0,#-1"},
+		{0xe002, "LEA R0,#2"},
+		{0x3e10, "ST R7,#16"},
+		{0xb001, "STI R0,#1"},
+		{0x6283, "LDR R1,R2,#3"},
+		{0x7abf, "STR R5,R2,#-1"},
+		{0xc080, "JMP R2"},
+		{0xc1c0, "RET"},
+		{0x4801, "JSR #1"},
+		{0x4080, "JSRR R2"},
+		{0xf025, "TRAP x25"},
+		{0x8000, "RTI"},
+		{0xd123, ".FILL xD123"},
+	}
+
+	for _, tc := range tcs {
+		if got := tc.ins.Disassemble(); got != tc.want {
+			tt.Errorf("%s: want: %q, got: %q", Word(tc.ins), tc.want, got)
+		}
+	}
+}
+
+func TestTraceStep(tt *testing.T) {
+	t := NewTestHarness(tt)
+	buf := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: log.Trace}))
+	cpu := New(WithLogger(logger))
+
+	_ = cpu.Mem.store(0x3000, 0x1265) // ADD R1,R1,#5
+
+	cpu.PC = 0x3000
+	cpu.REG[R1] = 0x0001
+
+	if err := cpu.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, `ASM="ADD R1,R1,#5"`) {
+		t.Errorf("trace: missing disassembly: %s", out)
+	}
+
+	if !strings.Contains(out, "R1:0x0001->0x0006") {
+		t.Errorf("trace: missing register delta: %s", out)
+	}
+}
+
+func TestObjectCode_Disassemble(tt *testing.T) {
+	t := NewTestHarness(tt)
+	machine := t.Make()
+
+	// An lc3tools-style object: a bare, big-endian origin and code that begins with a branch over
+	// data to the program's entry point.
+	b, err := os.ReadFile(filepath.Join("testdata", "lc3tools.obj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The object has no entry point, so loading it does not move the program counter.
+	machine.PC = 0x4000
+
+	if count, err := NewLoader(machine).LoadObject(b); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x4000 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x4000), machine.PC)
+	} else if count != 6 {
+		t.Errorf("count: want: 6, got: %d", count)
+	} else if view := machine.Mem.View(); view[0x3000] != 0x0e02 || view[0x3005] != 0xf025 {
+		t.Errorf("memory: want: 0x0e02 .. 0xf025, got: %s .. %s", view[0x3000], view[0x3005])
+	}
+
+	obj, err := ReadObjectCode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Loaded as a program, it starts at its origin, i.e. the branch to the entry point.
+	if cpu := New(WithLogger(t.logger), WithProgram(obj)); cpu.PC != 0x3000 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3000), cpu.PC)
+	}
+
+	if entry, ok := obj.EntryBranch(); !ok || entry != 0x3003 {
+		t.Errorf("entry: want: 0x3003, got: %s, %t", entry, ok)
+	}
+
+	want := `0x3000: 0x0e02  BRnzp #2 ; entry: 0x3003
+0x3001: 0x0048  .FILL x0048
+0x3002: 0x0000  NOP
+0x3003: 0xe1fd  LEA R0,#-3
+0x3004: 0xf022  TRAP x22
+0x3005: 0xf025  TRAP x25
+`
+
+	var buf bytes.Buffer
+
+	if err := obj.Disassemble(&buf, true); err != nil {
+		t.Fatal(err)
+	} else if got := buf.String(); got != want {
+		t.Errorf("listing:\nwant: %s\ngot:  %s", want, got)
+	}
+
+	buf.Reset()
+
+	if err := obj.Disassemble(&buf, false); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(buf.String(), "entry") {
+		t.Errorf("listing: unexpected annotation: %s", buf.String())
+	}
+}
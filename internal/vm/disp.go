@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -33,7 +34,10 @@ func NewDisplay() *Display {
 	return &Display{}
 }
 
-// Display status-register bit-fields for ready and interrupt-enabled flags.
+// Display status-register bit-fields for ready and interrupt-enabled flags. As with the keyboard,
+// the display clears the ready flag when the data register is written and the driver sets it when
+// the data is displayed. Programs set or clear the interrupt-enable flag by writing the status
+// register; neither the display nor the driver changes it, except to clear it on Init.
 const (
 	DisplayReady   = Register(1 << 15) // Ready
 	DisplayEnabled = Register(1 << 14) // IE
@@ -48,7 +52,8 @@ func (disp *Display) Init(_ *LC3, _ []Word) {
 }
 
 // Write updates the display data register with the given data. It clears the ready flag and the
-// driver should set it after the data is successfully displayed.
+// driver should set it after the data is successfully displayed. The interrupt-enable flag is not
+// changed.
 func (disp *Display) Write(data Register) {
 	// Clear ready flag.
 	disp.dsr &^= DisplayReady
@@ -95,6 +100,12 @@ type DisplayDriver struct {
 	// functions must not block, fail, or panic. The value should be written to a buffered channel
 	// or be otherwise asynchronously handled.
 	list []func(uint16)
+
+	// Capture that records written data synchronously, if any.
+	capture *StringDisplay
+
+	// Whether listeners are notified synchronously. See WithSynchronousIO.
+	sync bool
 }
 
 // NewDisplayDriver creates a new driver for the display and allocates resources. The driver has
@@ -141,8 +152,9 @@ func (driver *DisplayDriver) Read(addr Word) (Word, error) {
 	}
 }
 
-// InterruptRequested returns true when the display raises an interrupt request. For our purposes,
-// the display never interrupts the CPU.
+// InterruptRequested returns true if the display is ready and interrupts are enabled. That is, both
+// the R and IE bits are set in the status register. For our purposes, the display never interrupts
+// the CPU: the driver is not registered with the interrupt controller.
 func (driver *DisplayDriver) InterruptRequested() bool {
 	driver.mut.Lock()
 	defer driver.mut.Unlock()
@@ -152,7 +164,8 @@ func (driver *DisplayDriver) InterruptRequested() bool {
 }
 
 // Write sets the data or status registers of the display device. When the data register is written,
-// listeners are asynchronously notified.
+// listeners are asynchronously notified. Only the interrupt-enable flag of the status register is
+// writable: the ready flag is controlled by the device and driver.
 func (driver *DisplayDriver) Write(addr Word, value Register) error {
 	driver.mut.Lock()
 	defer driver.mut.Unlock()
@@ -160,7 +173,9 @@ func (driver *DisplayDriver) Write(addr Word, value Register) error {
 	if addr == driver.dataAddr {
 		return driver.write(value)
 	} else if addr == driver.statusAddr {
-		driver.handle.device.SetDSR(value)
+		device := driver.handle.device
+		device.SetDSR(device.DSR()&^DisplayEnabled | value&DisplayEnabled)
+
 		return nil
 	} else {
 		return fmt.Errorf("write: %w: %s:%s", ErrNoDevice, addr, driver)
@@ -170,18 +185,38 @@ func (driver *DisplayDriver) Write(addr Word, value Register) error {
 // Listen adds a display listener. Each time a character is displayed, all listeners are called
 // sequentially.
 func (driver *DisplayDriver) Listen(listener func(uint16)) {
+	driver.mut.Lock()
+	defer driver.mut.Unlock()
+
 	driver.list = append(driver.list, listener)
 }
 
 // write writes the value to the display device and asynchronously notifies the listeners of the
-// good news: there is data to be seen!
+// good news: there is data to be seen! In synchronous mode, listeners are notified, and the ready
+// flag set, before write returns.
 func (driver *DisplayDriver) write(value Register) error {
 	device := driver.handle.device
 	device.Write(value)
 
+	if driver.capture != nil {
+		driver.capture.write(uint16(value))
+	}
+
+	listeners := driver.list // The caller holds the lock.
+
+	if driver.sync {
+		for _, fn := range listeners {
+			fn(uint16(value))
+		}
+
+		device.SetDSR(device.DSR() | DisplayReady)
+
+		return nil
+	}
+
 	// Asynchronously notify listeners of the write.
 	go func() {
-		for _, fn := range driver.list {
+		for _, fn := range listeners {
 			fn(uint16(value))
 		}
 
@@ -216,3 +251,58 @@ func (driver *DisplayDriver) device() string {
 
 	return "DISP(DRIVER)"
 }
+
+// StringDisplay captures the data written to the display. Unlike listeners, which are notified
+// asynchronously, the capture is updated before the write to the display register completes, so the
+// data written by an instruction can be read as soon as the instruction has executed. It is useful
+// for testing programs that output text without waiting for the display.
+type StringDisplay struct {
+	mut     sync.Mutex
+	written []uint16
+}
+
+// WithStringDisplay is an option function that configures a StringDisplay to capture the machine's
+// display output.
+func WithStringDisplay() (OptionFn, *StringDisplay) {
+	disp := &StringDisplay{}
+
+	return func(vm *LC3, late bool) {
+		if late {
+			driver := vm.Mem.Devices.Get(DDRAddr).(*DisplayDriver)
+
+			driver.mut.Lock()
+			defer driver.mut.Unlock()
+
+			driver.capture = disp
+		}
+	}, disp
+}
+
+func (disp *StringDisplay) write(data uint16) {
+	disp.mut.Lock()
+	defer disp.mut.Unlock()
+
+	disp.written = append(disp.written, data)
+}
+
+// String returns the characters displayed so far.
+func (disp *StringDisplay) String() string {
+	disp.mut.Lock()
+	defer disp.mut.Unlock()
+
+	var buf strings.Builder
+
+	for _, char := range disp.written {
+		buf.WriteRune(rune(char))
+	}
+
+	return buf.String()
+}
+
+// Written returns a copy of the data written to the display so far.
+func (disp *StringDisplay) Written() []uint16 {
+	disp.mut.Lock()
+	defer disp.mut.Unlock()
+
+	return append([]uint16(nil), disp.written...)
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/smoynes/elsie/internal/log"
 )
@@ -13,9 +14,101 @@ import (
 // ErrHalted is a wrapped error returned when the CPU is stepped while the HALT flag in MCR is set.
 var ErrHalted = errors.New("halted")
 
-// Run starts and executes the instruction cycle until the program halts.
+// ExecError is returned by Step when an instruction fails. It records the address and encoding of
+// the failing instruction. If the instruction could not be fetched, IR is zero.
+type ExecError struct {
+	PC  Word        // Address of the instruction.
+	IR  Instruction // Instruction register.
+	Err error       // Error cause.
+}
+
+func (ee *ExecError) Error() string {
+	if ee.IR == 0 {
+		return fmt.Sprintf("ins: %s: %s", ee.PC, ee.Err)
+	}
+
+	return fmt.Sprintf("ins: %s: %s: %s", ee.PC, ee.IR.Disassemble(), ee.Err)
+}
+
+// Unwrap returns the error cause.
+func (ee *ExecError) Unwrap() error {
+	return ee.Err
+}
+
+// ErrStepLimit is a wrapped error returned by RunN when the program executes its budget of
+// instructions without halting.
+var ErrStepLimit = errors.New("step limit")
+
+// ErrOverflow is a wrapped error returned by Step when an addition overflows and the machine traps
+// overflow. See WithOverflowTrap.
+var ErrOverflow = errors.New("signed overflow")
+
+// HaltReason records why the machine last stopped running.
+type HaltReason uint8
+
+// Halt reasons.
+const (
+	// The machine has not stopped running.
+	HaltNone HaltReason = iota // NONE
+
+	// The program called the HALT trap.
+	HaltTrap // TRAP
+
+	// The RUN flag in MCR was cleared, other than by the HALT trap.
+	HaltMCR // MCR
+
+	// An instruction or interrupt failed.
+	HaltError // ERROR
+
+	// The context was cancelled or its deadline passed.
+	HaltCancelled // CANCELLED
+
+	// The program executed its budget of instructions.
+	HaltStepLimit // STEP LIMIT
+)
+
+// LastHalt returns the reason that Run or RunN last returned.
+func (vm *LC3) LastHalt() HaltReason {
+	return vm.halt
+}
+
+// haltReason returns the reason that the instruction cycle stopped with an error, if any.
+func (vm *LC3) haltReason(err error) HaltReason {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return HaltCancelled
+	case errors.Is(err, ErrStepLimit):
+		return HaltStepLimit
+	case err != nil:
+		return HaltError
+	case vm.haltFrame != nil:
+		return HaltTrap
+	default:
+		return HaltMCR
+	}
+}
+
+// Run starts and executes the instruction cycle until the program halts. If the machine has a reset
+// vector, execution begins at the address it holds. See WithResetVector.
 func (vm *LC3) Run(ctx context.Context) error {
-	var err error
+	return vm.RunN(ctx, math.MaxUint64)
+}
+
+// RunN starts and executes the instruction cycle until the program halts or until it has executed
+// limit instructions, in which case an ErrStepLimit is returned. Unlike a context deadline, the limit
+// does not depend on how fast the machine runs.
+func (vm *LC3) RunN(ctx context.Context, limit uint64) error {
+	var (
+		err   error
+		steps uint64
+	)
+
+	vm.halt = HaltNone
+
+	if err := vm.boot(); err != nil {
+		vm.halt = HaltError
+		return err
+	}
 
 	vm.log.Info("START", log.Group("STATE", vm))
 
@@ -23,6 +116,8 @@ func (vm *LC3) Run(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			vm.log.Warn("CANCELLED")
+			vm.halt = HaltCancelled
+
 			return ctx.Err()
 		default:
 		}
@@ -31,9 +126,15 @@ func (vm *LC3) Run(ctx context.Context) error {
 			break
 		} else if !vm.MCR.Running() {
 			break
+		} else if steps >= limit {
+			err = fmt.Errorf("%w: %d instructions", ErrStepLimit, limit)
+			break
 		}
 
-		if err = vm.Step(); err != nil {
+		err = vm.Step()
+		steps++
+
+		if err != nil {
 			break
 		}
 
@@ -44,6 +145,8 @@ func (vm *LC3) Run(ctx context.Context) error {
 		}
 	}
 
+	vm.halt = vm.haltReason(err)
+
 	if err != nil {
 		vm.log.Error(
 			"HALTED (HCF)",
@@ -62,7 +165,7 @@ func (vm *LC3) Run(ctx context.Context) error {
 
 // serviceInterrupts invokes the highest priority interrupt service routine, if any.
 func (vm *LC3) serviceInterrupts() error {
-	if vec, intr := vm.INT.Requested(vm.PSR.Priority()); intr {
+	if pl, vec, intr := vm.INT.request(vm.PSR.Priority()); intr {
 		isr := &interrupt{
 			table: ISRTable,
 			vec:   Word(vec), // TODO: change type to uint8?
@@ -72,6 +175,22 @@ func (vm *LC3) serviceInterrupts() error {
 
 		vm.log.Debug("INTR raised", "ISR", isr)
 
+		// Changes made servicing the interrupt are journaled with the preceding step.
+		if vm.journal != nil {
+			vm.Mem.entry = vm.journal.last()
+			defer func() { vm.Mem.entry = nil }()
+		}
+
+		// Service routines run with system privileges and stack, and at the device's priority so
+		// that lower priority interrupts are masked until the routine returns.
+		if vm.PSR.Privilege() == PrivilegeUser {
+			vm.USP = vm.REG[SP]
+			vm.REG[SP] = vm.SSP
+			vm.PSR &= ^StatusUser
+		}
+
+		vm.PSR = vm.PSR&^StatusPriority | ProcessorStatus(pl)<<8&StatusPriority
+
 		if err := isr.Handle(vm); err != nil {
 			// TODO: Double fault handler!
 			return fmt.Errorf("int: %w", err)
@@ -100,23 +219,52 @@ func (vm *LC3) serviceInterrupts() error {
 //   - store result: store operation result in memory using the computed
 //     address.
 //
-// An instruction implements methods according to its operational semantics; see [operation].
+// An instruction implements methods according to its operational semantics; see [operation]. If the
+// instruction fails, an ExecError is returned.
 func (vm *LC3) Step() error {
 	if !vm.MCR.Running() {
 		return fmt.Errorf("ins: %w", ErrHalted)
-	} else if err := vm.Fetch(); err != nil {
-		return fmt.Errorf("ins: %w", err)
 	}
 
+	if vm.journal != nil {
+		vm.Mem.entry = vm.journal.begin(vm)
+		defer func() { vm.Mem.entry = nil }()
+	}
+
+	var (
+		trace  = vm.log.Enabled(context.Background(), log.Trace)
+		pc     = vm.PC
+		before = vm.REG
+	)
+
+	if err := vm.Fetch(); err != nil {
+		return &ExecError{PC: Word(pc), Err: err}
+	}
+
+	_ = vm.Mem.watched() // Clear any watchpoint hit outside of an instruction, e.g. by the loader.
+
 	op := vm.Decode()
+	vm.stats.count(vm.IR.Opcode())
 	vm.EvalAddress(op)
 	vm.FetchOperands(op)
 	vm.Execute(op)
 	vm.Writeback(op)
 
+	if trace {
+		vm.traceStep(Word(pc), before)
+	}
+
+	if vm.stepListener != nil {
+		vm.stepListener(Word(pc), vm)
+	}
+
 	if err := op.Err(); err == nil {
 		vm.log.Debug("executed instruction", "OP", op)
 
+		if hit := vm.Mem.watched(); hit != nil {
+			return fmt.Errorf("ins: %w", hit)
+		}
+
 		return nil
 	} else if errors.Is(err, &interrupt{}) {
 		handler := err.(interruptableError) //nolint:errorlint
@@ -125,14 +273,25 @@ func (vm *LC3) Step() error {
 
 		if err := handler.Handle(vm); err != nil {
 			vm.log.Error("interrupt service routine error", "ERR", err)
-			return fmt.Errorf("step: %w", err)
+			return &ExecError{PC: Word(pc), IR: vm.IR, Err: fmt.Errorf("step: %w", err)}
+		}
+
+		// Remember the frame of the HALT trap so that halting from within it can be told apart
+		// from clearing MCR directly.
+		if te, ok := handler.(*trapError); ok && te.vec == Word(TrapHALT) {
+			frame := Word(vm.REG[SP])
+			vm.haltFrame = &frame
+		}
+
+		if hit := vm.Mem.watched(); hit != nil {
+			return fmt.Errorf("ins: %w", hit)
 		}
 
 		return nil
 	} else { // err != nil
 		vm.log.Error("instruction error", "OP", op, "ERR", err)
 
-		return fmt.Errorf("ins: %w", err)
+		return &ExecError{PC: Word(pc), IR: vm.IR, Err: err}
 	}
 }
 
@@ -145,6 +304,11 @@ func (vm *LC3) Fetch() error {
 	}
 
 	vm.IR = Instruction(vm.Mem.MDR)
+
+	if vm.Mem.poisoned(Word(vm.PC), Word(vm.IR)) {
+		vm.log.Warn("fetched uninitialized memory", "PC", vm.PC, "IR", vm.IR)
+	}
+
 	vm.PC++
 
 	vm.log.Debug("fetched", "IR", vm.IR)
@@ -152,55 +316,56 @@ func (vm *LC3) Fetch() error {
 	return nil
 }
 
-// Decode the instruction from IR.
+// Decode the instruction from IR. The operation returned is owned by the machine and is reset and
+// reused by the next call to Decode, so decoding does not allocate.
 func (vm *LC3) Decode() operation {
 	var oper operation
 
 	switch vm.IR.Opcode() {
 	case BR:
-		oper = &br{}
+		oper = &vm.ops.br
 	case AND:
 		if vm.IR.Imm() {
-			oper = &andImm{}
+			oper = &vm.ops.andImm
 		} else {
-			oper = &and{}
+			oper = &vm.ops.and
 		}
 	case ADD:
 		if vm.IR.Imm() {
-			oper = &addImm{}
+			oper = &vm.ops.addImm
 		} else {
-			oper = &add{}
+			oper = &vm.ops.add
 		}
 	case NOT:
-		oper = &not{}
+		oper = &vm.ops.not
 	case LD:
-		oper = &ld{}
+		oper = &vm.ops.ld
 	case LDI:
-		oper = &ldi{}
+		oper = &vm.ops.ldi
 	case LDR:
-		oper = &ldr{}
+		oper = &vm.ops.ldr
 	case LEA:
-		oper = &lea{}
+		oper = &vm.ops.lea
 	case ST:
-		oper = &st{}
+		oper = &vm.ops.st
 	case STI:
-		oper = &sti{}
+		oper = &vm.ops.sti
 	case STR:
-		oper = &str{}
+		oper = &vm.ops.str
 	case JMP, RET:
-		oper = &jmp{}
+		oper = &vm.ops.jmp
 	case JSR, JSRR:
 		if vm.IR.Relative() {
-			oper = &jsr{}
+			oper = &vm.ops.jsr
 		} else {
-			oper = &jsrr{}
+			oper = &vm.ops.jsrr
 		}
 	case TRAP:
-		oper = &trap{}
+		oper = &vm.ops.trap
 	case RTI:
-		oper = &rti{}
+		oper = &vm.ops.rti
 	case RESV:
-		oper = &resv{}
+		oper = &vm.ops.resv
 	}
 
 	oper.Decode(vm)
@@ -324,6 +489,29 @@ func (vm *LC3) Writeback(op operation) {
 	}
 }
 
+// operations holds an operation of each type for Decode to reuse.
+type operations struct {
+	br     br
+	not    not
+	and    and
+	andImm andImm
+	add    add
+	addImm addImm
+	ld     ld
+	ldi    ldi
+	ldr    ldr
+	lea    lea
+	st     st
+	sti    sti
+	str    str
+	jmp    jmp
+	jsr    jsr
+	jsrr   jsrr
+	trap   trap
+	rti    rti
+	resv   resv
+}
+
 // An operation represents a single CPU instruction as it is being executed by
 // the machine. The instruction's semantics are defined by implementing optional
 // interfaces for each execution stage: [addressable], [fetchable], [executable],
@@ -85,17 +85,26 @@ func (i *Interrupt) Register(priority Priority, isr ISR) {
 	}
 }
 
+// Requested returns the vector of the highest priority device that has requested an interrupt, if
+// its priority is greater than the current priority. As the LC-3 specifies, a request at the current
+// priority is masked, e.g. so that a service routine is not interrupted by its own device.
 func (i Interrupt) Requested(curr Priority) (uint8, bool) {
+	_, vec, ok := i.request(curr)
+	return vec, ok
+}
+
+// request returns the priority and vector of the highest priority interrupt request, if any.
+func (i Interrupt) request(curr Priority) (Priority, uint8, bool) {
 	for pl := len(i.idt) - 1; pl > int(curr); pl-- {
 		idt := i.idt[pl]
 		if idt.driver == nil {
 			continue
 		} else if idt.driver.InterruptRequested() {
-			return idt.vector, true
+			return Priority(pl), idt.vector, true
 		}
 	}
 
-	return 0, false
+	return 0, 0, false
 }
 
 // An interruptableError is returned from an instruction cycle to signal the CPU to jump to an
@@ -132,6 +141,11 @@ type interrupt struct {
 }
 
 func (intr *interrupt) Handle(cpu *LC3) error {
+	// Check that the whole frame fits, so that an overflow does not leave half a frame on the stack.
+	if err := cpu.stackRoom(2); err != nil {
+		return err
+	}
+
 	err := cpu.PushStack(Word(intr.psr))
 	if err != nil {
 		return err
@@ -226,15 +240,23 @@ func (ae *acv) String() string {
 // Trap handler table and defined vectors in the table.
 const (
 	TrapTable = Word(0x0000) // TRAP (0x0000:0x00ff)
+	TrapGETC  = uint8(0x20)  // GETC
 	TrapOUT   = uint8(0x21)  // OUT
 	TrapPUTS  = uint8(0x22)  // PUTS
+	TrapIN    = uint8(0x23)  // IN
+	TrapPUTSP = uint8(0x24)  // PUTSP
 	TrapHALT  = uint8(0x25)  // HALT
+	TrapCOUNT = uint8(0x41)  // COUNT
 )
 
 // Interrupt service routine table and defined service routines.
 const (
 	ISRTable    = Word(0x0100) // IVT (0x0100:0x01ff)
 	ISRKeyboard = Word(0x80)   // KBD
+
+	// ResetVectorAddr is the conventional location of the reset vector, i.e. the last entry in
+	// the table. See WithResetVector.
+	ResetVectorAddr = Word(0x01ff)
 )
 
 // Exception vector table and defined vectors in the table.
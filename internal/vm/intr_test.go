@@ -39,3 +39,35 @@ func TestInterrupt(tt *testing.T) {
 		t.Errorf("expected display interrupt vector: want: %0#2x, got: %0#2x", 0xdd, vec)
 	}
 }
+
+func TestInterrupt_Masking(tt *testing.T) {
+	var (
+		t      = NewTestHarness(tt)
+		intr   = Interrupt{}
+		disp   = NewDisplay()
+		driver = NewDisplayDriver(disp)
+	)
+
+	driver.handle.Init(nil, nil)
+	driver.handle.device.dsr = DisplayEnabled | DisplayReady
+
+	intr.Register(PL3, ISR{vector: 0xdd, driver: driver})
+
+	tcs := []struct {
+		curr Priority
+		want bool
+	}{
+		{PL4, false},
+		{PL3, false},
+		{PL2, true},
+		{PL0, true},
+	}
+
+	for _, tc := range tcs {
+		if vec, ok := intr.Requested(tc.curr); ok != tc.want {
+			t.Errorf("%s: requested: want: %t, got: %t", tc.curr, tc.want, ok)
+		} else if ok && vec != 0xdd {
+			t.Errorf("%s: vector: want: %0#2x, got: %0#2x", tc.curr, 0xdd, vec)
+		}
+	}
+}
@@ -37,6 +37,8 @@ const (
 	KBDRAddr Word = 0xfe02
 	DSRAddr  Word = 0xfe04 // Display status and data registers.
 	DDRAddr  Word = 0xfe06
+	CNTLAddr Word = 0xfe08 // Instruction counter low and high words. See Counter.
+	CNTHAddr Word = 0xfe0a
 	PSRAddr  Word = 0xfffc // Processor status register. Privileged.
 	MCRAddr  Word = 0xfffe // Machine control register. Privileged.
 )
@@ -144,19 +146,23 @@ func (mmio MMIO) DDR() Register {
 
 	if dev := mmio.devs[DDRAddr]; dev != nil {
 		val := dev.(*DisplayDriver)
+		val.mut.Lock()
 		ddr = val.handle.device.ddr
+		val.mut.Unlock()
 	}
 
 	return ddr
 }
 
-// DDR returns the value of the display status register, if it has been mapped.
+// DSR returns the value of the display status register, if it has been mapped.
 func (mmio MMIO) DSR() Word {
 	dsr := Word('⍝')
 
 	if dev := mmio.devs[DSRAddr]; dev != nil {
 		val := dev.(*DisplayDriver)
+		val.mut.Lock()
 		dsr = Word(val.handle.device.dsr)
+		val.mut.Unlock()
 	}
 
 	return dsr
@@ -168,7 +174,9 @@ func (mmio MMIO) KBDR() Word {
 
 	if dev := mmio.devs[KBDRAddr]; dev != nil {
 		val := dev.(*Keyboard)
+		val.mut.Lock()
 		kbdr = Word(val.KBDR)
+		val.mut.Unlock()
 	}
 
 	return kbdr
@@ -180,7 +188,9 @@ func (mmio MMIO) KBSR() Word {
 
 	if dev := mmio.devs[KBSRAddr]; dev != nil {
 		val := dev.(*Keyboard)
+		val.mut.Lock()
 		kbsr = Word(val.KBSR)
+		val.mut.Unlock()
 	}
 
 	return kbsr
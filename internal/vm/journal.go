@@ -0,0 +1,138 @@
+package vm
+
+// journal.go contains an execution journal for stepping backward while debugging.
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoHistory is a wrapped error returned by StepBack when there is no step to undo, either
+	// because the journal is disabled or because it is empty.
+	ErrNoHistory = errors.New("no history")
+
+	// ErrIrreversible is a wrapped error returned by StepBack when the step to undo accessed a
+	// device.
+	ErrIrreversible = errors.New("irreversible step")
+)
+
+// journal records the state changed by recently executed instructions so that they may be undone.
+type journal struct {
+	depth   int             // Maximum number of steps.
+	entries []*journalEntry // Steps, oldest first.
+}
+
+// journalEntry records the machine state before a step and the memory cells the step overwrote.
+type journalEntry struct {
+	pc        ProgramCounter
+	ir        Instruction
+	psr       ProcessorStatus
+	mcr       ControlRegister
+	usp, ssp  Register
+	reg       RegisterFile
+	mar, mdr  Register
+	haltFrame *Word
+
+	stores []journalStore // Overwritten cells, in order.
+	device bool           // Whether the step accessed a device.
+}
+
+// journalStore is a memory cell and its value before it was overwritten.
+type journalStore struct {
+	addr Word
+	old  Word
+}
+
+// WithJournal is an option function that enables an execution journal of up to depth steps so that
+// steps may be undone with StepBack. Interrupts serviced by Run after a step are journaled with the
+// step.
+func WithJournal(depth int) OptionFn {
+	return func(vm *LC3, late bool) {
+		if late && depth > 0 {
+			vm.journal = &journal{depth: depth}
+		}
+	}
+}
+
+// begin adds an entry for a step that is about to execute, discarding the oldest entry if the
+// journal is full.
+func (j *journal) begin(vm *LC3) *journalEntry {
+	if len(j.entries) == j.depth {
+		copy(j.entries, j.entries[1:])
+		j.entries = j.entries[:j.depth-1]
+	}
+
+	entry := &journalEntry{
+		pc:        vm.PC,
+		ir:        vm.IR,
+		psr:       vm.PSR,
+		mcr:       vm.MCR,
+		usp:       vm.USP,
+		ssp:       vm.SSP,
+		reg:       vm.REG,
+		mar:       vm.Mem.MAR,
+		mdr:       vm.Mem.MDR,
+		haltFrame: vm.haltFrame,
+	}
+
+	j.entries = append(j.entries, entry)
+
+	return entry
+}
+
+// last returns the most recent entry, if any.
+func (j *journal) last() *journalEntry {
+	if len(j.entries) == 0 {
+		return nil
+	}
+
+	return j.entries[len(j.entries)-1]
+}
+
+// access records a memory access at an address. Stores to memory cells are recorded with the
+// cell's value before the store. Accessing a device, other than the machine's own control and
+// status registers, makes the step irreversible.
+func (e *journalEntry) access(mem *Memory, addr Word, store bool) {
+	switch {
+	case addr == MCRAddr || addr == PSRAddr:
+	case addr >= IOPageAddr:
+		e.device = true
+	case store:
+		e.stores = append(e.stores, journalStore{addr: addr, old: mem.cell[addr]})
+	}
+}
+
+// StepBack undoes the last step: it restores registers and memory to their state before the
+// instruction, and any interrupt serviced after it, executed. The journal must be enabled with
+// WithJournal. An ErrIrreversible is returned, and the journal is cleared, if the step accessed a
+// device, since its side effects cannot be undone. Counters are not restored.
+func (vm *LC3) StepBack() error {
+	if vm.journal == nil {
+		return fmt.Errorf("step back: %w: journal disabled", ErrNoHistory)
+	}
+
+	entry := vm.journal.last()
+
+	if entry == nil {
+		return fmt.Errorf("step back: %w", ErrNoHistory)
+	} else if entry.device {
+		vm.journal.entries = nil
+		return fmt.Errorf("step back: %w: %s", ErrIrreversible, entry.pc)
+	}
+
+	vm.journal.entries = vm.journal.entries[:len(vm.journal.entries)-1]
+
+	for i := len(entry.stores) - 1; i >= 0; i-- {
+		vm.Mem.cell[entry.stores[i].addr] = entry.stores[i].old
+	}
+
+	vm.PC, vm.IR, vm.PSR, vm.MCR = entry.pc, entry.ir, entry.psr, entry.mcr
+	vm.USP, vm.SSP, vm.REG = entry.usp, entry.ssp, entry.reg
+	vm.Mem.MAR, vm.Mem.MDR = entry.mar, entry.mdr
+	vm.haltFrame = entry.haltFrame
+
+	vm.log.Debug("stepped back", "PC", vm.PC)
+
+	return nil
+}
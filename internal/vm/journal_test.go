@@ -0,0 +1,144 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLC3_StepBack(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("ADD", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = New(WithLogger(t.logger), WithSystemContext(), WithJournal(8))
+		)
+
+		cpu.PC = 0x3000
+		cpu.REG[R0] = 0x0000
+
+		for i := Word(0); i < 3; i++ {
+			_ = cpu.Mem.store(0x3000+i, 0x1021) // ADD R0,R0,#1
+		}
+
+		for i := 0; i < 3; i++ {
+			if err := cpu.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if cpu.REG[R0] != 3 {
+			t.Fatalf("R0: want: 3, got: %s", cpu.REG[R0])
+		}
+
+		for _, want := range []struct {
+			r0 Register
+			pc ProgramCounter
+		}{{2, 0x3002}, {1, 0x3001}} {
+			if err := cpu.StepBack(); err != nil {
+				t.Fatal(err)
+			} else if cpu.REG[R0] != want.r0 {
+				t.Errorf("R0: want: %s, got: %s", want.r0, cpu.REG[R0])
+			} else if cpu.PC != want.pc {
+				t.Errorf("PC: want: %s, got: %s", want.pc, cpu.PC)
+			} else if cpu.PSR.Cond() != ConditionPositive {
+				t.Errorf("COND: want: %s, got: %s", ConditionPositive, cpu.PSR.Cond())
+			}
+		}
+
+		// Stepping forward again repeats the instruction.
+		if err := cpu.Step(); err != nil {
+			t.Fatal(err)
+		} else if cpu.REG[R0] != 2 {
+			t.Errorf("R0: want: 2, got: %s", cpu.REG[R0])
+		}
+	})
+
+	tt.Run("ST", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = New(WithLogger(t.logger), WithSystemContext(), WithJournal(8))
+		)
+
+		cpu.PC = 0x3000
+		cpu.REG[R7] = 0xcafe
+
+		_ = cpu.Mem.store(0x3000, 0b0011_111_0_0000_1111) // ST R7,#15
+		_ = cpu.Mem.store(0x3010, 0x0f00)
+
+		if err := cpu.Step(); err != nil {
+			t.Fatal(err)
+		} else if err := cpu.StepBack(); err != nil {
+			t.Fatal(err)
+		}
+
+		if view := cpu.Mem.View(); view[0x3010] != 0x0f00 {
+			t.Errorf("memory: want: %s, got: %s", Word(0x0f00), view[0x3010])
+		}
+	})
+
+	tt.Run("depth", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = New(WithLogger(t.logger), WithSystemContext(), WithJournal(2))
+		)
+
+		cpu.PC = 0x3000
+
+		for i := Word(0); i < 3; i++ {
+			_ = cpu.Mem.store(0x3000+i, 0x1021) // ADD R0,R0,#1
+
+			if err := cpu.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := cpu.StepBack(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+			t.Errorf("want: %v, got: %v", ErrNoHistory, err)
+		} else if cpu.PC != 0x3001 {
+			t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3001), cpu.PC)
+		}
+	})
+
+	tt.Run("device", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = New(WithLogger(t.logger), WithSystemContext(), WithJournal(8))
+		)
+
+		cpu.PC = 0x3000
+
+		_ = cpu.Mem.store(0x3000, 0x1021)               // ADD R0,R0,#1
+		_ = cpu.Mem.store(0x3001, 0b1010_001_000000000) // LDI R1,#0
+		_ = cpu.Mem.store(0x3002, Word(KBDRAddr))
+
+		for i := 0; i < 2; i++ {
+			if err := cpu.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := cpu.StepBack(); !errors.Is(err, ErrIrreversible) {
+			t.Errorf("want: %v, got: %v", ErrIrreversible, err)
+		} else if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+			t.Errorf("want: %v, got: %v", ErrNoHistory, err)
+		}
+	})
+
+	tt.Run("disabled", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = t.Make()
+		)
+
+		if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+			t.Errorf("want: %v, got: %v", ErrNoHistory, err)
+		}
+	})
+}
@@ -1,12 +1,18 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"sync"
 )
 
 // Keyboard is a hardwired input device for typos. It is its own driver.
+//
+// The keyboard queues keys but neither echoes nor edits them: it has no notion of a line. Echo is
+// controlled by the terminal, see tty.Console.SetEcho, and programs read a line by reading keys
+// until a newline, e.g. with the GETC trap.
 type Keyboard struct {
 	// mut provides mutual exclusion for the device. It might be interesting to contrast the lock
 	// used here with the use of channels in the Display device.
@@ -20,9 +26,25 @@ type Keyboard struct {
 
 	// Keyboard Data Register.
 	KBDR Register
+
+	// buf queues keys that are pressed while the data register is full. Keys are moved to the data
+	// register, in order, as it is read.
+	buf   []uint16
+	head  int // Index of the oldest queued key.
+	count int // Number of queued keys.
+
+	// script is a source of input that is read instead of waiting for updates. When the script is
+	// exhausted, eof is set and the keyboard never again becomes ready.
+	script io.Reader
+	eof    bool
 }
 
-// Bit fields for keyboard status flags.
+// KeyboardBufferSize is the default number of keys the keyboard queues behind the data register.
+const KeyboardBufferSize = 16
+
+// Bit fields for keyboard status flags. The keyboard sets the ready flag when a key is pressed and
+// clears it when the data register is read. Programs set or clear the interrupt-enable flag by
+// writing the status register; the keyboard never changes it, except to clear it on Init.
 const (
 	KeyboardReady  = Register(1 << 15) // IR
 	KeyboardEnable = Register(1 << 14) // IE
@@ -30,25 +52,68 @@ const (
 
 // NewKeyboard creates a new keyboard device and allocates resources
 func NewKeyboard() *Keyboard {
+	return NewKeyboardWithCapacity(KeyboardBufferSize)
+}
+
+// NewKeyboardWithCapacity creates a new keyboard device that queues up to n keys behind the data
+// register, so that fast input is not lost while the program is busy. With a capacity of zero,
+// there is no queue and a key is only accepted once the previous key has been read.
+func NewKeyboardWithCapacity(n int) *Keyboard {
 	k := &Keyboard{
 		mut:  sync.Mutex{},
 		KBSR: 0x0000,
 		KBDR: Register(a[rand.Intn(len(a))]), //nolint:gosec
+		buf:  make([]uint16, max(n, 0)),
 	}
 	k.intr = sync.NewCond(&k.mut)
 
 	return k
 }
 
+// NewScriptedKeyboard creates a keyboard device that reads input from a script rather than from a
+// terminal. Each time the status register is polled and is not ready, a byte is read from the script
+// into the data register and the ready flag is set. It is intended for testing programs that read
+// input.
+func NewScriptedKeyboard(script io.Reader) *Keyboard {
+	k := NewKeyboard()
+	k.script = script
+
+	return k
+}
+
+// Script replaces the keyboard's input with a script. See NewScriptedKeyboard.
+func (k *Keyboard) Script(script io.Reader) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	k.script = script
+	k.eof = false
+}
+
+// EOF returns true if the keyboard's script has been exhausted.
+func (k *Keyboard) EOF() bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	return k.eof
+}
+
+// Keyboard interrupt configuration.
+const (
+	KeyboardPriority = PL4  // Keyboard interrupt priority.
+	KeyboardVector   = 0x80 // Keyboard interrupt vector in the ISR table.
+)
+
 // Init configures the keyboard device for use. It registers the device with the interrupt
-// controller and enables interrupts.
+// controller. Interrupts are disabled until a program sets the IE flag in the status register.
 func (k *Keyboard) Init(vm *LC3, _ []Word) {
-	isr := ISR{vector: 0xff, driver: k}
-	vm.INT.Register(PriorityNormal, isr)
+	isr := ISR{vector: KeyboardVector, driver: k}
+	vm.INT.Register(KeyboardPriority, isr)
 
 	k.mut.Lock()
-	k.KBSR = ^KeyboardReady | KeyboardEnable // Enable interrupts, clear ready flag.
-	k.KBDR = Register(a[rand.Intn(len(a))])  //nolint:gosec
+	k.KBSR = 0x0000                         // Disable interrupts, clear ready flag.
+	k.KBDR = Register(a[rand.Intn(len(a))]) //nolint:gosec
+	k.head, k.count = 0, 0                  // Discard queued keys.
 	k.mut.Unlock()
 
 	k.intr.Broadcast()
@@ -64,23 +129,59 @@ func (k *Keyboard) InterruptRequested() bool {
 }
 
 // Read returns the value of a keyboard's register. If the data register is read then the ready flag
-// is cleared.
+// is cleared, unless a queued key takes its place.
 func (k *Keyboard) Read(addr Word) (Word, error) {
 	k.mut.Lock()
 	defer k.mut.Unlock()
 
 	if addr == KBSRAddr {
+		if k.script != nil && k.KBSR&KeyboardReady == 0 {
+			k.readScript()
+		}
+
 		return Word(k.KBSR), nil
 	}
 
 	val := Word(k.KBDR)
 	k.KBDR = 0x0000
-	k.KBSR = KeyboardReady | KeyboardEnable // ??
+	k.KBSR &^= KeyboardReady // Data is consumed.
+
+	if k.count > 0 {
+		k.KBDR = Register(k.buf[k.head])
+		k.KBSR |= KeyboardReady
+		k.head = (k.head + 1) % len(k.buf)
+		k.count--
+	}
+
+	k.intr.Broadcast()
 
 	return val, nil
 }
 
-// Write updates the status keyboard status register.
+// readScript reads the next byte of input from the script, if any, and sets the ready flag. The
+// caller must hold the lock.
+func (k *Keyboard) readScript() {
+	if k.eof {
+		return
+	}
+
+	var buf [1]byte
+
+	n, err := k.script.Read(buf[:])
+
+	switch {
+	case n == 1:
+		k.KBDR = Register(buf[0])
+		k.KBSR |= KeyboardReady
+	case errors.Is(err, io.EOF):
+		k.eof = true
+	case err != nil:
+		k.eof = true // Treat errors as the end of input.
+	}
+}
+
+// Write updates the keyboard status register. Only the interrupt-enable flag is writable: the
+// ready flag is controlled by the keyboard.
 func (k *Keyboard) Write(addr Word, val Register) error {
 	if addr != KBSRAddr {
 		return fmt.Errorf("kbd: %w: %s", ErrNoDevice, addr)
@@ -89,29 +190,44 @@ func (k *Keyboard) Write(addr Word, val Register) error {
 	k.mut.Lock()
 	defer k.mut.Unlock()
 
-	enabled := (k.KBSR&KeyboardEnable == 0) && (val&KeyboardEnable != 0)
-	k.KBSR = val
-
-	if enabled && (k.KBSR&KeyboardReady != 0) {
-		k.intr.Signal()
-	}
+	k.KBSR = k.KBSR&^KeyboardEnable | val&KeyboardEnable
 
 	return nil
 }
 
-// Update blocks until the keyboard interrupt is enabled and atomically sets the data and ready
-// flag.
+// Update sets the data register and ready flag with a key or, if the data register has not yet been
+// read, queues the key in the keyboard's buffer. Update blocks while the buffer is full. If
+// interrupts are enabled, the keyboard then requests an interrupt.
 func (k *Keyboard) Update(key uint16) {
 	k.mut.Lock()
 	defer k.mut.Unlock()
 
-	for !(k.KBSR&(KeyboardEnable & ^KeyboardReady) != 0) {
+	for k.KBSR&KeyboardReady != 0 && k.count == len(k.buf) {
 		k.intr.Wait()
 	}
 
-	k.KBDR = Register(key)
-	k.KBSR |= KeyboardReady // Data is ready.
-	k.intr.Signal()
+	if k.KBSR&KeyboardReady != 0 {
+		k.buf[(k.head+k.count)%len(k.buf)] = key
+		k.count++
+	} else {
+		k.KBDR = Register(key)
+		k.KBSR |= KeyboardReady // Data is ready.
+	}
+
+	k.intr.Broadcast()
+}
+
+// Depth returns the number of keys that have not yet been read, including the key in the data
+// register.
+func (k *Keyboard) Depth() int {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if k.KBSR&KeyboardReady != 0 {
+		return k.count + 1
+	}
+
+	return k.count
 }
 
 func (k *Keyboard) String() string {
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/smoynes/elsie/internal/log"
 )
@@ -27,10 +28,13 @@ func NewLoader(vm *LC3) *Loader {
 	}
 }
 
-// Load loads the object code starting at its origin address.
+// Load loads the object code starting at its origin address. If the object has an entry point, the
+// program counter is set to it. Otherwise, the program counter is unchanged: Load also stores system
+// routines and vector-table entries, which must not move it. To start a program at its origin, use
+// WithProgram or set the program counter after loading.
 func (l *Loader) Load(obj ObjectCode) (uint16, error) {
 	if len(obj.Code) == 0 {
-		return 0, fmt.Errorf("%w: object too small", ErrObjectLoader)
+		return 0, &LoadTooShortError{}
 	}
 
 	var (
@@ -42,16 +46,90 @@ func (l *Loader) Load(obj ObjectCode) (uint16, error) {
 		err := l.vm.Mem.store(addr, code)
 
 		if err != nil {
-			return count, fmt.Errorf("%w: %w", ErrObjectLoader, err)
+			return count, &LoadRangeError{Addr: addr, Err: err}
 		}
 
 		count++
 		addr++
 	}
 
+	if obj.Entry != nil {
+		l.vm.PC = ProgramCounter(*obj.Entry)
+	}
+
+	return count, nil
+}
+
+// WithProgram is an option function that loads object code during late initialization and sets the
+// program counter to the object's entry point or, if it has none, to its origin. The machine panics
+// if the object cannot be loaded, as it does if devices cannot be mapped.
+func WithProgram(obj ObjectCode) OptionFn {
+	return func(vm *LC3, late bool) {
+		if !late {
+			return
+		}
+
+		if _, err := NewLoader(vm).Load(obj); err != nil {
+			err = fmt.Errorf("program: %w", err)
+			vm.log.Error(err.Error())
+			panic(err)
+		}
+
+		if obj.Entry == nil {
+			vm.PC = ProgramCounter(obj.Orig)
+		}
+	}
+}
+
+// LoadAll loads each object in turn and returns the total number of words loaded. Objects may not
+// overlap one another: if any do, an error is returned before anything is loaded.
+func (l *Loader) LoadAll(objs []ObjectCode) (uint16, error) {
+	for i := range objs {
+		for j := i + 1; j < len(objs); j++ {
+			if objs[i].overlaps(objs[j]) {
+				return 0, fmt.Errorf("%w: objects overlap: %s and %s",
+					ErrObjectLoader, objs[i].Orig, objs[j].Orig)
+			}
+		}
+	}
+
+	var count uint16
+
+	for i := range objs {
+		n, err := l.Load(objs[i])
+		count += n
+
+		if err != nil {
+			return count, err
+		}
+	}
+
 	return count, nil
 }
 
+// LoadBytes loads headerless, big-endian words starting at an address. Unlike object code, the data
+// does not include an origin. It returns an error, without loading anything, if the data is not a
+// whole number of words or if it would extend past the top of the address space.
+func (l *Loader) LoadBytes(addr Word, data []byte) (uint16, error) {
+	if len(data)%2 != 0 {
+		return 0, fmt.Errorf("%w: odd number of bytes: %d", ErrObjectLoader, len(data))
+	} else if int(addr)+len(data)/2 > int(AddrSpace)+1 {
+		return 0, fmt.Errorf("%w: data exceeds address space: %s + %d words",
+			ErrObjectLoader, addr, len(data)/2)
+	}
+
+	obj := ObjectCode{
+		Orig: addr,
+		Code: make([]Word, len(data)/2),
+	}
+
+	for i := range obj.Code {
+		obj.Code[i] = Word(binary.BigEndian.Uint16(data[2*i:]))
+	}
+
+	return l.Load(obj)
+}
+
 // LoadVector stores the object and sets the vector-table entry to the object's origin address.
 func (l *Loader) LoadVector(vector Word, obj ObjectCode) (uint16, error) {
 	l.log.Debug("Loading vector", "vec", vector, "obj", obj)
@@ -68,22 +146,179 @@ func (l *Loader) LoadVector(vector Word, obj ObjectCode) (uint16, error) {
 
 	// Store the object's origin address in the vector table.
 	if err = l.vm.Mem.store(vector, obj.Orig); err != nil {
-		return count, fmt.Errorf("%w: %w", ErrObjectLoader, err)
+		return count, &VectorRangeError{Vector: vector, Err: err}
 	}
 
 	return count, nil
 }
 
+// InstallVector sets an entry in a vector table, i.e. the trap table or the interrupt and exception
+// table, to the address of a handler. The entry is written by the loader and so does not depend on
+// the machine's privilege. An error is returned if the table is unknown or the vector is outside
+// the table.
+func (vm *LC3) InstallVector(table, vector, handlerAddr Word) error {
+	switch table {
+	case TrapTable, ISRTable:
+	default:
+		return fmt.Errorf("%w: unknown vector table: %s", ErrObjectLoader, table)
+	}
+
+	if vector > 0x00ff {
+		return &VectorRangeError{Vector: vector}
+	}
+
+	obj := ObjectCode{
+		Orig: table + vector,
+		Code: []Word{handlerAddr},
+	}
+
+	_, err := NewLoader(vm).Load(obj)
+
+	return err
+}
+
 // ObjectCode is a data structure that holds code and its origin offset in memory. Code may be
-// comprised of either instructions or data.
+// comprised of either instructions or data. If the object has an entry point, it is the address of
+// the first instruction to execute.
 type ObjectCode struct {
-	Orig Word
-	Code []Word
+	Orig  Word
+	Code  []Word
+	Entry *Word // Entry point, if any.
 }
 
-// Read loads an object from bytes.
+// overlaps returns true if the two objects share an address in memory.
+func (obj ObjectCode) overlaps(other ObjectCode) bool {
+	var (
+		start, end           = int(obj.Orig), int(obj.Orig) + len(obj.Code)
+		otherStart, otherEnd = int(other.Orig), int(other.Orig) + len(other.Code)
+	)
+
+	return start < otherEnd && otherStart < end
+}
+
+// Object-file header. An object file may begin with a header: the magic bytes followed by a
+// big-endian version word. The version is in the low byte and the high byte holds flags. Files
+// without the header are read as bare object code.
+const (
+	ObjectMagic   = "ELSIE\x01"
+	ObjectVersion = Word(0x0001)
+
+	// ObjectChecksum is a header flag indicating that the object code is followed by a checksum
+	// word. See ObjectCode.Checksum.
+	ObjectChecksum = Word(0x0100)
+
+	// ObjectEntry is a header flag indicating that the version is followed by the object's entry
+	// point.
+	ObjectEntry = Word(0x0200)
+)
+
+// Checksum returns the 16-bit sum of the object's origin, its length in words, its entry point, if
+// any, and its code words, ignoring overflow. The origin and length are included so that an object
+// loaded at the wrong address or missing code does not pass verification.
+func (obj ObjectCode) Checksum() Word {
+	sum := obj.Orig + Word(len(obj.Code))
+
+	if obj.Entry != nil {
+		sum += *obj.Entry
+	}
+
+	for _, code := range obj.Code {
+		sum += code
+	}
+
+	return sum
+}
+
+// WriteTo writes the object in the binary object format, without a header: the origin followed by
+// the code, each a big-endian word. The entry point, which is recorded in the header, is not
+// written.
+func (obj ObjectCode) WriteTo(w io.Writer) (int64, error) {
+	words := append([]Word{obj.Orig}, obj.Code...)
+
+	if err := binary.Write(w, binary.BigEndian, words); err != nil {
+		return 0, err
+	}
+
+	return int64(len(words) * 2), nil
+}
+
+// ReadObjectCode reads object code from a stream in the binary object format, with or without a
+// header.
+func ReadObjectCode(r io.Reader) (ObjectCode, error) {
+	obj := ObjectCode{}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return obj, fmt.Errorf("%w: %w", ErrObjectLoader, err)
+	}
+
+	if _, err := obj.read(b); err != nil {
+		return ObjectCode{}, err
+	}
+
+	return obj, nil
+}
+
+// LoadObject reads object code from bytes in the binary object format, with or without a header,
+// and loads it at its origin.
+func (l *Loader) LoadObject(b []byte) (uint16, error) {
+	obj := ObjectCode{}
+
+	if _, err := obj.read(b); err != nil {
+		return 0, err
+	}
+
+	return l.Load(obj)
+}
+
+// Read loads an object from bytes and returns the number of bytes read. If the bytes begin with the
+// object header, the header is checked and skipped. If the header has the entry flag, the entry
+// point is read and, if it has the checksum flag, the checksum trailer is verified. On error, the
+// count is zero and the object must not be used.
 func (obj *ObjectCode) read(b []byte) (int, error) {
-	var count int
+	var (
+		count    int
+		checksum *Word // Checksum trailer, if any.
+	)
+
+	if bytes.HasPrefix(b, []byte(ObjectMagic)) {
+		header := len(ObjectMagic) + 2
+
+		if len(b) < header+2 || len(b)%2 != 0 {
+			return 0, fmt.Errorf("%w: object code truncated", ErrObjectLoader)
+		}
+
+		version := Word(binary.BigEndian.Uint16(b[len(ObjectMagic):]))
+
+		if version&0x00ff != ObjectVersion {
+			return 0, fmt.Errorf("%w: object version: want: %s, got: %s",
+				ErrObjectLoader, ObjectVersion, version&0x00ff)
+		}
+
+		b = b[header:]
+		count += header
+
+		if version&ObjectEntry != 0 {
+			if len(b) < 4 {
+				return 0, fmt.Errorf("%w: object code truncated", ErrObjectLoader)
+			}
+
+			entry := Word(binary.BigEndian.Uint16(b))
+			obj.Entry = &entry
+			b = b[2:]
+			count += 2
+		}
+
+		if version&ObjectChecksum != 0 {
+			if len(b) < 4 {
+				return 0, fmt.Errorf("%w: object code truncated", ErrObjectLoader)
+			}
+
+			sum := Word(binary.BigEndian.Uint16(b[len(b)-2:]))
+			checksum = &sum
+			b = b[:len(b)-2]
+		}
+	}
 
 	if len(b) < 2 {
 		return 0, fmt.Errorf("%w: object code too small", ErrObjectLoader)
@@ -93,7 +328,7 @@ func (obj *ObjectCode) read(b []byte) (int, error) {
 	err := binary.Read(in, binary.BigEndian, &obj.Orig)
 
 	if err != nil {
-		return count, fmt.Errorf("%w: %w", ErrObjectLoader, err)
+		return 0, fmt.Errorf("%w: %w", ErrObjectLoader, err)
 	}
 
 	count += 2
@@ -102,12 +337,77 @@ func (obj *ObjectCode) read(b []byte) (int, error) {
 	err = binary.Read(in, binary.BigEndian, obj.Code)
 
 	if err != nil {
-		return count, fmt.Errorf("%w: %w", ErrObjectLoader, err)
+		return 0, fmt.Errorf("%w: %w", ErrObjectLoader, err)
 	}
 
 	count += len(obj.Code) * 2
 
+	if checksum != nil {
+		if got := obj.Checksum(); got != *checksum {
+			return 0, fmt.Errorf("%w: %w: want: %s, got: %s",
+				ErrObjectLoader, ErrChecksum, *checksum, got)
+		}
+
+		count += 2
+	}
+
 	return count, nil
 }
 
-var ErrObjectLoader = errors.New("loader error")
+var (
+	ErrObjectLoader = errors.New("loader error")
+
+	// ErrChecksum is a wrapped error returned when object code does not match its checksum, e.g.
+	// because the object file is corrupt.
+	ErrChecksum = errors.New("checksum mismatch")
+)
+
+// LoadTooShortError is returned when an object has no code to load. It wraps ErrObjectLoader.
+type LoadTooShortError struct{}
+
+func (*LoadTooShortError) Error() string {
+	return fmt.Sprintf("%s: object too small", ErrObjectLoader)
+}
+
+func (*LoadTooShortError) Unwrap() error {
+	return ErrObjectLoader
+}
+
+// LoadRangeError is returned when object code cannot be stored at an address, e.g. an unmapped
+// address in the I/O page. It wraps ErrObjectLoader and the cause.
+type LoadRangeError struct {
+	Addr Word  // Address of the failed store.
+	Err  error // Error cause.
+}
+
+func (le *LoadRangeError) Error() string {
+	return fmt.Sprintf("%s: addr: %s: %s", ErrObjectLoader, le.Addr, le.Err)
+}
+
+func (le *LoadRangeError) Unwrap() []error {
+	return []error{ErrObjectLoader, le.Err}
+}
+
+// VectorRangeError is returned when a vector-table entry cannot be stored, either because the vector
+// is outside its table or because its address cannot be stored. It wraps ErrObjectLoader and the
+// cause, if any.
+type VectorRangeError struct {
+	Vector Word  // Vector, or the address of the vector-table entry.
+	Err    error // Error cause, if any.
+}
+
+func (ve *VectorRangeError) Error() string {
+	if ve.Err == nil {
+		return fmt.Sprintf("%s: vector out of range: %s", ErrObjectLoader, ve.Vector)
+	}
+
+	return fmt.Sprintf("%s: vector: %s: %s", ErrObjectLoader, ve.Vector, ve.Err)
+}
+
+func (ve *VectorRangeError) Unwrap() []error {
+	if ve.Err == nil {
+		return []error{ErrObjectLoader}
+	}
+
+	return []error{ErrObjectLoader, ve.Err}
+}
@@ -1,9 +1,11 @@
 package vm
 
 import (
+	"bytes"
 	"errors"
 	"log/slog"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/smoynes/elsie/internal/log"
@@ -24,6 +26,7 @@ type loaderCase struct {
 	instructions []Word
 	expLoaded    uint16
 	expErr       error
+	expAs        any // Target for errors.As, if any.
 }
 
 func TestLoader_Load(tt *testing.T) {
@@ -47,11 +50,13 @@ func TestLoader_Load(tt *testing.T) {
 			Word(NewInstruction(STI, 0xdad)),
 		},
 		expErr:    ErrObjectLoader,
+		expAs:     new(*LoadRangeError),
 		expLoaded: 1,
 	}, {
 		name:         "too short",
 		instructions: []Word{},
 		expErr:       ErrObjectLoader,
+		expAs:        new(*LoadTooShortError),
 	},
 	}
 
@@ -79,11 +84,19 @@ func TestLoader_Load(tt *testing.T) {
 				t.Error("expected error:", "want:", tc.expErr, "got:", err)
 			case !errors.Is(err, tc.expErr):
 				t.Error("unexpected error:", "want", tc.expErr, "got", err)
+			case tc.expAs != nil && !errors.As(err, tc.expAs):
+				t.Errorf("unexpected error type: want: %T, got: %T", tc.expAs, err)
 			}
 
 			if loaded == 0 && err == nil {
 				t.Error("none loaded")
 			}
+
+			if rangeErr, ok := tc.expAs.(**LoadRangeError); ok && *rangeErr != nil {
+				if want := tc.origin + Word(tc.expLoaded); (*rangeErr).Addr != want {
+					t.Errorf("range error addr: want: %s, got: %s", want, (*rangeErr).Addr)
+				}
+			}
 		})
 	}
 }
@@ -111,6 +124,7 @@ func TestLoader_LoadVector(tt *testing.T) {
 			Word(NewInstruction(STI, 0xdad)),
 		},
 		expErr:    ErrObjectLoader,
+		expAs:     new(*LoadRangeError),
 		expLoaded: 0,
 	}, {
 		name:   "vector error",
@@ -122,15 +136,18 @@ func TestLoader_LoadVector(tt *testing.T) {
 			Word(NewInstruction(STI, 0xdad)),
 		},
 		expErr:    ErrObjectLoader,
+		expAs:     new(*VectorRangeError),
 		expLoaded: 3,
 	}, {
 		name:         "too short",
 		instructions: []Word{},
 		expErr:       ErrObjectLoader,
+		expAs:        new(*LoadTooShortError),
 	}, {
 		name:         "nil",
 		instructions: nil,
 		expErr:       ErrObjectLoader,
+		expAs:        new(*LoadTooShortError),
 	},
 	}
 
@@ -158,6 +175,8 @@ func TestLoader_LoadVector(tt *testing.T) {
 				t.Error("expected error:", "want:", tc.expErr, "got:", err)
 			case !errors.Is(err, tc.expErr):
 				t.Error("unexpected error:", "want", tc.expErr, "got", err)
+			case tc.expAs != nil && !errors.As(err, tc.expAs):
+				t.Errorf("unexpected error type: want: %T, got: %T", tc.expAs, err)
 			}
 
 			if loaded == 0 && err == nil {
@@ -176,6 +195,65 @@ func TestLoader_LoadVector(tt *testing.T) {
 	}
 }
 
+func TestLoader_LoadBytes(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name      string
+		addr      Word
+		data      []byte
+		expLoaded uint16
+		expErr    error
+	}{{
+		name:      "Ok",
+		addr:      0x3100,
+		data:      []byte{0xe0, 0x3b, 0xf0, 0x25},
+		expLoaded: 2,
+	}, {
+		name:   "odd length",
+		addr:   0x3100,
+		data:   []byte{0xe0, 0x3b, 0xf0},
+		expErr: ErrObjectLoader,
+	}, {
+		name:   "address range",
+		addr:   0xffff,
+		data:   []byte{0xe0, 0x3b, 0xf0, 0x25},
+		expErr: ErrObjectLoader,
+	}}
+
+	for _, tc := range tcs {
+		tc := tc
+
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := loaderHarness{tt}
+			t.Parallel()
+
+			machine := New(WithLogger(t.Logger()))
+			loader := NewLoader(machine)
+
+			loaded, err := loader.LoadBytes(tc.addr, tc.data)
+
+			if loaded != tc.expLoaded {
+				t.Errorf("Wrong loaded count: got: %d != want: %d", loaded, tc.expLoaded)
+			}
+
+			if !errors.Is(err, tc.expErr) {
+				t.Error("unexpected error:", "want", tc.expErr, "got", err)
+			}
+
+			if tc.expErr != nil {
+				return
+			}
+
+			for i, want := range []Word{0xe03b, 0xf025} {
+				if got := machine.Mem.cell[tc.addr+Word(i)]; got != want {
+					t.Errorf("cell %s: want: %s, got: %s", tc.addr+Word(i), want, got)
+				}
+			}
+		})
+	}
+}
+
 type objectCase struct {
 	name      string
 	bytes     []byte
@@ -202,6 +280,34 @@ func TestObjectCode(t *testing.T) {
 				Instruction(0x5678).Encode(),
 			},
 		},
+	}, {
+		name: "header",
+		bytes: []byte{
+			'E', 'L', 'S', 'I', 'E', 0x01,
+			0x00, 0x01,
+			0x40, 0x00,
+			0x12, 0x34,
+		},
+		expRead: 12,
+		expObject: ObjectCode{
+			Orig: Word(0x4000),
+			Code: []Word{Instruction(0x1234).Encode()},
+		},
+	}, {
+		name: "truncated header",
+		bytes: []byte{
+			'E', 'L', 'S', 'I', 'E', 0x01,
+			0x00, 0x01,
+		},
+		expErr: ErrObjectLoader,
+	}, {
+		name: "version mismatch",
+		bytes: []byte{
+			'E', 'L', 'S', 'I', 'E', 0x01,
+			0x00, 0x02,
+			0x40, 0x00,
+		},
+		expErr: ErrObjectLoader,
 	}, {
 		name:   "too short",
 		bytes:  nil,
@@ -250,3 +356,319 @@ func TestObjectCode(t *testing.T) {
 		})
 	}
 }
+
+func TestObjectCode_WriteTo(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	obj := ObjectCode{
+		Orig: 0x3000,
+		Code: []Word{0x1234, 0x5678, 0xf025},
+	}
+
+	var buf bytes.Buffer
+
+	if n, err := obj.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	} else if n != 8 {
+		t.Errorf("written: want: 8, got: %d", n)
+	}
+
+	want := []byte{0x30, 0x00, 0x12, 0x34, 0x56, 0x78, 0xf0, 0x25}
+
+	if !bytes.Equal(want, buf.Bytes()) {
+		t.Errorf("bytes: want: %x, got: %x", want, buf.Bytes())
+	}
+
+	got, err := ReadObjectCode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if got.Orig != obj.Orig {
+		t.Errorf("origin: want: %s, got: %s", obj.Orig, got.Orig)
+	} else if !slices.Equal(got.Code, obj.Code) {
+		t.Errorf("code: want: %v, got: %v", obj.Code, got.Code)
+	}
+
+	if _, err := ReadObjectCode(bytes.NewReader(nil)); !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("empty: want: %v, got: %v", ErrObjectLoader, err)
+	}
+}
+
+func TestLoader_LoadEntry(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	machine := New(WithLogger(t.Logger()))
+	loader := NewLoader(machine)
+
+	obj := ObjectCode{
+		Orig: 0x3100,
+		Code: []Word{
+			Word(NewInstruction(LEA, 0o73)),
+			Word(NewInstruction(TRAP, 0x25)),
+		},
+	}
+
+	if _, err := loader.Load(obj); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != ProgramCounter(UserSpaceAddr) {
+		t.Errorf("PC: want: %s, got: %s", UserSpaceAddr, machine.PC)
+	}
+
+	entry := Word(0x3101)
+	obj.Entry = &entry
+
+	if _, err := loader.Load(obj); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x3101 {
+		t.Errorf("PC: want: 0x3101, got: %s", machine.PC)
+	}
+}
+
+func TestLoader_LoadAll(tt *testing.T) {
+	tt.Parallel()
+
+	code := []Word{
+		Word(NewInstruction(LEA, 0o73)),
+		Word(NewInstruction(TRAP, 0x25)),
+	}
+
+	tt.Run("separate", func(tt *testing.T) {
+		t := loaderHarness{tt}
+		t.Parallel()
+
+		machine := New(WithLogger(t.Logger()))
+		loader := NewLoader(machine)
+
+		objs := []ObjectCode{
+			{Orig: 0x3000, Code: code},
+			{Orig: 0x3002, Code: code},
+		}
+
+		loaded, err := loader.LoadAll(objs)
+		if err != nil {
+			t.Fatal(err)
+		} else if loaded != 4 {
+			t.Errorf("loaded: want: 4, got: %d", loaded)
+		}
+
+		view := machine.Mem.View()
+
+		for _, addr := range []Word{0x3000, 0x3002} {
+			if view[addr] != code[0] || view[addr+1] != code[1] {
+				t.Errorf("%s: want: %v, got: %v", addr, code, view[addr:addr+2])
+			}
+		}
+	})
+
+	tt.Run("overlapping", func(tt *testing.T) {
+		t := loaderHarness{tt}
+		t.Parallel()
+
+		machine := New(WithLogger(t.Logger()))
+		loader := NewLoader(machine)
+
+		objs := []ObjectCode{
+			{Orig: 0x3000, Code: code},
+			{Orig: 0x3001, Code: code},
+		}
+
+		loaded, err := loader.LoadAll(objs)
+		if !errors.Is(err, ErrObjectLoader) {
+			t.Errorf("want: %v, got: %v", ErrObjectLoader, err)
+		} else if loaded != 0 {
+			t.Errorf("loaded: want: 0, got: %d", loaded)
+		}
+
+		if view := machine.Mem.View(); view[0x3000] != 0 {
+			t.Errorf("memory: want: 0x0000, got: %s", view[0x3000])
+		}
+	})
+}
+
+func TestLoader_LoadObject(tt *testing.T) {
+	tt.Parallel()
+
+	code := []byte{0x30, 0x00, 0xf0, 0x25}
+
+	tcs := map[string][]byte{
+		"legacy":   code,
+		"headered": append([]byte(ObjectMagic+"\x00\x01"), code...),
+	}
+
+	for name, data := range tcs {
+		data := data
+
+		tt.Run(name, func(tt *testing.T) {
+			t := loaderHarness{tt}
+			t.Parallel()
+
+			machine := New(WithLogger(t.Logger()))
+			loader := NewLoader(machine)
+
+			loaded, err := loader.LoadObject(data)
+			if err != nil {
+				t.Fatal(err)
+			} else if loaded != 1 {
+				t.Errorf("loaded: want: 1, got: %d", loaded)
+			}
+
+			if view := machine.Mem.View(); view[0x3000] != 0xf025 {
+				t.Errorf("memory: want: 0xf025, got: %s", view[0x3000])
+			}
+		})
+	}
+}
+
+func TestLC3_InstallVector(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	machine := New(WithLogger(t.Logger()))
+
+	if err := machine.InstallVector(TrapTable, 0x40, 0x4000); err != nil {
+		t.Fatal(err)
+	}
+
+	trap := ObjectCode{
+		Orig: 0x3000,
+		Code: []Word{Word(NewInstruction(TRAP, 0x40))},
+	}
+
+	if _, err := NewLoader(machine).Load(trap); err != nil {
+		t.Fatal(err)
+	}
+
+	machine.PC = 0x3000
+
+	if err := machine.Step(); err != nil {
+		t.Fatal(err)
+	} else if machine.PC != 0x4000 {
+		t.Errorf("PC: want: 0x4000, got: %s", machine.PC)
+	}
+
+	var vecErr *VectorRangeError
+
+	if err := machine.InstallVector(0x0200, 0x40, 0x4000); !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("table: want: %v, got: %v", ErrObjectLoader, err)
+	} else if err := machine.InstallVector(TrapTable, 0x100, 0x4000); !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("vector: want: %v, got: %v", ErrObjectLoader, err)
+	} else if !errors.As(err, &vecErr) || vecErr.Vector != 0x100 {
+		t.Errorf("vector: want: %T, got: %#v", vecErr, err)
+	}
+}
+
+func TestLoader_LoadObjectChecksum(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	obj := []byte{
+		'E', 'L', 'S', 'I', 'E', 0x01,
+		0x01, 0x01, // Version, with checksum flag.
+		0x30, 0x00, // .ORIG x3000
+		0xe0, 0x02, // LEA R0,#2
+		0xf0, 0x25, // HALT
+		0x00, 0x29, // Checksum.
+	}
+
+	machine := New(WithLogger(t.Logger()))
+
+	if count, err := NewLoader(machine).LoadObject(obj); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("count: want: 2, got: %d", count)
+	}
+
+	corrupt := slices.Clone(obj)
+	corrupt[11] = 0x03 // LEA R0,#3
+
+	machine = New(WithLogger(t.Logger()))
+
+	if _, err := NewLoader(machine).LoadObject(corrupt); !errors.Is(err, ErrChecksum) {
+		t.Errorf("want: %v, got: %v", ErrChecksum, err)
+	} else if !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("want: %v, got: %v", ErrObjectLoader, err)
+	} else if view := machine.Mem.View(); view[0x3000] == 0xe003 {
+		t.Errorf("corrupt code loaded: %s", view[0x3000])
+	}
+
+	// The origin is covered by the checksum, too.
+	corrupt = slices.Clone(obj)
+	corrupt[8] = 0x40 // .ORIG x4000
+
+	var moved ObjectCode
+
+	if count, err := moved.read(corrupt); !errors.Is(err, ErrChecksum) {
+		t.Errorf("origin: want: %v, got: %v", ErrChecksum, err)
+	} else if count != 0 {
+		t.Errorf("origin: count: want: 0, got: %d", count)
+	}
+
+	if _, err := ReadObjectCode(bytes.NewReader(obj[:10])); !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("truncated: want: %v, got: %v", ErrObjectLoader, err)
+	}
+}
+
+func TestLoader_LoadObjectEntry(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	obj := []byte{
+		'E', 'L', 'S', 'I', 'E', 0x01,
+		0x02, 0x01, // Version, with entry flag.
+		0x00, 0x00, // Entry point: x0000.
+		0x00, 0x00, // .ORIG x0000
+		0xf0, 0x25, // HALT
+	}
+
+	machine := New(WithLogger(t.Logger()))
+
+	if count, err := NewLoader(machine).LoadObject(obj); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("count: want: 1, got: %d", count)
+	} else if machine.PC != 0x0000 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x0000), machine.PC)
+	}
+
+	if _, err := ReadObjectCode(bytes.NewReader(obj[:10])); !errors.Is(err, ErrObjectLoader) {
+		t.Errorf("truncated: want: %v, got: %v", ErrObjectLoader, err)
+	}
+}
+
+func TestWithProgram(tt *testing.T) {
+	t := loaderHarness{tt}
+	t.Parallel()
+
+	obj := ObjectCode{
+		Orig: 0x3100,
+		Code: []Word{
+			Word(NewInstruction(LEA, 0o73)),
+			Word(NewInstruction(TRAP, 0x25)),
+		},
+	}
+
+	machine := New(WithLogger(t.Logger()), WithProgram(obj))
+
+	if view := machine.Mem.View(); !slices.Equal(obj.Code, view[0x3100:0x3102]) {
+		t.Errorf("memory: want: %v, got: %v", obj.Code, view[0x3100:0x3102])
+	} else if machine.PC != 0x3100 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3100), machine.PC)
+	}
+
+	entry := Word(0x3101)
+	obj.Entry = &entry
+	machine = New(WithLogger(t.Logger()), WithProgram(obj))
+
+	if machine.PC != 0x3101 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3101), machine.PC)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic loading empty object")
+		}
+	}()
+
+	_ = New(WithLogger(t.Logger()), WithProgram(ObjectCode{Orig: 0x3000}))
+}
@@ -1,6 +1,10 @@
 package vm
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/smoynes/elsie/internal/log"
 )
 
@@ -43,6 +47,24 @@ func (vm *LC3) LogValue() log.Value {
 	)
 }
 
+// traceStep logs an executed instruction, disassembled, and the general-purpose registers it
+// changed.
+func (vm *LC3) traceStep(pc Word, before RegisterFile) {
+	deltas := make([]string, 0, len(vm.REG))
+
+	for r := range vm.REG {
+		if vm.REG[r] != before[r] {
+			deltas = append(deltas, fmt.Sprintf("%s:%s->%s", GPR(r), before[r], vm.REG[r]))
+		}
+	}
+
+	vm.log.Log(context.Background(), log.Trace, "traced instruction",
+		"PC", pc,
+		"ASM", vm.IR.Disassemble(),
+		"REG", strings.Join(deltas, " "),
+	)
+}
+
 func (mmio *MMIO) WithLogger(logger *log.Logger) {
 	mmio.log = logger.With("subsystem", "IO")
 
@@ -26,6 +26,22 @@ type Memory struct {
 	// Memory-mapped device registers.
 	Devices MMIO
 
+	// Counts of memory accesses.
+	fetches, stores uint64
+
+	// Cycles spent accessing memory and the cost of each access.
+	cycles, latency uint64
+
+	// Watched addresses and the most recent store to one of them.
+	watch map[Word]struct{}
+	hit   *WatchpointError
+
+	// Value of uninitialized memory cells, if poisoned.
+	poison *Word
+
+	// Journal entry of the step being executed, if journaled.
+	entry *journalEntry
+
 	log *log.Logger
 }
 
@@ -49,6 +65,8 @@ func NewMemory(psr *ProcessorStatus) Memory {
 		MDR: 0x0ff0,
 
 		cell: PhysicalMemory{},
+
+		latency: 1,
 		Devices: MMIO{
 			devs: make(map[Word]any),
 			log:  log.DefaultLogger(),
@@ -74,11 +92,18 @@ func (mem *Memory) Fetch() error {
 		return fmt.Errorf("%w: fetch: %w", memErr, ErrAccessControl)
 	}
 
+	if mem.entry != nil {
+		mem.entry.access(mem, Word(mem.MAR), false)
+	}
+
 	err := mem.load(Word(mem.MAR), &mem.MDR)
 	if err != nil {
 		return fmt.Errorf("%w: fetch: %w", memErr, err)
 	}
 
+	mem.fetches++
+	mem.cycles += mem.latency
+
 	return nil
 }
 
@@ -92,14 +117,65 @@ func (mem *Memory) Store() error {
 		return fmt.Errorf("%w: store: %w", ErrMemory, ErrAccessControl)
 	}
 
-	err := mem.store(Word(mem.MAR), Word(mem.MDR))
+	addr := Word(mem.MAR)
+	_, watched := mem.watch[addr]
+
+	var old Register
+
+	// Device registers are not read: reading one may change the device's state, e.g. clear the
+	// keyboard's ready flag.
+	if watched && addr < IOPageAddr {
+		old = Register(mem.cell[addr])
+	}
+
+	if mem.entry != nil {
+		mem.entry.access(mem, addr, true)
+	}
+
+	err := mem.store(addr, Word(mem.MDR))
 	if err != nil {
 		return fmt.Errorf("%w: store: %w", ErrMemory, err)
 	}
 
+	mem.stores++
+	mem.cycles += mem.latency
+
+	if watched {
+		mem.hit = &WatchpointError{Addr: addr, Old: Word(old), New: Word(mem.MDR)}
+	}
+
 	return nil
 }
 
+// fill sets every memory cell to the poison value, if any, or to zero.
+func (mem *Memory) fill() {
+	if mem.poison == nil {
+		mem.cell = PhysicalMemory{}
+		return
+	}
+
+	for i := range mem.cell {
+		mem.cell[i] = *mem.poison
+	}
+}
+
+// poisoned returns true if a fetched word is the poison value, i.e. if it was not initialized.
+func (mem *Memory) poisoned(addr Word, word Word) bool {
+	return mem.poison != nil && addr < IOPageAddr && word == *mem.poison
+}
+
+// WithMemoryPoison is an option function that fills memory with a poison value, e.g. 0xdead, before
+// anything is loaded. When the machine fetches an instruction that is the poison value, it logs a
+// warning: the program has probably jumped to uninitialized memory. Reset poisons memory again.
+func WithMemoryPoison(poison Word) OptionFn {
+	return func(vm *LC3, late bool) {
+		if !late {
+			vm.Mem.poison = &poison
+			vm.Mem.fill()
+		}
+	}
+}
+
 // View returns a copy of the memory cells. It is intended as a debugging and
 // development tool and is quite expensive computationally.
 func (mem *Memory) View() PhysicalMemory {
@@ -140,7 +216,7 @@ func (mem *Memory) store(addr Word, cell Word) error {
 func (mem *Memory) privileged() bool {
 	return (Word(mem.MAR) < UserSpaceAddr ||
 		Word(mem.MAR) == MCRAddr ||
-		Word(mem.MDR) == PSRAddr)
+		Word(mem.MAR) == PSRAddr)
 }
 
 // MemeoryErrors are returned to provide the address if a wrapped ErrMemory.
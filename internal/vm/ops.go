@@ -133,8 +133,7 @@ func (op *and) Decode(vm *LC3) {
 }
 
 func (op *and) Execute() {
-	op.vm.REG[op.dest] = op.vm.REG[op.sr1]
-	op.vm.REG[op.dest] &= op.vm.REG[op.sr2]
+	op.vm.REG[op.dest] = op.vm.REG[op.sr1] & op.vm.REG[op.sr2]
 	op.vm.PSR.Set(op.vm.REG[op.dest])
 }
 
@@ -199,8 +198,11 @@ func (op *add) String() string {
 }
 
 func (op *add) Execute() {
-	op.vm.REG[op.dr] = Register(int16(op.vm.REG[op.sr1]) + int16(op.vm.REG[op.sr2]))
+	a, b := int16(op.vm.REG[op.sr1]), int16(op.vm.REG[op.sr2])
+
+	op.vm.REG[op.dr] = Register(a + b)
 	op.vm.PSR.Set(op.vm.REG[op.dr])
+	op.checkOverflow(a, b)
 }
 
 type addImm struct {
@@ -231,6 +233,15 @@ func (op *addImm) Execute() {
 
 	op.vm.REG[op.dr] = Register(operand + lit)
 	op.vm.PSR.Set(op.vm.REG[op.dr])
+	op.checkOverflow(operand, lit)
+}
+
+// checkOverflow fails the operation if the machine traps overflow and the signed sum of a and b
+// overflows, i.e. the operands have the same sign and the sum does not. The sum wraps regardless.
+func (op *mo) checkOverflow(a, b int16) {
+	if sum := a + b; op.vm.overflowTrap && (a < 0) == (b < 0) && (sum < 0) != (a < 0) {
+		op.Fail(fmt.Errorf("%w: %d + %d", ErrOverflow, a, b))
+	}
 }
 
 // LD: Load word from memory.
@@ -693,7 +704,7 @@ func (op rti) String() string {
 }
 
 func (op *rti) Decode(vm *LC3) {
-	op.vm = vm
+	*op = rti{mo: mo{vm: vm}}
 }
 
 func (op *rti) Execute() {
@@ -725,6 +736,11 @@ func (op *rti) Execute() {
 
 	op.vm.PSR = ProcessorStatus(op.vm.Mem.MDR)
 
+	// Returning past the HALT trap's frame means the trap returned without halting.
+	if frame := op.vm.haltFrame; frame != nil && Word(op.vm.REG[SP]) > *frame {
+		op.vm.haltFrame = nil
+	}
+
 	if op.vm.PSR.Privilege() == PrivilegeUser {
 		// When dropping privileges, swap system and user stacks.
 		op.vm.SSP = op.vm.REG[SP]
@@ -775,7 +791,7 @@ func (op resv) String() string {
 var _ executable = &resv{}
 
 func (op *resv) Decode(vm *LC3) {
-	op.vm = vm
+	*op = resv{mo: mo{vm: vm}}
 }
 
 func (op *resv) Execute() {
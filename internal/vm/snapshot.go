@@ -0,0 +1,136 @@
+package vm
+
+// snapshot.go saves and restores the state of the machine.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSnapshot is a wrapped error returned when a snapshot cannot be saved or loaded.
+var ErrSnapshot = errors.New("snapshot error")
+
+// SnapshotVersion is the version of the snapshot format written by SaveSnapshot.
+const SnapshotVersion uint16 = 1
+
+// snapshotMagic identifies snapshot files.
+var snapshotMagic = [4]byte{'L', 'C', '3', 'S'}
+
+// snapshot is the binary layout of a snapshot. All fields are written big-endian, in order: a
+// header, the CPU registers, and the contents of physical memory.
+type snapshot struct {
+	Magic   [4]byte
+	Version uint16
+
+	PC, IR, PSR, MCR, USP, SSP Word
+	REG                        [NumGPR]Word
+
+	Mem PhysicalMemory
+}
+
+// SaveSnapshot writes the machine's registers and memory to an output stream. Device state, e.g.
+// the display and keyboard, is not saved.
+func (vm *LC3) SaveSnapshot(w io.Writer) error {
+	snap := snapshot{
+		Magic:   snapshotMagic,
+		Version: SnapshotVersion,
+		PC:      Word(vm.PC),
+		IR:      Word(vm.IR),
+		PSR:     Word(vm.PSR),
+		MCR:     Word(vm.MCR),
+		USP:     Word(vm.USP),
+		SSP:     Word(vm.SSP),
+		Mem:     vm.Mem.cell,
+	}
+
+	for i := range vm.REG {
+		snap.REG[i] = Word(vm.REG[i])
+	}
+
+	if err := binary.Write(w, binary.BigEndian, &snap); err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot creates a new machine and restores its registers and memory from a snapshot written
+// by SaveSnapshot. Options are applied as they are by New, before the snapshot is restored. Devices
+// are initialized as they are for a new machine.
+func LoadSnapshot(r io.Reader, opts ...OptionFn) (*LC3, error) {
+	snap := new(snapshot)
+
+	if err := binary.Read(r, binary.BigEndian, snap); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSnapshot, err)
+	} else if snap.Magic != snapshotMagic {
+		return nil, fmt.Errorf("%w: not a snapshot", ErrSnapshot)
+	} else if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version: %d", ErrSnapshot, snap.Version)
+	}
+
+	vm := New(opts...)
+
+	vm.PC = ProgramCounter(snap.PC)
+	vm.IR = Instruction(snap.IR)
+	vm.PSR = ProcessorStatus(snap.PSR)
+	vm.MCR = ControlRegister(snap.MCR)
+	vm.USP = Register(snap.USP)
+	vm.SSP = Register(snap.SSP)
+	vm.Mem.cell = snap.Mem
+
+	for i := range snap.REG {
+		vm.REG[i] = Register(snap.REG[i])
+	}
+
+	return vm, nil
+}
+
+// state is the JSON layout of the machine's registers. Words are written as hex strings.
+type state struct {
+	PC  string         `json:"pc"`
+	IR  string         `json:"ir"`
+	PSR psrState       `json:"psr"`
+	MCR string         `json:"mcr"`
+	USP string         `json:"usp"`
+	SSP string         `json:"ssp"`
+	REG [NumGPR]string `json:"reg"`
+}
+
+// psrState is the JSON layout of the processor status register, with its flags decoded.
+type psrState struct {
+	Value     string `json:"value"`
+	Privilege string `json:"privilege"`
+	Priority  uint8  `json:"priority"`
+	N         bool   `json:"n"`
+	Z         bool   `json:"z"`
+	P         bool   `json:"p"`
+}
+
+// StateJSON returns the machine's registers as indented JSON, e.g. to compare with expected state
+// in tests. Unlike a snapshot, it is human-readable and omits memory.
+func (vm *LC3) StateJSON() ([]byte, error) {
+	st := state{
+		PC: Word(vm.PC).String(),
+		IR: Word(vm.IR).String(),
+		PSR: psrState{
+			Value:     Word(vm.PSR).String(),
+			Privilege: vm.PSR.Privilege().String(),
+			Priority:  uint8(vm.PSR.Priority()),
+			N:         vm.PSR.Negative(),
+			Z:         vm.PSR.Zero(),
+			P:         vm.PSR.Positive(),
+		},
+		MCR: Word(vm.MCR).String(),
+		USP: Word(vm.USP).String(),
+		SSP: Word(vm.SSP).String(),
+	}
+
+	for i := range vm.REG {
+		st.REG[i] = Word(vm.REG[i]).String()
+	}
+
+	return json.MarshalIndent(st, "", "  ")
+}
@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	program := []Word{
+		0x5260, // AND R1,R1,#0
+		0x1265, // ADD R1,R1,#5
+		0x3201, // ST R1,#1
+		0x127f, // ADD R1,R1,#-1
+		0x0000, // (data)
+	}
+
+	for i, code := range program {
+		_ = cpu.Mem.store(0x3000+Word(i), code)
+	}
+
+	cpu.PC = 0x3000
+
+	for i := 0; i < 3; i++ {
+		if err := cpu.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		buf  bytes.Buffer
+		want = *cpu
+	)
+
+	if err := cpu.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mutate the machine after the snapshot.
+	if err := cpu.Step(); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = cpu.Mem.store(0x3004, 0xdead)
+
+	restored, err := LoadSnapshot(&buf, WithLogger(t.logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.PC != want.PC || restored.IR != want.IR || restored.PSR != want.PSR ||
+		restored.MCR != want.MCR || restored.USP != want.USP || restored.SSP != want.SSP {
+		t.Errorf("registers: want:\n%s\ngot:\n%s", &want, restored)
+	}
+
+	if restored.REG != want.REG {
+		t.Errorf("REG: want:\n%s\ngot:\n%s", want.REG, restored.REG)
+	}
+
+	if restored.Mem.cell != want.Mem.cell {
+		t.Error("memory differs")
+	}
+
+	if got := restored.Mem.cell[0x3004]; got != 0x0005 {
+		t.Errorf("stored value: want: %s, got: %s", Word(0x0005), got)
+	}
+}
+
+func TestSnapshot_Errors(tt *testing.T) {
+	t := NewTestHarness(tt)
+
+	if _, err := LoadSnapshot(bytes.NewReader([]byte("LC3S"))); !errors.Is(err, ErrSnapshot) {
+		t.Errorf("short: want: %v, got: %v", ErrSnapshot, err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := t.Make().SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	data[5] = 0xff // Version.
+
+	if _, err := LoadSnapshot(bytes.NewReader(data)); !errors.Is(err, ErrSnapshot) {
+		t.Errorf("version: want: %v, got: %v", ErrSnapshot, err)
+	}
+
+	data[0] = 'X' // Magic.
+
+	if _, err := LoadSnapshot(bytes.NewReader(data)); !errors.Is(err, ErrSnapshot) {
+		t.Errorf("magic: want: %v, got: %v", ErrSnapshot, err)
+	}
+}
+
+func TestLC3_StateJSON(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cpu.StateJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(want), got) {
+		t.Errorf("state:\nwant: %s\ngot:  %s", want, got)
+	}
+}
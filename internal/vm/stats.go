@@ -0,0 +1,66 @@
+package vm
+
+// stats.go contains counters for measuring the cost of running programs.
+
+// stats counts executed instructions. Memory accesses are counted by the memory controller.
+type stats struct {
+	instructions uint64
+	opcodes      map[Opcode]uint64
+	cycles       uint64
+}
+
+// instructionStages is the number of cycles each instruction spends in the stages of the instruction
+// cycle, not counting memory accesses: fetch, decode, evaluate address, fetch operands, execute and
+// store result.
+const instructionStages = 6
+
+// count records an instruction that has been fetched and decoded.
+func (s *stats) count(op Opcode) {
+	if s.opcodes == nil {
+		s.opcodes = make(map[Opcode]uint64)
+	}
+
+	s.instructions++
+	s.opcodes[op]++
+	s.cycles += instructionStages
+}
+
+// InstructionCount returns the number of instructions the machine has executed, including those in
+// service routines.
+func (vm *LC3) InstructionCount() uint64 {
+	return vm.stats.instructions
+}
+
+// OpcodeCounts returns the number of executed instructions by opcode.
+func (vm *LC3) OpcodeCounts() map[Opcode]uint64 {
+	counts := make(map[Opcode]uint64, len(vm.stats.opcodes))
+
+	for op, n := range vm.stats.opcodes {
+		counts[op] = n
+	}
+
+	return counts
+}
+
+// MemoryAccesses returns the number of memory fetches and stores through the address and data
+// registers, i.e. the memory cycles used by instruction fetches, operands, stores, and stack and
+// vector-table accesses while handling interrupts.
+func (vm *LC3) MemoryAccesses() (fetches, stores uint64) {
+	return vm.Mem.fetches, vm.Mem.stores
+}
+
+// CycleCount returns the number of clock cycles the machine has used: one for each stage of each
+// executed instruction plus the latency of each memory access. See WithMemoryLatency.
+func (vm *LC3) CycleCount() uint64 {
+	return vm.stats.cycles + vm.Mem.cycles
+}
+
+// WithMemoryLatency is an option function that sets the number of cycles each memory access costs.
+// The default is one cycle.
+func WithMemoryLatency(cycles uint64) OptionFn {
+	return func(vm *LC3, late bool) {
+		if !late {
+			vm.Mem.latency = cycles
+		}
+	}
+}
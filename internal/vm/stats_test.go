@@ -0,0 +1,139 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestInstructionCount(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	const n = 5 // Loop iterations.
+
+	program := []Word{
+		0x5260, // AND R1,R1,#0
+		0x1265, // ADD R1,R1,#5
+		0xf030, // LOOP: TRAP x30
+		0x127f, // ADD R1,R1,#-1
+		0x03fd, // BRp LOOP
+	}
+	handler := []Word{
+		0x14a1, // ADD R2,R2,#1
+		0x8000, // RTI
+	}
+
+	for i, code := range program {
+		_ = cpu.Mem.store(0x3000+Word(i), code)
+	}
+
+	for i, code := range handler {
+		_ = cpu.Mem.store(0x1000+Word(i), code)
+	}
+
+	_ = cpu.Mem.store(0x0030, 0x1000)
+
+	cpu.PC = 0x3000
+	cpu.REG[SP] = 0x2e00
+	cpu.REG[R2] = 0
+
+	if cpu.InstructionCount() != 0 {
+		t.Errorf("want: 0, got: %d", cpu.InstructionCount())
+	}
+
+	for cpu.PC != 0x3005 {
+		if err := cpu.Step(); err != nil {
+			t.Fatal(err)
+		} else if cpu.InstructionCount() > 1000 {
+			t.Fatalf("runaway program: PC: %s", cpu.PC)
+		}
+	}
+
+	// Two instructions to initialize, then each iteration executes the trap, its two-instruction
+	// handler and two instructions to loop.
+	if want, got := uint64(2+n*5), cpu.InstructionCount(); want != got {
+		t.Errorf("instructions: want: %d, got: %d", want, got)
+	}
+
+	want := map[Opcode]uint64{
+		AND:  1,
+		ADD:  1 + n + n,
+		TRAP: n,
+		RTI:  n,
+		BR:   n,
+	}
+	got := cpu.OpcodeCounts()
+
+	for op := range want {
+		if want[op] != got[op] {
+			t.Errorf("opcode: %s: want: %d, got: %d", op, want[op], got[op])
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("opcodes: want: %v, got: %v", want, got)
+	}
+
+	// Each instruction is fetched from memory. Each trap also fetches its vector and pushes the PC
+	// and PSR to the stack, which RTI then pops.
+	fetches, stores := cpu.MemoryAccesses()
+
+	if want := uint64(2+n*5) + n + 2*n; fetches != want {
+		t.Errorf("fetches: want: %d, got: %d", want, fetches)
+	}
+
+	if want := uint64(2 * n); stores != want {
+		t.Errorf("stores: want: %d, got: %d", want, stores)
+	}
+
+	if cpu.REG[R2] != n {
+		t.Errorf("R2: want: %d, got: %s", n, cpu.REG[R2])
+	}
+}
+
+func TestCycleCount(tt *testing.T) {
+	t := NewTestHarness(tt)
+
+	// cycles executes a single instruction and returns the number of cycles it used.
+	cycles := func(ins Word, opts ...OptionFn) uint64 {
+		cpu := New(append([]OptionFn{WithLogger(t.logger), WithSystemContext()}, opts...)...)
+
+		_ = cpu.Mem.store(0x3000, ins)
+		_ = cpu.Mem.store(0x3001, 0x3002) // Pointer.
+		_ = cpu.Mem.store(0x3002, 0x1234)
+
+		cpu.PC = 0x3000
+
+		if cpu.CycleCount() != 0 {
+			t.Errorf("initial cycles: want: 0, got: %d", cpu.CycleCount())
+		}
+
+		if err := cpu.Step(); err != nil {
+			t.Fatal(err)
+		}
+
+		return cpu.CycleCount()
+	}
+
+	const (
+		ld  = 0x2000 // LD R0,#0
+		ldi = 0xa000 // LDI R0,#0
+	)
+
+	if got, want := cycles(ld), uint64(instructionStages+2); got != want {
+		t.Errorf("LD: want: %d, got: %d", want, got)
+	}
+
+	if ldCycles, ldiCycles := cycles(ld), cycles(ldi); ldiCycles <= ldCycles {
+		t.Errorf("LDI: want: more than %d, got: %d", ldCycles, ldiCycles)
+	}
+
+	ldCycles, ldiCycles := cycles(ld, WithMemoryLatency(10)), cycles(ldi, WithMemoryLatency(10))
+
+	if want := uint64(instructionStages + 2*10); ldCycles != want {
+		t.Errorf("LD latency: want: %d, got: %d", want, ldCycles)
+	} else if want := ldCycles + 10; ldiCycles != want {
+		t.Errorf("LDI latency: want: %d, got: %d", want, ldiCycles)
+	}
+}
@@ -4,6 +4,7 @@ package vm
 
 import (
 	"fmt"
+	"strconv"
 )
 
 // Word is the base data type on which the CPU operates. Registers, memory
@@ -14,6 +15,21 @@ func (w Word) String() string {
 	return fmt.Sprintf("%0#4x", uint16(w))
 }
 
+// Int16 interprets the word as a two's complement, signed integer.
+func (w Word) Int16() int16 {
+	return int16(w)
+}
+
+// Uint16 interprets the word as an unsigned integer.
+func (w Word) Uint16() uint16 {
+	return uint16(w)
+}
+
+// SignedString formats the word as a signed, decimal integer, e.g. -3 for 0xfffd.
+func (w Word) SignedString() string {
+	return strconv.Itoa(int(w.Int16()))
+}
+
 // Sext sign-extends the lower n bits in-place.
 func (w *Word) Sext(n uint8) {
 	// Maybe this deserves an explanation. 😬
@@ -113,12 +129,12 @@ func (i Instruction) SR() GPR {
 
 // SR1 returns the first register operand from the instruction.
 func (i Instruction) SR1() GPR {
-	return GPR(i & 0x01d0 >> 6)
+	return GPR(i & 0x01c0 >> 6)
 }
 
 // SR2 returns the second register operand from the instruction.
 func (i Instruction) SR2() GPR {
-	return GPR(i & 0x0003)
+	return GPR(i & 0x0007)
 }
 
 // Imm returns true if the immediate-mode flag is set in the instruction
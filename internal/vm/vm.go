@@ -3,7 +3,9 @@ package vm
 // vm.go defines the virtual machine and assembles it from smaller parts.
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/smoynes/elsie/internal/log"
@@ -21,6 +23,23 @@ type LC3 struct {
 	INT Interrupt       // Interrupt Line.
 	Mem Memory          // All the memory you'll ever need!
 
+	stats stats      // Execution counters.
+	ops   operations // Decoded operations.
+
+	stepListener func(pc Word, machine *LC3) // Called after each instruction.
+
+	resetVector *Word // Address of the reset vector, if any.
+	booted      bool  // Whether the reset vector has been followed since reset.
+
+	overflowTrap bool // Whether signed overflow is an error.
+
+	stackLimits *stackLimits // Lowest addresses the stacks may use, if limited.
+
+	halt      HaltReason // Why the machine last stopped running.
+	haltFrame *Word      // System stack pointer on entry to the HALT trap, if it was taken.
+
+	journal *journal // Execution journal, if enabled.
+
 	log *log.Logger // A record of where we've been.
 }
 
@@ -53,6 +72,9 @@ func New(opts ...OptionFn) *LC3 {
 		display       = NewDisplay()
 		displayDriver = NewDisplayDriver(display)
 
+		// The counter reads the machine's own statistics.
+		counter = NewCounter(&vm)
+
 		// Device configuration for memory-mapped I/O.
 		devices = map[Word]any{
 			MCRAddr:  &vm.MCR,
@@ -61,6 +83,8 @@ func New(opts ...OptionFn) *LC3 {
 			KBDRAddr: kbd,
 			DSRAddr:  displayDriver,
 			DDRAddr:  displayDriver,
+			CNTLAddr: counter,
+			CNTHAddr: counter,
 		}
 	)
 
@@ -83,10 +107,7 @@ func New(opts ...OptionFn) *LC3 {
 	kbd.Init(&vm, nil)                                // Keyboard needs no configuration.
 	displayDriver.Init(&vm, []Word{DSRAddr, DDRAddr}) // Configure the display's address range.
 
-	// Drop privileges and switch to user execution context.
-	vm.PSR &^= (StatusPrivilege & StatusUser)
-	vm.PSR |= (StatusPriority & StatusNormal) // Debatable.
-	vm.REG[SP] = vm.USP
+	vm.dropPrivileges()
 
 	// Run late init...
 	for _, fn := range opts {
@@ -96,6 +117,73 @@ func New(opts ...OptionFn) *LC3 {
 	return &vm
 }
 
+// Reset reinitializes the machine without reallocating it or remapping its devices, which is
+// expensive and may conflict with existing mappings.
+//
+// Reset clears:
+//
+//   - the CPU registers, i.e. PC, IR, PSR, stack pointers and general-purpose registers, to their
+//     initial values;
+//   - the MCR, so that the machine is running;
+//   - memory, including any loaded system image, and the MAR and MDR; poisoned memory is
+//     poisoned again;
+//   - the instruction, memory-access and cycle counters;
+//   - the reason the machine last halted;
+//   - the execution journal, if enabled.
+//
+// If the machine has a reset vector, it is followed again when the machine next runs.
+//
+// Reset preserves device mappings and the state of the devices themselves, registered interrupts,
+// watchpoints and the logger. Options given to New are not applied again.
+func (vm *LC3) Reset() {
+	vm.initializeRegisters()
+
+	vm.Mem.MAR = 0xffff
+	vm.Mem.MDR = 0x0ff0
+	vm.Mem.fill()
+	vm.Mem.fetches, vm.Mem.stores = 0, 0
+	vm.Mem.cycles = 0
+	vm.Mem.hit = nil
+
+	vm.stats = stats{}
+	vm.booted = false
+	vm.halt = HaltNone
+	vm.haltFrame = nil
+
+	if vm.journal != nil {
+		vm.journal.entries = nil
+	}
+
+	vm.dropPrivileges()
+}
+
+// boot follows the reset vector, if any, by loading the entry address from the vector into PC.
+func (vm *LC3) boot() error {
+	if vm.booted || vm.resetVector == nil {
+		return nil
+	}
+
+	var entry Register
+
+	if err := vm.Mem.load(*vm.resetVector, &entry); err != nil {
+		return fmt.Errorf("reset: %w", err)
+	}
+
+	vm.booted = true
+	vm.PC = ProgramCounter(entry)
+
+	vm.log.Debug("reset", "VECTOR", *vm.resetVector, "PC", vm.PC)
+
+	return nil
+}
+
+// dropPrivileges switches to the user execution context.
+func (vm *LC3) dropPrivileges() {
+	vm.PSR &^= (StatusPrivilege & StatusUser)
+	vm.PSR |= (StatusPriority & StatusNormal) // Debatable.
+	vm.REG[SP] = vm.USP
+}
+
 func (vm *LC3) String() string {
 	return fmt.Sprintf("PC:  %s IR:  %s \nPSR: %s\nUSP: %s SSP: %s MCR: %s\n"+
 		"MAR: %s MDR: %s",
@@ -132,8 +220,34 @@ func (vm *LC3) initializeRegisters() {
 	})
 }
 
-// PushStack pushes a word onto the current stack.
+// ErrStackOverflow is a wrapped error returned when pushing a word onto a stack would cross the
+// stack's limit. See WithStackLimits.
+var ErrStackOverflow = errors.New("stack overflow")
+
+// stackLimits are the lowest addresses that the user and system stacks may use.
+type stackLimits struct {
+	user, system Word
+}
+
+// WithStackLimits is an option function that limits the growth of the user and system stacks. Each
+// limit is the lowest address its stack may use. Pushing a word below it, e.g. by a runaway series
+// of interrupts, returns an ErrStackOverflow rather than overwriting memory below the stack or
+// wrapping the stack pointer into the I/O page. By default, stacks are not limited.
+func WithStackLimits(user, system Word) OptionFn {
+	return func(vm *LC3, late bool) {
+		if late {
+			vm.stackLimits = &stackLimits{user: user, system: system}
+		}
+	}
+}
+
+// PushStack pushes a word onto the current stack. If the stack is limited and the push would cross
+// the limit, an ErrStackOverflow is returned and the stack is unchanged.
 func (vm *LC3) PushStack(w Word) error {
+	if err := vm.stackRoom(1); err != nil {
+		return err
+	}
+
 	vm.REG[SP]--
 	vm.Mem.MAR = vm.REG[SP]
 	vm.Mem.MDR = Register(w)
@@ -141,6 +255,26 @@ func (vm *LC3) PushStack(w Word) error {
 	return vm.Mem.Store()
 }
 
+// stackRoom returns an ErrStackOverflow if the stack is limited and pushing n words onto the
+// current stack would cross the limit.
+func (vm *LC3) stackRoom(n int) error {
+	if vm.stackLimits == nil {
+		return nil
+	}
+
+	limit := vm.stackLimits.user
+	if vm.PSR.Privilege() == PrivilegeSystem {
+		limit = vm.stackLimits.system
+	}
+
+	if sp := Word(vm.REG[SP]); int(sp)-n < int(limit) {
+		return fmt.Errorf("push: %w: %s stack: SP: %s, limit: %s",
+			ErrStackOverflow, vm.PSR.Privilege(), sp, limit)
+	}
+
+	return nil
+}
+
 // PopStack pops a word from the current stack into MDR.
 func (vm *LC3) PopStack() error {
 	vm.REG[SP]++
@@ -299,6 +433,27 @@ func WithSystemContext() OptionFn {
 	}
 }
 
+// WithResetVector is an option function that configures the machine to boot from a reset vector,
+// i.e. the address of a word that holds the address of the first instruction. Rather than starting
+// at the bottom of user space, Run loads PC from the vector before executing the first instruction,
+// and again after Reset. The vector is typically ResetVectorAddr and is written by a system image.
+func WithResetVector(addr Word) OptionFn {
+	return func(vm *LC3, late bool) {
+		if late {
+			vm.resetVector = &addr
+		}
+	}
+}
+
+// WithOverflowTrap is an option function that makes signed overflow in ADD an error: Step returns
+// an ErrOverflow, after storing the wrapped sum. By default, addition wraps silently, as it does on
+// real hardware.
+func WithOverflowTrap() OptionFn {
+	return func(vm *LC3, late bool) {
+		vm.overflowTrap = true
+	}
+}
+
 // WithDisplay is an option function that configures a callback that is called for displayed words.
 // It uses late initialization under the assumption startup output is not listened for.
 func WithDisplayListener(listener func(uint16)) OptionFn {
@@ -309,3 +464,43 @@ func WithDisplayListener(listener func(uint16)) OptionFn {
 		}
 	}
 }
+
+// WithSynchronousIO is an option function that makes device I/O deterministic for testing. Display
+// listeners are called, and the display becomes ready, before the instruction that writes the
+// display completes, rather than from another goroutine. Since listeners are called while the
+// driver is locked, they must not call the driver. Keyboard interrupt requests are already polled
+// synchronously between instructions and are unaffected.
+func WithSynchronousIO() OptionFn {
+	return func(vm *LC3, late bool) {
+		if late {
+			driver := vm.Mem.Devices.Get(DDRAddr).(*DisplayDriver)
+
+			driver.mut.Lock()
+			defer driver.mut.Unlock()
+
+			driver.sync = true
+		}
+	}
+}
+
+// WithStepListener is an option function that configures a callback that is called after each
+// instruction is executed, with the address of the instruction. The listener may inspect, but should
+// not modify, the machine.
+func WithStepListener(listener func(pc Word, machine *LC3)) OptionFn {
+	return func(vm *LC3, late bool) {
+		if late {
+			vm.stepListener = listener
+		}
+	}
+}
+
+// WithKeyboardScript is an option function that configures the keyboard to read input from a
+// script. See NewScriptedKeyboard.
+func WithKeyboardScript(script io.Reader) OptionFn {
+	return func(vm *LC3, late bool) {
+		if late {
+			kbd := vm.Mem.Devices.Get(KBSRAddr).(*Keyboard)
+			kbd.Script(script)
+		}
+	}
+}
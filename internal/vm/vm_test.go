@@ -1,9 +1,15 @@
 package vm
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRESV(tt *testing.T) {
@@ -223,6 +229,26 @@ func TestInstructions(tt *testing.T) {
 		}
 	})
 
+	tt.Run("AND, DR is SR2", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = t.Make()
+		)
+
+		_ = cpu.Mem.store(Word(cpu.PC), 0b0101_001_000_0_00_001)
+		cpu.REG[R0] = 0x5aff
+		cpu.REG[R1] = 0x00f0
+
+		err := cpu.Step()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if cpu.REG[R1] != 0x00f0 {
+			t.Errorf("R1 incorrect, want: %0#4x, got: %0#4x", 0x00f0, cpu.REG[R1])
+		}
+	})
+
 	tt.Run("ANDIMM", func(tt *testing.T) {
 		var (
 			t   = NewTestHarness(tt)
@@ -1035,6 +1061,148 @@ func TestInstructions(tt *testing.T) {
 	})
 }
 
+func TestInstruction_Registers(tt *testing.T) {
+	tt.Parallel()
+
+	for r := R0; r <= R7; r++ {
+		r := r
+
+		tt.Run(r.String(), func(tt *testing.T) {
+			t := NewTestHarness(tt)
+
+			// ADD DR,SR1,SR2 with every other register field set to ones, so that a mask that
+			// strays outside the field would be caught.
+			ins := Instruction(0x1000 | 0x0e00 | Word(r)<<6 | 0x0007)
+			if got := ins.SR1(); got != r {
+				t.Errorf("SR1: %s: want: %s, got: %s", ins, r, got)
+			}
+
+			ins = Instruction(0x1000 | 0x0e00 | 0x01c0 | Word(r))
+			if got := ins.SR2(); got != r {
+				t.Errorf("SR2: %s: want: %s, got: %s", ins, r, got)
+			}
+
+			ins = Instruction(0x1000 | Word(r)<<9 | 0x01c0 | 0x0007)
+			if got := ins.DR(); got != r {
+				t.Errorf("DR: %s: want: %s, got: %s", ins, r, got)
+			}
+		})
+	}
+}
+
+func TestMemory_AccessControl(tt *testing.T) {
+	tt.Parallel()
+
+	for _, addr := range []Word{PSRAddr, MCRAddr, 0x0100} {
+		addr := addr
+
+		tt.Run(addr.String(), func(tt *testing.T) {
+			var (
+				t   = NewTestHarness(tt)
+				cpu = t.Make()
+			)
+
+			cpu.PSR = StatusUser | StatusNormal
+
+			cpu.Mem.MAR = Register(addr)
+			if err := cpu.Mem.Fetch(); !errors.Is(err, ErrAccessControl) {
+				t.Errorf("fetch: want: %v, got: %v", ErrAccessControl, err)
+			}
+
+			cpu.Mem.MAR = Register(addr)
+			cpu.Mem.MDR = 0x0000
+			if err := cpu.Mem.Store(); !errors.Is(err, ErrAccessControl) {
+				t.Errorf("store: want: %v, got: %v", ErrAccessControl, err)
+			}
+		})
+	}
+
+	tt.Run("data", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = t.Make()
+		)
+
+		cpu.PSR = StatusUser | StatusNormal
+
+		// A data value that happens to equal a privileged address is not privileged.
+		cpu.Mem.MAR = 0x3000
+		cpu.Mem.MDR = Register(PSRAddr)
+		if err := cpu.Mem.Store(); err != nil {
+			t.Errorf("store: %v", err)
+		}
+
+		cpu.Mem.MAR = 0x3000
+		if err := cpu.Mem.Fetch(); err != nil {
+			t.Errorf("fetch: %v", err)
+		} else if cpu.Mem.MDR != Register(PSRAddr) {
+			t.Errorf("fetch: want: %s, got: %s", Word(PSRAddr), cpu.Mem.MDR)
+		}
+	})
+}
+
+func TestMemory_Poison(tt *testing.T) {
+	var (
+		t      = NewTestHarness(tt)
+		logs   = new(strings.Builder)
+		logger = slog.New(slog.NewTextHandler(logs, nil))
+		cpu    = New(WithLogger(logger), WithMemoryPoison(0xdead))
+	)
+
+	if _, err := NewLoader(cpu).Load(ObjectCode{
+		Orig: 0x3000,
+		Code: []Word{0xc040}, // JMP R1
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu.REG[R1] = 0x4000
+
+	if err := cpu.Step(); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(logs.String(), "uninitialized") {
+		t.Errorf("unexpected warning: %s", logs)
+	}
+
+	_ = cpu.Step() // Executes RESV, i.e. 0xdead, and raises an exception.
+
+	if !strings.Contains(logs.String(), "level=WARN msg=\"fetched uninitialized memory\" PC=0x4000") {
+		t.Errorf("expected warning: %s", logs)
+	}
+}
+
+func TestOpcode_String(tt *testing.T) {
+	tt.Parallel()
+
+	want := []string{
+		"BR", "ADD", "LD", "ST", "JSR", "AND", "LDR", "STR",
+		"RTI", "NOT", "LDI", "STI", "JMP", "RESV", "LEA", "TRAP",
+	}
+
+	for op := Opcode(0); op <= TRAP; op++ {
+		if got := op.String(); got != want[op] {
+			tt.Errorf("opcode %d: want: %s, got: %s", uint16(op), want[op], got)
+		}
+
+		if got := NewInstruction(op, 0).Opcode().String(); got != want[op] {
+			tt.Errorf("instruction opcode %d: want: %s, got: %s", uint16(op), want[op], got)
+		}
+	}
+
+	// RET is not an opcode, but a JMP through R7, which the disassembler distinguishes.
+	ret := NewInstruction(JMP, uint16(RETP)<<6)
+
+	if got := ret.Opcode().String(); got != "JMP" {
+		tt.Errorf("RET opcode: want: JMP, got: %s", got)
+	} else if got := ret.Disassemble(); got != "RET" {
+		tt.Errorf("RET: want: RET, got: %s", got)
+	}
+
+	if got := Opcode(16).String(); got != "Opcode(16)" {
+		tt.Errorf("invalid opcode: want: Opcode(16), got: %s", got)
+	}
+}
+
 func TestSext(tt *testing.T) {
 	tt.Parallel()
 
@@ -1125,3 +1293,405 @@ func TestSext(tt *testing.T) {
 		})
 	}
 }
+
+func TestWord_Signed(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		have     Word
+		signed   int16
+		unsigned uint16
+		str      string
+	}{
+		{have: 0x0003, signed: 3, unsigned: 3, str: "3"},
+		{have: 0xfffd, signed: -3, unsigned: 0xfffd, str: "-3"},
+		{have: 0x0000, signed: 0, unsigned: 0, str: "0"},
+	}
+
+	for _, tc := range tcs {
+		tt.Run(tc.have.String(), func(tt *testing.T) {
+			t := NewTestHarness(tt)
+
+			if got := tc.have.Int16(); got != tc.signed {
+				t.Errorf("Int16: want: %d, got: %d", tc.signed, got)
+			}
+
+			if got := tc.have.Uint16(); got != tc.unsigned {
+				t.Errorf("Uint16: want: %d, got: %d", tc.unsigned, got)
+			}
+
+			if got := tc.have.SignedString(); got != tc.str {
+				t.Errorf("SignedString: want: %q, got: %q", tc.str, got)
+			}
+		})
+	}
+}
+
+func TestReset(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	program := []Word{
+		0xf025, // TRAP HALT
+	}
+	halt := []Word{
+		0x5020, // AND R0,R0,#0
+		0xb000, // STI R0,MCR
+		Word(MCRAddr),
+	}
+
+	for i, code := range program {
+		_ = cpu.Mem.store(0x3000+Word(i), code)
+	}
+
+	for i, code := range halt {
+		_ = cpu.Mem.store(0x1000+Word(i), code)
+	}
+
+	_ = cpu.Mem.store(TrapTable+0x25, 0x1000)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := cpu.Run(ctx); err != nil {
+		t.Fatal(err)
+	} else if cpu.MCR.Running() {
+		t.Fatal("expected machine to halt")
+	}
+
+	cpu.Reset()
+
+	if cpu.PC != ProgramCounter(UserSpaceAddr) {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(UserSpaceAddr), cpu.PC)
+	}
+
+	if !cpu.MCR.Running() {
+		t.Errorf("MCR: want running, got: %s", cpu.MCR.String())
+	}
+
+	if cpu.InstructionCount() != 0 {
+		t.Errorf("instructions: want: 0, got: %d", cpu.InstructionCount())
+	}
+
+	var cell Register
+	if err := cpu.Mem.load(0x3000, &cell); err != nil {
+		t.Error(err)
+	} else if cell != 0 {
+		t.Errorf("memory not cleared: %s", cell)
+	}
+
+	// Devices remain mapped to the machine's registers.
+	if mcr, err := cpu.Mem.Devices.Load(MCRAddr); err != nil {
+		t.Error(err)
+	} else if mcr != Register(cpu.MCR) {
+		t.Errorf("MCR device: want: %s, got: %s", Register(cpu.MCR), mcr)
+	}
+}
+
+func TestRunN(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	const limit = 100
+
+	_ = cpu.Mem.store(0x3000, 0x0fff) // LOOP: BRnzp LOOP
+
+	cpu.PC = 0x3000
+	cpu.PSR |= StatusZero
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := cpu.RunN(ctx, limit)
+
+	if !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("want: %s, got: %v", ErrStepLimit, err)
+	} else if got := cpu.InstructionCount(); got != limit {
+		t.Errorf("instructions: want: %d, got: %d", limit, got)
+	} else if cpu.PC != 0x3000 {
+		t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3000), cpu.PC)
+	}
+}
+
+func TestStep_ExecError(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	// Fetching from system space in user mode is an access control violation.
+	cpu.PC = 0x0200
+	cpu.PSR = StatusUser | StatusNormal
+
+	err := cpu.Step()
+
+	var execErr *ExecError
+
+	if !errors.As(err, &execErr) {
+		t.Fatalf("want: ExecError, got: %#v", err)
+	} else if execErr.PC != 0x0200 {
+		t.Errorf("PC: want: %s, got: %s", Word(0x0200), execErr.PC)
+	}
+
+	if !errors.Is(err, ErrAccessControl) {
+		t.Errorf("want: %v, got: %v", ErrAccessControl, err)
+	}
+}
+
+func TestLC3_DecodeReuse(tt *testing.T) {
+	var (
+		t   = NewTestHarness(tt)
+		cpu = t.Make()
+	)
+
+	for word := 0; word <= 0xffff; word++ {
+		cpu.IR = Instruction(word)
+
+		// Dirty the reused operation before decoding it again.
+		cpu.Decode().Fail(errors.New("dirty"))
+		reused := cpu.Decode()
+
+		fresh := reflect.New(reflect.TypeOf(reused).Elem()).Interface().(operation)
+		fresh.Decode(cpu)
+
+		if !reflect.DeepEqual(reused, fresh) {
+			t.Fatalf("decode: %s: want: %#v, got: %#v", Word(word), fresh, reused)
+		}
+	}
+}
+
+func BenchmarkLC3_Step(b *testing.B) {
+	cpu := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	_ = cpu.Mem.store(0x3000, Word(NewInstruction(BR, 0x0fff))) // BRnzp #-1
+	cpu.PC = 0x3000
+	cpu.PSR |= StatusZero
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := cpu.Step(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLC3_ResetVector(tt *testing.T) {
+	tt.Parallel()
+
+	run := func(t *testHarness, cpu *LC3) {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := cpu.RunN(ctx, 1); !errors.Is(err, ErrStepLimit) {
+			t.Fatalf("run: want: %v, got: %v", ErrStepLimit, err)
+		}
+	}
+
+	tt.Run("vectored", func(tt *testing.T) {
+		t := NewTestHarness(tt)
+		cpu := New(WithLogger(t.logger), WithResetVector(ResetVectorAddr))
+
+		_ = cpu.Mem.store(ResetVectorAddr, 0x4000)
+		run(t, cpu)
+
+		if cpu.PC != 0x4001 {
+			t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x4001), cpu.PC)
+		}
+
+		// Continuing does not follow the vector again.
+		run(t, cpu)
+
+		if cpu.PC != 0x4002 {
+			t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x4002), cpu.PC)
+		}
+
+		// Reset does.
+		cpu.Reset()
+		_ = cpu.Mem.store(ResetVectorAddr, 0x5000)
+		run(t, cpu)
+
+		if cpu.PC != 0x5001 {
+			t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x5001), cpu.PC)
+		}
+	})
+
+	tt.Run("no vector", func(tt *testing.T) {
+		t := NewTestHarness(tt)
+		cpu := New(WithLogger(t.logger))
+
+		_ = cpu.Mem.store(ResetVectorAddr, 0x4000)
+		run(t, cpu)
+
+		if cpu.PC != 0x3001 {
+			t.Errorf("PC: want: %s, got: %s", ProgramCounter(0x3001), cpu.PC)
+		}
+	})
+}
+
+func TestADD_Overflow(tt *testing.T) {
+	tt.Parallel()
+
+	tcs := []struct {
+		name string
+		trap bool
+		ins  Word
+	}{
+		{"wrap register", false, 0x1001},  // ADD R0,R0,R1
+		{"wrap immediate", false, 0x1021}, // ADD R0,R0,#1
+		{"trap register", true, 0x1001},
+		{"trap immediate", true, 0x1021},
+	}
+
+	for _, tc := range tcs {
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := NewTestHarness(tt)
+			opts := []OptionFn{WithLogger(t.logger)}
+
+			if tc.trap {
+				opts = append(opts, WithOverflowTrap())
+			}
+
+			cpu := New(opts...)
+			_ = cpu.Mem.store(0x3000, tc.ins)
+			cpu.PC = 0x3000
+			cpu.REG[R0] = 0x7fff
+			cpu.REG[R1] = 0x0001
+
+			err := cpu.Step()
+
+			if cpu.REG[R0] != 0x8000 {
+				t.Errorf("R0: want: %s, got: %s", Register(0x8000), cpu.REG[R0])
+			}
+
+			switch {
+			case tc.trap && !errors.Is(err, ErrOverflow):
+				t.Errorf("want: %v, got: %v", ErrOverflow, err)
+			case !tc.trap && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLC3_LastHalt(tt *testing.T) {
+	tt.Parallel()
+
+	// The handler clears MCR: AND R0,R0,#0; STI R0,#0; .FILL xfffe.
+	handler := ObjectCode{Orig: 0x1000, Code: []Word{0x5020, 0xb000, Word(MCRAddr)}}
+
+	tcs := []struct {
+		name   string
+		ins    Word
+		opts   []OptionFn
+		cancel bool
+		want   HaltReason
+	}{
+		{name: "trap", ins: 0xf025, want: HaltTrap},            // TRAP x25
+		{name: "mcr", ins: 0xf030, want: HaltMCR},              // TRAP x30
+		{name: "step limit", ins: 0x0fff, want: HaltStepLimit}, // BRnzp #-1
+		{name: "cancelled", ins: 0x0fff, cancel: true, want: HaltCancelled},
+		{
+			name: "error",
+			ins:  0x1021, // ADD R0,R0,#1
+			opts: []OptionFn{WithOverflowTrap()},
+			want: HaltError,
+		},
+	}
+
+	for _, tc := range tcs {
+		tt.Run(tc.name, func(tt *testing.T) {
+			t := NewTestHarness(tt)
+			cpu := New(append([]OptionFn{WithLogger(t.logger)}, tc.opts...)...)
+
+			if _, err := NewLoader(cpu).Load(handler); err != nil {
+				t.Fatal(err)
+			} else if err := cpu.InstallVector(TrapTable, Word(TrapHALT), handler.Orig); err != nil {
+				t.Fatal(err)
+			} else if err := cpu.InstallVector(TrapTable, 0x30, handler.Orig); err != nil {
+				t.Fatal(err)
+			}
+
+			_ = cpu.Mem.store(0x3000, tc.ins)
+			cpu.PC = 0x3000
+			cpu.PSR |= StatusZero
+			cpu.REG[R0] = 0x7fff
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if tc.cancel {
+				cancel()
+			}
+
+			if cpu.LastHalt() != HaltNone {
+				t.Errorf("before run: want: %s, got: %s", HaltNone, cpu.LastHalt())
+			}
+
+			_ = cpu.RunN(ctx, 10)
+
+			if got := cpu.LastHalt(); got != tc.want {
+				t.Errorf("halt: want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLC3_StackLimits(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("user", func(tt *testing.T) {
+		t := NewTestHarness(tt)
+		cpu := New(WithLogger(t.logger), WithStackLimits(0xfdfe, 0x2000))
+
+		cpu.PSR |= StatusUser
+		cpu.REG[SP] = 0xfe00
+
+		for i := 0; i < 2; i++ {
+			if err := cpu.PushStack(Word(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := cpu.PushStack(0xdead); !errors.Is(err, ErrStackOverflow) {
+			t.Errorf("want: %v, got: %v", ErrStackOverflow, err)
+		} else if cpu.REG[SP] != 0xfdfe {
+			t.Errorf("SP: want: %s, got: %s", Register(0xfdfe), cpu.REG[SP])
+		}
+	})
+
+	tt.Run("interrupt", func(tt *testing.T) {
+		t := NewTestHarness(tt)
+		cpu := New(WithLogger(t.logger), WithStackLimits(0x3000, 0x2fff))
+
+		_ = cpu.Mem.store(0x3000, 0xf025) // TRAP x25
+		_ = cpu.Mem.store(0x2ffe, 0x0f00)
+
+		cpu.PC = 0x3000
+		cpu.PSR |= StatusUser
+		cpu.REG[SP] = 0xfe00
+		cpu.SSP = 0x3000
+
+		// The trap pushes PSR and PC onto the system stack, but there is only room for one word.
+		if err := cpu.Step(); !errors.Is(err, ErrStackOverflow) {
+			t.Errorf("want: %v, got: %v", ErrStackOverflow, err)
+		} else if view := cpu.Mem.View(); view[0x2ffe] != 0x0f00 {
+			t.Errorf("memory below limit: want: %s, got: %s", Word(0x0f00), view[0x2ffe])
+		} else if view[0x2fff] != 0x0000 {
+			t.Errorf("partial frame: want: %s, got: %s", Word(0x0000), view[0x2fff])
+		}
+
+		// Neither word of the frame was pushed.
+		if cpu.REG[SP] != 0x3000 || cpu.SSP != 0x3000 {
+			t.Errorf("SP: want: %s, SSP: %s, got: %s, %s",
+				Register(0x3000), Register(0x3000), cpu.REG[SP], cpu.SSP)
+		}
+	})
+}
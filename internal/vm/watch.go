@@ -0,0 +1,57 @@
+package vm
+
+// watch.go contains watchpoints for debugging memory writes.
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWatchpoint is a wrapped error returned when a watched address is written.
+var ErrWatchpoint = errors.New("watchpoint")
+
+// WatchpointError is returned by Step after an instruction stores to a watched address. The store
+// completes before the error is returned so the machine's state may be inspected and execution
+// resumed.
+type WatchpointError struct {
+	Addr Word // Watched address.
+	Old  Word // Value before the store; zero for device registers, which are not read.
+	New  Word // Value after the store.
+}
+
+func (we *WatchpointError) Error() string {
+	return fmt.Sprintf("%s: addr: %s, old: %s, new: %s", ErrWatchpoint, we.Addr, we.Old, we.New)
+}
+
+func (we *WatchpointError) Is(err error) bool {
+	if err == ErrWatchpoint {
+		return true
+	} else if _, ok := err.(*WatchpointError); ok {
+		return true
+	} else {
+		return false
+	}
+}
+
+// WatchWrite sets a watchpoint on an address. Any store to the address, including to device
+// registers in the I/O page, causes Step to return a WatchpointError.
+func (vm *LC3) WatchWrite(addr Word) {
+	if vm.Mem.watch == nil {
+		vm.Mem.watch = make(map[Word]struct{})
+	}
+
+	vm.Mem.watch[addr] = struct{}{}
+}
+
+// UnwatchWrite removes a watchpoint from an address.
+func (vm *LC3) UnwatchWrite(addr Word) {
+	delete(vm.Mem.watch, addr)
+}
+
+// watched returns the watchpoint hit since the last call, if any, and clears it.
+func (mem *Memory) watched() *WatchpointError {
+	hit := mem.hit
+	mem.hit = nil
+
+	return hit
+}
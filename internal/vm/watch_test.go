@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWatchWrite(tt *testing.T) {
+	tt.Parallel()
+
+	tt.Run("ST", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = t.Make()
+		)
+
+		cpu.PC = 0x0400
+		cpu.REG[R7] = 0xcafe
+
+		_ = cpu.Mem.store(Word(cpu.PC), 0b0011_111_0_1000_0000)   // ST R7,#0x80
+		_ = cpu.Mem.store(Word(cpu.PC+1), 0b0011_111_0_1000_0000) // ST R7,#0x80
+		_ = cpu.Mem.store(Word(0x0481), 0x0f00)
+
+		cpu.WatchWrite(0x0481)
+
+		err := cpu.Step()
+
+		var watchErr *WatchpointError
+
+		if !errors.Is(err, ErrWatchpoint) {
+			t.Fatalf("want: %s, got: %v", ErrWatchpoint, err)
+		} else if !errors.As(err, &watchErr) {
+			t.Fatalf("want: %T, got: %T", watchErr, err)
+		}
+
+		if watchErr.Addr != 0x0481 || watchErr.Old != 0x0f00 || watchErr.New != 0xcafe {
+			t.Errorf("want: addr: %s old: %s new: %s, got: %s",
+				Word(0x0481), Word(0x0f00), Word(0xcafe), watchErr)
+		}
+
+		// The store completes before the watchpoint is raised.
+		var val Register
+		_ = cpu.Mem.load(Word(0x0481), &val)
+
+		if val != 0xcafe {
+			t.Errorf("Mem[%s] want: %s, got: %s", Word(0x0481), Word(0xcafe), val)
+		} else if cpu.PC != 0x0401 {
+			t.Errorf("PC want: %s, got: %s", ProgramCounter(0x0401), cpu.PC)
+		}
+
+		// Stores elsewhere do not raise the watchpoint.
+		cpu.UnwatchWrite(0x0481)
+
+		if err := cpu.Step(); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	tt.Run("I/O page", func(tt *testing.T) {
+		var (
+			t   = NewTestHarness(tt)
+			cpu = New(WithLogger(t.logger), WithKeyboardScript(strings.NewReader("a")))
+		)
+
+		cpu.PC = 0x0400
+		cpu.REG[R0] = 0x0000
+
+		_ = cpu.Mem.store(Word(cpu.PC), 0b1011_000_0_0000_0000) // STI R0,#0
+		_ = cpu.Mem.store(Word(0x0401), Word(KBSRAddr))
+
+		cpu.WatchWrite(KBSRAddr)
+
+		err := cpu.Step()
+
+		var watchErr *WatchpointError
+
+		if !errors.As(err, &watchErr) {
+			t.Fatalf("want: %T, got: %v", watchErr, err)
+		} else if watchErr.Addr != KBSRAddr || watchErr.Old != 0x0000 || watchErr.New != 0x0000 {
+			t.Errorf("unexpected watchpoint: %s", watchErr)
+		}
+
+		// The status register was not read, so the keyboard has not read its script.
+		if kbsr := cpu.Mem.Devices.KBSR(); kbsr != 0x0000 {
+			t.Errorf("KBSR: want: %s, got: %s", Word(0x0000), kbsr)
+		}
+	})
+}
@@ -6,7 +6,10 @@
 //
 // Commands:
 //   - exec
+//   - run
+//   - debug
 //   - asm
+//   - fmt
 //   - demo
 //   - help
 package main // import "github.com/smoynes/elsie"
@@ -21,7 +24,10 @@ import (
 
 var commands = []cli.Command{
 	cmd.Executor(),
+	cmd.Runner(),
+	cmd.Debugger(),
 	cmd.Assembler(),
+	cmd.Formatter(),
 	cmd.Demo(),
 }
 